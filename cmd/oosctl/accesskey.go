@@ -0,0 +1,109 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/teamssix/oos-go-sdk/oos"
+)
+
+func cmdAccessKey(a *app, args []string) error {
+	if len(args) < 1 {
+		return errUsage
+	}
+	fs := a.newFlags("ak " + args[0])
+	user := fs.String("user", "", "IAM user name, the caller by default")
+	maxItems := fs.Int("max", 100, "max keys to list")
+	out := fs.String("out", "", "rotate: JSON file the new key is written to")
+	stateDir := fs.String("state-dir", ".", "rotate: directory of the rotation state file")
+	idle := fs.Duration("idle", oos.DefaultRotationIdlePeriod, "rotate: the old key must be unused for the period before it's deactivated")
+	deleteAfter := fs.Duration("delete-after", 24*time.Hour, "rotate: grace period between the deactivation and the deletion")
+	if err := fs.Parse(args[1:]); err != nil {
+		return errUsage
+	}
+	client, err := a.iamClient()
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "create":
+		out, err := client.CreateAccessKey(*user)
+		if err != nil {
+			return err
+		}
+		key := out.CreateAccessKeyResult.AcessKey
+		return a.print(key, func(w io.Writer) {
+			fmt.Fprintf(w, "AccessKeyId:     %s\nSecretAccessKey: %s\nStatus:          %s\n", key.AccessKeyId, key.SecretAccessKey, key.Status)
+		})
+	case "ls":
+		out, err := client.ListAccessKey(*maxItems, "", *user)
+		if err != nil {
+			return err
+		}
+		members := out.ListAccessKeysResult.MemberList
+		return a.print(members, func(w io.Writer) {
+			for _, m := range members {
+				fmt.Fprintf(w, "%s  %-8s  primary=%t  %s\n", m.AccessKeyId, m.Status, m.IsPrimary, m.UserName)
+			}
+		})
+	}
+
+	if fs.NArg() != 1 {
+		return errUsage
+	}
+	id := fs.Arg(0)
+	switch args[0] {
+	case "delete":
+		if _, err = client.DeleteAccessKey(id, *user); err != nil {
+			return err
+		}
+	case "activate", "deactivate":
+		if err = client.UpdateAccessKey(id, args[0] == "activate"); err != nil {
+			return err
+		}
+	case "rotate":
+		if *out == "" {
+			return errUsage
+		}
+		state, err := client.RotateAccessKey(oos.AccessKeyRotation{
+			OldAccessKeyId: id,
+			UserName:       *user,
+			Sink:           oos.FileCredentialsSink{Path: *out},
+			Store:          oos.NewFileCheckpointStore(*stateDir),
+			IdlePeriod:     *idle,
+			DeleteAfter:    *deleteAfter,
+		})
+		var pending *oos.RotationPendingError
+		if err != nil && !errors.As(err, &pending) {
+			return err
+		}
+		printErr := a.print(state, func(w io.Writer) {
+			fmt.Fprintf(w, "%s -> %s  next step: %s\n", state.OldAccessKeyId, state.NewAccessKeyId, state.Step)
+		})
+		if pending != nil {
+			return pending
+		}
+		return printErr
+	case "last-used":
+		out, err := client.GetAccessKeyLastUsed(id)
+		if err != nil {
+			return err
+		}
+		res := out.GetAccessKeyLastUsedResult
+		return a.print(res, func(w io.Writer) {
+			last := "never"
+			if res.LastUsedDate != nil {
+				last = res.LastUsedDate.String()
+			}
+			fmt.Fprintf(w, "%s  last used %s  service %s\n", id, last, res.ServiceName)
+		})
+	default:
+		return errUsage
+	}
+	return a.print(map[string]string{"access_key_id": id, "action": args[0]}, func(w io.Writer) {
+		fmt.Fprintln(w, args[0], id)
+	})
+}
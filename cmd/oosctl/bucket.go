@@ -0,0 +1,250 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/teamssix/oos-go-sdk/oos"
+)
+
+func cmdMb(a *app, args []string) error {
+	fs := a.newFlags("mb")
+	location := fs.String("location", "", "metadata location such as ChengDu, data is stored locally")
+	acl := fs.String("acl", "", "bucket ACL: private, public-read or public-read-write")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 1 {
+		return errUsage
+	}
+	u, err := parseOOSURL(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	client, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	var conf interface{}
+	if *location != "" {
+		if conf, err = oos.BuildCreateBucketConfigLocal(*location); err != nil {
+			return err
+		}
+	}
+	var options []oos.Option
+	if *acl != "" {
+		options = append(options, oos.ACL(oos.ACLType(*acl)))
+	}
+	if err = client.CreateBucket(u.Bucket, conf, options...); err != nil {
+		return err
+	}
+	return a.print(map[string]string{"bucket": u.Bucket}, func(w io.Writer) { fmt.Fprintln(w, "created", "oos://"+u.Bucket) })
+}
+
+func cmdRb(a *app, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	u, err := parseOOSURL(args[0])
+	if err != nil {
+		return err
+	}
+	client, err := a.client()
+	if err != nil {
+		return err
+	}
+	if err = client.DeleteBucket(u.Bucket); err != nil {
+		return err
+	}
+	return a.print(map[string]string{"bucket": u.Bucket}, func(w io.Writer) { fmt.Fprintln(w, "deleted", "oos://"+u.Bucket) })
+}
+
+// bucketConfig is a bucket sub resource handled by "oosctl bucket"
+type bucketConfig struct {
+	get func(c *oos.Client, bucket string) (interface{}, error)
+	set func(c *oos.Client, bucket string, arg string) error
+	del func(c *oos.Client, bucket string) error
+}
+
+var bucketConfigs = map[string]bucketConfig{
+	"acl": {
+		get: func(c *oos.Client, bucket string) (interface{}, error) { return c.GetBucketACL(bucket) },
+		set: func(c *oos.Client, bucket string, acl string) error { return c.SetBucketACL(bucket, oos.ACLType(acl)) },
+	},
+	"cors": {
+		get: func(c *oos.Client, bucket string) (interface{}, error) {
+			rules, err := c.GetBucketCors(bucket)
+			return oos.CORSXML{CORSRules: rules}, err
+		},
+		set: func(c *oos.Client, bucket string, file string) error {
+			var conf oos.CORSXML
+			if err := decodeConfigFile(file, &conf, &conf.CORSRules); err != nil {
+				return err
+			}
+			if err := oos.ValidateCORSRules(conf.CORSRules); err != nil {
+				return err
+			}
+			return c.SetBucketCors(bucket, conf.CORSRules)
+		},
+		del: func(c *oos.Client, bucket string) error { return c.DeleteBucketCors(bucket) },
+	},
+	"lifecycle": {
+		get: func(c *oos.Client, bucket string) (interface{}, error) { return c.GetBucketLifecycle(bucket) },
+		set: func(c *oos.Client, bucket string, file string) error {
+			var conf oos.LifecycleConfiguration
+			if err := decodeConfigFile(file, &conf, &conf.Rules); err != nil {
+				return err
+			}
+			if err := oos.ValidateLifecycleRules(conf.Rules); err != nil {
+				return err
+			}
+			return c.SetBucketLifecycle(bucket, conf.Rules)
+		},
+		del: func(c *oos.Client, bucket string) error { return c.DeleteBucketLifecycle(bucket) },
+	},
+	"policy": {
+		get: func(c *oos.Client, bucket string) (interface{}, error) {
+			text, err := c.GetBucketPolicy(bucket)
+			return json.RawMessage(text), err
+		},
+		set: func(c *oos.Client, bucket string, file string) error {
+			data, err := ioutil.ReadFile(file)
+			if err != nil {
+				return err
+			}
+			return c.SetBucketPolicy(bucket, string(data))
+		},
+		del: func(c *oos.Client, bucket string) error { return c.DeleteBucketPolicy(bucket) },
+	},
+	"website": {
+		get: func(c *oos.Client, bucket string) (interface{}, error) { return c.GetBucketWebsite(bucket) },
+		set: func(c *oos.Client, bucket string, file string) error {
+			var wxml oos.WebsiteXML
+			var conf oos.WebsiteConfiguration
+			isXML, err := decodeConfigFileAs(file, &wxml, &conf)
+			if err != nil {
+				return err
+			}
+			if isXML {
+				conf = websiteConfigFromXML(wxml)
+			}
+			if err = oos.ValidateWebsiteConfiguration(conf); err != nil {
+				return err
+			}
+			return c.SetBucketWebsite(bucket, conf)
+		},
+		del: func(c *oos.Client, bucket string) error { return c.DeleteBucketWebsite(bucket) },
+	},
+	"logging": {
+		get: func(c *oos.Client, bucket string) (interface{}, error) { return c.GetBucketLogging(bucket) },
+		set: func(c *oos.Client, bucket string, file string) error {
+			var conf oos.LoggingXML
+			if err := decodeConfigFile(file, &conf, &conf.LoggingEnabled); err != nil {
+				return err
+			}
+			return c.SetBucketLogging(bucket, conf.LoggingEnabled.TargetBucket, conf.LoggingEnabled.TargetPrefix, true)
+		},
+		del: func(c *oos.Client, bucket string) error { return c.SetBucketLogging(bucket, "", "", false) },
+	},
+	"object-lock": {
+		get: func(c *oos.Client, bucket string) (interface{}, error) { return c.GetBucketObjectLock(bucket) },
+		set: func(c *oos.Client, bucket string, file string) error {
+			var conf oos.BucketObjectLock
+			if err := decodeConfigFile(file, &conf, &conf); err != nil {
+				return err
+			}
+			return c.SetBucketObjectLock(bucket, conf)
+		},
+		del: func(c *oos.Client, bucket string) error { return c.DeleteBucketObjectLock(bucket) },
+	},
+}
+
+func cmdBucket(a *app, args []string) error {
+	if len(args) < 3 {
+		return errUsage
+	}
+	conf, ok := bucketConfigs[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown bucket configuration %q", args[0])
+	}
+	u, err := parseOOSURL(args[2])
+	if err != nil {
+		return err
+	}
+	client, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	switch args[1] {
+	case "get":
+		v, err := conf.get(client, u.Bucket)
+		if err != nil {
+			return err
+		}
+		return a.print(v, func(w io.Writer) {
+			if raw, ok := v.(json.RawMessage); ok {
+				fmt.Fprintln(w, string(raw))
+				return
+			}
+			bs, _ := xml.MarshalIndent(v, "", "  ")
+			fmt.Fprintln(w, string(bs))
+		})
+	case "set":
+		if len(args) != 4 {
+			return errUsage
+		}
+		if err = conf.set(client, u.Bucket, args[3]); err != nil {
+			return err
+		}
+	case "delete":
+		if conf.del == nil {
+			return fmt.Errorf("%s can't be deleted", args[0])
+		}
+		if err = conf.del(client, u.Bucket); err != nil {
+			return err
+		}
+	default:
+		return errUsage
+	}
+	return a.print(map[string]string{"bucket": u.Bucket, args[0]: args[1]}, func(w io.Writer) {
+		fmt.Fprintf(w, "%s %s oos://%s\n", args[0], args[1], u.Bucket)
+	})
+}
+
+// decodeConfigFile decodes an XML file into xmlTarget, or a JSON file into jsonTarget
+func decodeConfigFile(file string, xmlTarget, jsonTarget interface{}) error {
+	_, err := decodeConfigFileAs(file, xmlTarget, jsonTarget)
+	return err
+}
+
+// decodeConfigFileAs is decodeConfigFile and reports whether the file was XML
+func decodeConfigFileAs(file string, xmlTarget, jsonTarget interface{}) (bool, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return false, err
+	}
+	data = bytes.TrimSpace(data)
+	if strings.HasPrefix(string(data), "<") {
+		return true, xml.Unmarshal(data, xmlTarget)
+	}
+	return false, json.Unmarshal(data, jsonTarget)
+}
+
+// websiteConfigFromXML converts the website XML to the configuration accepted by SetBucketWebsite
+func websiteConfigFromXML(wxml oos.WebsiteXML) oos.WebsiteConfiguration {
+	conf := oos.WebsiteConfiguration{WebsiteAllRequestTo: wxml.WebsiteAllRequestTo}
+	if wxml.IndexDocument != nil {
+		conf.IndexDocument = *wxml.IndexDocument
+	}
+	if wxml.ErrorDocument != nil {
+		conf.ErrorDocument = *wxml.ErrorDocument
+	}
+	if wxml.RoutingRules != nil {
+		conf.RoutingRules = *wxml.RoutingRules
+	}
+	return conf
+}
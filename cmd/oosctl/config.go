@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/teamssix/oos-go-sdk/oos"
+)
+
+// profile holds the connection settings of one account
+type profile struct {
+	Endpoint        string `json:"endpoint"`          // oos endpoint such as https://oos-cn.ctyunapi.cn
+	IAMEndpoint     string `json:"iam_endpoint"`      // IAM endpoint for access key management
+	AccessKeyID     string `json:"access_key_id"`     // AccessId
+	AccessKeySecret string `json:"access_key_secret"` // AccessKey
+	SecurityToken   string `json:"security_token"`    // STS token
+	V2Signature     bool   `json:"v2_signature"`      // Sign with V2 instead of V4
+	UnsignedPayload bool   `json:"unsigned_payload"`  // Don't hash the payload with V4
+	Region          string `json:"region"`            // V4 signing region, resolved from the endpoint if it's empty
+}
+
+// configFile is the profiles file, ~/.oosctl.json by default
+//
+//	{
+//	  "profiles": {
+//	    "default": {"endpoint": "https://oos-cn.ctyunapi.cn", "access_key_id": "...", "access_key_secret": "..."}
+//	  }
+//	}
+type configFile struct {
+	Profiles map[string]profile `json:"profiles"`
+}
+
+const (
+	envConfig          = "OOSCTL_CONFIG"
+	envProfile         = "OOSCTL_PROFILE"
+	envEndpoint        = "OOS_ENDPOINT"
+	envAccessKeyID     = "OOS_ACCESS_KEY_ID"
+	envAccessKeySecret = "OOS_ACCESS_KEY_SECRET"
+	envSecurityToken   = "OOS_SECURITY_TOKEN"
+	envRegion          = "OOS_REGION"
+	defaultProfile     = "default"
+)
+
+// defaultConfigPath returns ~/.oosctl.json
+func defaultConfigPath() string {
+	if p := os.Getenv(envConfig); p != "" {
+		return p
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ".oosctl.json"
+	}
+	return filepath.Join(home, ".oosctl.json")
+}
+
+// loadProfile reads the profile from the config file. The environment and the flags override its values.
+func loadProfile(g *globalFlags) (profile, error) {
+	var p profile
+	name := g.profile
+	if name == "" {
+		name = os.Getenv(envProfile)
+	}
+	if name == "" {
+		name = defaultProfile
+	}
+
+	path := g.config
+	if path == "" {
+		path = defaultConfigPath()
+	}
+	data, err := ioutil.ReadFile(path)
+	if err == nil {
+		var cf configFile
+		if err = json.Unmarshal(data, &cf); err != nil {
+			return p, fmt.Errorf("invalid config file %s: %v", path, err)
+		}
+		found := false
+		p, found = cf.Profiles[name]
+		if !found && (g.profile != "" || name != defaultProfile) {
+			return p, fmt.Errorf("profile %q not found in %s", name, path)
+		}
+	} else if !os.IsNotExist(err) || g.config != "" {
+		return p, err
+	}
+
+	if v := os.Getenv(envEndpoint); v != "" {
+		p.Endpoint = v
+	}
+	if v := os.Getenv(envAccessKeyID); v != "" {
+		p.AccessKeyID = v
+	}
+	if v := os.Getenv(envAccessKeySecret); v != "" {
+		p.AccessKeySecret = v
+	}
+	if v := os.Getenv(envSecurityToken); v != "" {
+		p.SecurityToken = v
+	}
+	if v := os.Getenv(envRegion); v != "" {
+		p.Region = v
+	}
+	if g.endpoint != "" {
+		p.Endpoint = g.endpoint
+	}
+	if g.region != "" {
+		p.Region = g.region
+	}
+	return p, nil
+}
+
+// newClient creates the client for the profile
+func newClient(p profile, iam bool) (*oos.Client, error) {
+	endpoint := p.Endpoint
+	if iam {
+		endpoint = p.IAMEndpoint
+		if endpoint == "" {
+			return nil, errors.New("iam_endpoint is not set in the profile")
+		}
+	}
+	if endpoint == "" {
+		return nil, errors.New("endpoint is not set, use --endpoint, " + envEndpoint + " or the config file")
+	}
+
+	options := []oos.ClientOption{
+		oos.V4Signature(!p.V2Signature),
+		oos.EnableSha256ForPayload(!p.UnsignedPayload),
+	}
+	if p.SecurityToken != "" {
+		options = append(options, oos.SecurityToken(p.SecurityToken))
+	}
+	if p.Region != "" {
+		options = append(options, oos.Region(p.Region))
+	}
+	client, err := oos.New(endpoint, p.AccessKeyID, p.AccessKeySecret, options...)
+	if err != nil {
+		return nil, err
+	}
+	if err = client.Config.Validate(); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
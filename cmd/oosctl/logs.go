@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/teamssix/oos-go-sdk/oos"
+)
+
+// cmdLogs summarizes the server access logs under the logging prefix
+func cmdLogs(a *app, args []string) error {
+	fs := a.newFlags("logs")
+	since := fs.Duration("since", 24*time.Hour, "read the records of this long ago until now")
+	top := fs.Int("top", 10, "number of the top keys and requesters")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 1 {
+		return errUsage
+	}
+	u, err := parseOOSURL(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	bucket, err := a.bucket(u.Bucket)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	reader := bucket.NewAccessLogReader(u.Key, now.Add(-*since), now)
+	defer reader.Close()
+	stats := oos.NewAccessLogStats()
+	for reader.Next() {
+		stats.Add(reader.Record())
+	}
+	if err = reader.Err(); err != nil {
+		return err
+	}
+
+	summary := struct {
+		Total        oos.AccessLogCounter
+		TopKeys      []oos.AccessLogRank
+		TopErrorKeys []oos.AccessLogRank
+		Requesters   []oos.AccessLogRank
+		Operations   map[string]oos.AccessLogCounter
+		ErrorCodes   map[string]int64
+		SkippedLines int
+	}{stats.Total, stats.TopKeys(*top), stats.TopErrorKeys(*top), stats.TopRequestersByBytes(*top),
+		stats.ByOperation, stats.ByErrorCode, reader.SkippedLines}
+	return a.print(summary, func(w io.Writer) {
+		fmt.Fprintf(w, "requests %d  errors %d (%.2f%%)  bytes sent %d\n", stats.Total.Requests, stats.Total.Errors,
+			stats.Total.ErrorRate()*100, stats.Total.BytesSent)
+		printRanks := func(title string, ranks []oos.AccessLogRank) {
+			if len(ranks) == 0 {
+				return
+			}
+			fmt.Fprintln(w, title)
+			for _, r := range ranks {
+				fmt.Fprintf(w, "  %8d req %6d err %12d bytes  %s\n", r.Counter.Requests, r.Counter.Errors, r.Counter.BytesSent, r.Name)
+			}
+		}
+		printRanks("top keys:", summary.TopKeys)
+		printRanks("top error keys:", summary.TopErrorKeys)
+		printRanks("top requesters by bytes:", summary.Requesters)
+		if reader.SkippedLines > 0 {
+			fmt.Fprintf(w, "skipped %d unparsable lines\n", reader.SkippedLines)
+		}
+	})
+}
@@ -0,0 +1,210 @@
+// Command oosctl is a command-line tool for oos built on the SDK.
+//
+// Usage:
+//
+//	oosctl [global flags] <command> [flags] [args]
+//
+// Run "oosctl help" for the list of commands.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/teamssix/oos-go-sdk/oos"
+)
+
+// globalFlags are the flags accepted before the command
+type globalFlags struct {
+	config   string // Config file path
+	profile  string // Profile name
+	endpoint string // Endpoint override
+	region   string // Signing region override
+	output   string // text or json
+}
+
+// app is the state of one invocation
+type app struct {
+	stdout io.Writer
+	stderr io.Writer
+	flags  globalFlags
+	prof   *profile
+}
+
+// command is a subcommand
+type command struct {
+	usage string
+	help  string
+	run   func(a *app, args []string) error
+}
+
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"ls":        {"ls [-r] [oos://bucket[/prefix]]", "list buckets, or objects under a prefix", cmdLs},
+		"cp":        {"cp [-r] [flags] <src> <dest>", "copy between local paths and oos:// URLs", cmdCp},
+		"mv":        {"mv [-r] [flags] <src> <dest>", "copy then delete the source", cmdMv},
+		"rm":        {"rm [-r] oos://bucket/key", "delete an object, or all objects under a prefix with -r", cmdRm},
+		"stat":      {"stat oos://bucket/key", "show the object metadata", cmdStat},
+		"presign":   {"presign [-method GET] [-expires 3600] oos://bucket/key", "print a presigned URL", cmdPresign},
+		"mb":        {"mb [-location ChengDu] [-acl private] oos://bucket", "create a bucket", cmdMb},
+		"rb":        {"rb oos://bucket", "delete an empty bucket", cmdRb},
+		"bucket":    {"bucket <acl|cors|lifecycle|policy|website|logging|object-lock> <get|set|delete> oos://bucket [file|value]", "get or set bucket configuration from JSON or XML files", cmdBucket},
+		"multipart": {"multipart <ls|abort|clean> [-older-than 24h] [-dry-run] oos://bucket[/key] [upload-id]", "list, abort or clean up multipart uploads", cmdMultipart},
+		"ak":        {"ak <create|ls|delete|activate|deactivate|last-used|rotate> [flags] [access-key-id]", "manage access keys", cmdAccessKey},
+		"user":      {"user <ls|create|get|delete|groups|policies|add-to-group|remove-from-group|attach|detach> [-max 100] [name] [group|policy-arn]", "manage IAM users, their groups and policies", cmdUser},
+		"policy":    {"policy create [-description text] <name> <policy.json>", "create an IAM policy", cmdPolicy},
+		"deploy":    {"deploy [-release name] [-keep 2] [-exclude globs] <dir> oos://bucket | deploy -activate release oos://bucket", "publish a directory as the bucket website, or switch to a release", cmdDeploy},
+		"logs":      {"logs [-since 24h] [-top 10] oos://log-bucket/prefix", "summarize the server access logs written by bucket logging", cmdLogs},
+		"sts":       {"sts <session-token|assume-role> [-duration 3600] [-session name] [-policy json] [role-arn]", "print temporary credentials as environment variables", cmdSTS},
+	}
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run executes the command line and returns the exit code
+func run(args []string, stdout, stderr io.Writer) int {
+	a := &app{stdout: stdout, stderr: stderr}
+
+	fs := flag.NewFlagSet("oosctl", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&a.flags.config, "config", "", "config file path (default ~/.oosctl.json)")
+	fs.StringVar(&a.flags.profile, "profile", "", "profile name in the config file (default \"default\")")
+	fs.StringVar(&a.flags.endpoint, "endpoint", "", "endpoint, overrides the profile")
+	fs.StringVar(&a.flags.region, "region", "", "V4 signing region, overrides the profile")
+	fs.StringVar(&a.flags.output, "output", "text", "output format: text or json")
+	fs.Usage = func() { a.usage() }
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	rest := fs.Args()
+	if len(rest) == 0 || rest[0] == "help" || rest[0] == "-h" {
+		a.usage()
+		return 0
+	}
+	if a.flags.output != "text" && a.flags.output != "json" {
+		fmt.Fprintln(stderr, "oosctl: --output must be text or json")
+		return 2
+	}
+
+	cmd, ok := commands[rest[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "oosctl: unknown command %q\n", rest[0])
+		a.usage()
+		return 2
+	}
+	if err := cmd.run(a, rest[1:]); err != nil {
+		if errors.Is(err, errUsage) {
+			fmt.Fprintln(stderr, "usage: oosctl "+cmd.usage)
+			return 2
+		}
+		fmt.Fprintln(stderr, "oosctl:", err)
+		return 1
+	}
+	return 0
+}
+
+func (a *app) usage() {
+	fmt.Fprintln(a.stderr, "usage: oosctl [--config file] [--profile name] [--endpoint url] [--region name] [--output text|json] <command> [args]")
+	fmt.Fprintln(a.stderr, "\ncommands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(a.stderr, "  %-10s %s\n", name, commands[name].help)
+		fmt.Fprintf(a.stderr, "  %-10s   oosctl %s\n", "", commands[name].usage)
+	}
+}
+
+var errUsage = errors.New("usage")
+
+// profile loads the profile once
+func (a *app) profile() (profile, error) {
+	if a.prof == nil {
+		p, err := loadProfile(&a.flags)
+		if err != nil {
+			return p, err
+		}
+		a.prof = &p
+	}
+	return *a.prof, nil
+}
+
+// client creates the oos client
+func (a *app) client() (*oos.Client, error) {
+	p, err := a.profile()
+	if err != nil {
+		return nil, err
+	}
+	return newClient(p, false)
+}
+
+// iamClient creates the client for the IAM endpoint
+func (a *app) iamClient() (*oos.Client, error) {
+	p, err := a.profile()
+	if err != nil {
+		return nil, err
+	}
+	return newClient(p, true)
+}
+
+// bucket creates the object handle of the bucket
+func (a *app) bucket(name string) (*oos.Object, error) {
+	client, err := a.client()
+	if err != nil {
+		return nil, err
+	}
+	return client.Bucket(name)
+}
+
+// oosURL is a parsed oos://bucket/key
+type oosURL struct {
+	Bucket string
+	Key    string
+}
+
+func (u oosURL) String() string {
+	return "oos://" + u.Bucket + "/" + u.Key
+}
+
+const urlScheme = "oos://"
+
+func isOOSURL(s string) bool {
+	return strings.HasPrefix(s, urlScheme)
+}
+
+// parseOOSURL parses oos://bucket/key
+func parseOOSURL(s string) (oosURL, error) {
+	if !isOOSURL(s) {
+		return oosURL{}, fmt.Errorf("%q is not an oos:// URL", s)
+	}
+	rest := s[len(urlScheme):]
+	u := oosURL{}
+	if i := strings.Index(rest, "/"); i >= 0 {
+		u.Bucket, u.Key = rest[:i], rest[i+1:]
+	} else {
+		u.Bucket = rest
+	}
+	if u.Bucket == "" {
+		return u, fmt.Errorf("%q has no bucket", s)
+	}
+	return u, nil
+}
+
+// newFlags creates the flag set of a subcommand
+func (a *app) newFlags(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(a.stderr)
+	return fs
+}
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/teamssix/oos-go-sdk/oos"
+)
+
+// fakeOOS is an in-memory oos with the path-style object, listing and multi-delete APIs
+type fakeOOS struct {
+	mu      sync.Mutex
+	objects map[string][]byte // bucket/key to the data
+	locked  map[string]bool   // bucket/key which can't be deleted
+}
+
+func newFakeOOS() *fakeOOS {
+	return &fakeOOS{objects: map[string][]byte{}, locked: map[string]bool{}}
+}
+
+func (f *fakeOOS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)
+	bucket, key := parts[0], ""
+	if len(parts) == 2 {
+		key = parts[1]
+	}
+	query := r.URL.Query()
+	name := bucket + "/" + key
+
+	switch {
+	case r.Method == "GET" && key == "":
+		f.list(w, bucket, query)
+	case r.Method == "POST" && key == "":
+		f.deleteObjects(w, r, bucket)
+	case r.Method == "PUT":
+		data, _ := ioutil.ReadAll(r.Body)
+		f.objects[name] = data
+		w.Header().Set("ETag", `"etag"`)
+	case r.Method == "GET" || r.Method == "HEAD":
+		data, ok := f.objects[name]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, "<Error><Code>NoSuchKey</Code></Error>")
+			return
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		w.Header().Set("ETag", `"etag"`)
+		if _, meta := query["objectMeta"]; r.Method == "GET" && !meta {
+			w.Write(data)
+		}
+	case r.Method == "DELETE":
+		delete(f.objects, name)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// list returns at most max-keys objects, NextMarker is only set with the delimiter as the service does
+func (f *fakeOOS) list(w http.ResponseWriter, bucket string, query map[string][]string) {
+	get := func(k string) string {
+		if v := query[k]; len(v) > 0 {
+			return v[0]
+		}
+		return ""
+	}
+	prefix, marker, delimiter := get("prefix"), get("marker"), get("delimiter")
+	maxKeys := 1000
+	if n, err := strconv.Atoi(get("max-keys")); err == nil && n < maxKeys {
+		maxKeys = n
+	}
+
+	var keys []string
+	for name := range f.objects {
+		if k := strings.TrimPrefix(name, bucket+"/"); k != name && strings.HasPrefix(k, prefix) && k > marker {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	out := oos.ListObjectsResult{Prefix: prefix, Marker: marker, MaxKeys: maxKeys, Delimiter: delimiter}
+	seen := map[string]bool{}
+	last := ""
+	for _, k := range keys {
+		if len(out.Objects)+len(out.CommonPrefixes) == maxKeys {
+			out.IsTruncated = true
+			break
+		}
+		if i := strings.Index(k[len(prefix):], delimiter); delimiter != "" && i >= 0 {
+			p := k[:len(prefix)+i+len(delimiter)]
+			if !seen[p] {
+				seen[p] = true
+				out.CommonPrefixes = append(out.CommonPrefixes, p)
+			}
+			last = k
+			continue
+		}
+		out.Objects = append(out.Objects, oos.ObjectProperties{Key: k, Size: int64(len(f.objects[bucket+"/"+k])),
+			LastModified: time.Unix(0, 0).UTC()})
+		last = k
+	}
+	if out.IsTruncated && delimiter != "" {
+		out.NextMarker = last
+	}
+	xml.NewEncoder(w).Encode(out)
+}
+
+// deleteObjects deletes the keys which aren't locked, the locked ones are reported as errors
+func (f *fakeOOS) deleteObjects(w http.ResponseWriter, r *http.Request, bucket string) {
+	var req struct {
+		Objects []struct {
+			Key string `xml:"Key"`
+		} `xml:"Object"`
+		Quiet bool `xml:"Quiet"`
+	}
+	if err := xml.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	var buf bytes.Buffer
+	buf.WriteString("<DeleteResult>")
+	for _, o := range req.Objects {
+		name := bucket + "/" + o.Key
+		if f.locked[name] {
+			fmt.Fprintf(&buf, "<Error><Key>%s</Key><Code>AccessDenied</Code></Error>", o.Key)
+			continue
+		}
+		delete(f.objects, name)
+		if !req.Quiet {
+			fmt.Fprintf(&buf, "<Deleted><Key>%s</Key></Deleted>", o.Key)
+		}
+	}
+	buf.WriteString("</DeleteResult>")
+	w.Write(buf.Bytes())
+}
+
+// oosctl runs the command line against the server and returns the exit code and the output
+func oosctl(t *testing.T, endpoint string, args ...string) (int, string, string) {
+	t.Helper()
+	config := filepath.Join(t.TempDir(), "oosctl.json")
+	data := fmt.Sprintf(`{"profiles": {"default": {"endpoint": %q, "access_key_id": "ak", "access_key_secret": "sk", "region": "cn"}}}`, endpoint)
+	if err := ioutil.WriteFile(config, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	code := run(append([]string{"-config", config}, args...), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestLsRecursivePagesWithoutNextMarker(t *testing.T) {
+	fake := newFakeOOS()
+	for i := 0; i < 1500; i++ {
+		fake.objects[fmt.Sprintf("b/logs/%04d", i)] = []byte("x")
+	}
+	fake.objects["b/logs/dir/a"] = []byte("x")
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	code, out, stderr := oosctl(t, srv.URL, "ls", "-r", "oos://b/logs/")
+	if code != 0 {
+		t.Fatalf("ls -r exit %d: %s", code, stderr)
+	}
+	if n := strings.Count(out, "\n"); n != 1501 {
+		t.Errorf("ls -r listed %d objects, want 1501", n)
+	}
+
+	code, out, stderr = oosctl(t, srv.URL, "ls", "oos://b/logs/")
+	if code != 0 {
+		t.Fatalf("ls exit %d: %s", code, stderr)
+	}
+	if n := strings.Count(out, "\n"); n != 1501 {
+		t.Errorf("ls listed %d entries, want 1501", n)
+	}
+	if !strings.Contains(out, "DIR  oos://b/logs/dir/") {
+		t.Errorf("ls doesn't group oos://b/logs/dir/:\n%s", out)
+	}
+}
+
+func TestCpUploadDownloadAndRecursive(t *testing.T) {
+	fake := newFakeOOS()
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "a.txt")
+	if err := ioutil.WriteFile(src, []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if code, _, stderr := oosctl(t, srv.URL, "cp", "-q", src, "oos://b/docs/"); code != 0 {
+		t.Fatalf("cp upload exit %d: %s", code, stderr)
+	}
+	if got := string(fake.objects["b/docs/a.txt"]); got != "hello" {
+		t.Fatalf("uploaded %q, want hello", got)
+	}
+
+	dest := filepath.Join(dir, "b.txt")
+	if code, _, stderr := oosctl(t, srv.URL, "cp", "-q", "oos://b/docs/a.txt", dest); code != 0 {
+		t.Fatalf("cp download exit %d: %s", code, stderr)
+	}
+	if data, _ := ioutil.ReadFile(dest); string(data) != "hello" {
+		t.Fatalf("downloaded %q, want hello", data)
+	}
+
+	for i := 0; i < 1200; i++ {
+		fake.objects[fmt.Sprintf("b/many/%04d", i)] = []byte("x")
+	}
+	out := filepath.Join(dir, "many")
+	if code, _, stderr := oosctl(t, srv.URL, "cp", "-r", "-q", "oos://b/many", out); code != 0 {
+		t.Fatalf("cp -r exit %d: %s", code, stderr)
+	}
+	files, err := ioutil.ReadDir(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1200 {
+		t.Errorf("cp -r copied %d files, want 1200", len(files))
+	}
+}
+
+func TestRmRecursiveSkipsUndeletableKeys(t *testing.T) {
+	fake := newFakeOOS()
+	for i := 0; i < 1100; i++ {
+		fake.objects[fmt.Sprintf("b/tmp/%04d", i)] = []byte("x")
+	}
+	fake.objects["b/keep"] = []byte("x")
+	fake.locked["b/tmp/0003"] = true
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	done := make(chan struct{})
+	var code int
+	var stderr string
+	go func() {
+		code, _, stderr = oosctl(t, srv.URL, "rm", "-r", "oos://b/tmp/")
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(30 * time.Second):
+		t.Fatal("rm -r doesn't stop on the undeletable key")
+	}
+
+	if code != 1 || !strings.Contains(stderr, "failed to delete oos://b/tmp/0003") {
+		t.Errorf("rm -r exit %d, stderr %q, want the failure of oos://b/tmp/0003", code, stderr)
+	}
+	if len(fake.objects) != 2 || fake.objects["b/tmp/0003"] == nil || fake.objects["b/keep"] == nil {
+		t.Errorf("%d objects left, want b/tmp/0003 and b/keep", len(fake.objects))
+	}
+
+	if code, _, stderr := oosctl(t, srv.URL, "rm", "oos://b/keep"); code != 0 {
+		t.Fatalf("rm exit %d: %s", code, stderr)
+	}
+	if _, ok := fake.objects["b/keep"]; ok {
+		t.Error("rm didn't delete b/keep")
+	}
+}
+
+func TestMain(m *testing.M) {
+	// Keep the user's environment out of the profile
+	for _, k := range []string{envConfig, envProfile, envEndpoint, envAccessKeyID, envAccessKeySecret, envSecurityToken, envRegion} {
+		os.Unsetenv(k)
+	}
+	os.Exit(m.Run())
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/teamssix/oos-go-sdk/oos"
+)
+
+func cmdMultipart(a *app, args []string) error {
+	if len(args) < 2 {
+		return errUsage
+	}
+	if args[0] == "clean" {
+		return cmdMultipartClean(a, args[1:])
+	}
+	u, err := parseOOSURL(args[1])
+	if err != nil {
+		return err
+	}
+	bucket, err := a.bucket(u.Bucket)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "ls":
+		uploads := []oos.UncompletedUpload{}
+		keyMarker, uploadIDMarker := "", ""
+		for {
+			lmur, err := bucket.ListMultipartUploads(oos.Prefix(u.Key), oos.KeyMarker(keyMarker), oos.UploadIDMarker(uploadIDMarker))
+			if err != nil {
+				return err
+			}
+			uploads = append(uploads, lmur.Uploads...)
+			if !lmur.IsTruncated {
+				break
+			}
+			keyMarker, uploadIDMarker = lmur.NextKeyMarker, lmur.NextUploadIDMarker
+		}
+		return a.print(uploads, func(w io.Writer) {
+			for _, up := range uploads {
+				fmt.Fprintf(w, "%s  %s  oos://%s/%s\n", up.Initiated.Format("2006-01-02 15:04:05"), up.UploadID, u.Bucket, up.Key)
+			}
+		})
+	case "abort":
+		if len(args) != 3 || u.Key == "" {
+			return errUsage
+		}
+		imur := oos.InitiateMultipartUploadResult{Bucket: u.Bucket, Key: u.Key, UploadID: args[2]}
+		if err = bucket.AbortMultipartUpload(imur); err != nil {
+			return err
+		}
+		return a.print(imur, func(w io.Writer) { fmt.Fprintln(w, "aborted", args[2], u) })
+	}
+	return errUsage
+}
+
+// cmdMultipartClean aborts the multipart uploads older than -older-than under the prefix
+func cmdMultipartClean(a *app, args []string) error {
+	fs := a.newFlags("multipart clean")
+	olderThan := fs.Duration("older-than", 24*time.Hour, "only abort uploads initiated before this long ago")
+	dryRun := fs.Bool("dry-run", false, "only list the uploads to abort")
+	routines := fs.Int("routines", 5, "concurrent aborts")
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if fs.NArg() != 1 {
+		return errUsage
+	}
+	u, err := parseOOSURL(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	bucket, err := a.bucket(u.Bucket)
+	if err != nil {
+		return err
+	}
+
+	report, err := bucket.AbortIncompleteUploads(*olderThan, u.Key, *dryRun, oos.Routines(*routines))
+	perr := a.print(report, func(w io.Writer) {
+		for _, up := range report.Matched {
+			fmt.Fprintf(w, "%s  %s  oos://%s/%s\n", up.Initiated.Format("2006-01-02 15:04:05"), up.UploadID, u.Bucket, up.Key)
+		}
+		for _, f := range report.Failed {
+			fmt.Fprintf(w, "failed %s oos://%s/%s: %v\n", f.Upload.UploadID, u.Bucket, f.Upload.Key, f.Err)
+		}
+		if *dryRun {
+			fmt.Fprintf(w, "%d of %d uploads would be aborted\n", len(report.Matched), report.Scanned)
+		} else {
+			fmt.Fprintf(w, "aborted %d of %d uploads, %d failed\n", len(report.Aborted), report.Scanned, len(report.Failed))
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if perr != nil {
+		return perr
+	}
+	if len(report.Failed) > 0 {
+		return fmt.Errorf("%d uploads failed to abort", len(report.Failed))
+	}
+	return nil
+}
@@ -0,0 +1,502 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/teamssix/oos-go-sdk/oos"
+)
+
+// listEntry is one line of ls
+type listEntry struct {
+	Key          string    `json:"key"`
+	Size         int64     `json:"size"`
+	LastModified time.Time `json:"last_modified"`
+	ETag         string    `json:"etag,omitempty"`
+	StorageClass string    `json:"storage_class,omitempty"`
+	IsPrefix     bool      `json:"is_prefix,omitempty"`
+}
+
+func cmdLs(a *app, args []string) error {
+	fs := a.newFlags("ls")
+	recursive := fs.Bool("r", false, "list recursively instead of grouping by \"/\"")
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+
+	if fs.NArg() == 0 {
+		client, err := a.client()
+		if err != nil {
+			return err
+		}
+		lbr, err := client.ListBuckets()
+		if err != nil {
+			return err
+		}
+		return a.print(lbr.Buckets, func(w io.Writer) {
+			for _, b := range lbr.Buckets {
+				fmt.Fprintf(w, "%s  oos://%s\n", b.CreationDate.Format("2006-01-02 15:04:05"), b.Name)
+			}
+		})
+	}
+	if fs.NArg() != 1 {
+		return errUsage
+	}
+
+	u, err := parseOOSURL(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	bucket, err := a.bucket(u.Bucket)
+	if err != nil {
+		return err
+	}
+
+	entries := []listEntry{}
+	options := []oos.Option{oos.Prefix(u.Key)}
+	if !*recursive {
+		options = append(options, oos.Delimiter("/"))
+	}
+	marker := ""
+	for {
+		lor, err := bucket.ListObjects(append(options, oos.Marker(marker))...)
+		if err != nil {
+			return err
+		}
+		for _, p := range lor.CommonPrefixes {
+			entries = append(entries, listEntry{Key: p, IsPrefix: true})
+		}
+		for _, o := range lor.Objects {
+			entries = append(entries, listEntry{Key: o.Key, Size: o.Size, LastModified: o.LastModified,
+				ETag: strings.Trim(o.ETag, "\""), StorageClass: o.StorageClass})
+		}
+		if !lor.IsTruncated {
+			break
+		}
+		// NextMarker is only returned with the delimiter, otherwise continue after the last key
+		marker = lor.NextMarker
+		if marker == "" && len(lor.Objects) > 0 {
+			marker = lor.Objects[len(lor.Objects)-1].Key
+		}
+		if n := len(lor.CommonPrefixes); n > 0 && lor.CommonPrefixes[n-1] > marker {
+			marker = lor.CommonPrefixes[n-1]
+		}
+		if marker == "" {
+			break
+		}
+	}
+
+	return a.print(entries, func(w io.Writer) {
+		for _, e := range entries {
+			if e.IsPrefix {
+				fmt.Fprintf(w, "%19s  %10s  oos://%s/%s\n", "", "DIR", u.Bucket, e.Key)
+			} else {
+				fmt.Fprintf(w, "%s  %10d  oos://%s/%s\n", e.LastModified.Format("2006-01-02 15:04:05"), e.Size, u.Bucket, e.Key)
+			}
+		}
+	})
+}
+
+// transferFlags are the flags of cp and mv
+type transferFlags struct {
+	recursive *bool
+	partSize  *int64
+	routines  *int
+	quiet     *bool
+	acl       *string
+}
+
+func (a *app) newTransferFlags(name string) (*flag.FlagSet, *transferFlags) {
+	fs := a.newFlags(name)
+	tf := &transferFlags{
+		recursive: fs.Bool("r", false, "copy directories and prefixes recursively"),
+		partSize:  fs.Int64("part-size", 100, "part size in MB, larger files use multipart transfer"),
+		routines:  fs.Int("j", 3, "concurrent parts of a multipart transfer"),
+		quiet:     fs.Bool("q", false, "don't show the progress"),
+		acl:       fs.String("acl", "", "object ACL of the uploaded objects: private, public-read or public-read-write"),
+	}
+	return fs, tf
+}
+
+func cmdCp(a *app, args []string) error {
+	fs, tf := a.newTransferFlags("cp")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 2 {
+		return errUsage
+	}
+	return a.transfer(fs.Arg(0), fs.Arg(1), tf, false)
+}
+
+func cmdMv(a *app, args []string) error {
+	fs, tf := a.newTransferFlags("mv")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 2 {
+		return errUsage
+	}
+	return a.transfer(fs.Arg(0), fs.Arg(1), tf, true)
+}
+
+// transferPair is one source and destination of cp/mv
+type transferPair struct {
+	src  string
+	dest string
+	size int64
+}
+
+// transfer copies src to dest, and deletes src after the copy when move is set
+func (a *app) transfer(src, dest string, tf *transferFlags, move bool) error {
+	if *tf.partSize < 1 || *tf.partSize > oos.MaxPartSize/(1024*1024) {
+		return fmt.Errorf("-part-size must be between 1 and %d MB", oos.MaxPartSize/(1024*1024))
+	}
+	if !isOOSURL(src) && !isOOSURL(dest) {
+		return fmt.Errorf("one of %q and %q must be an oos:// URL", src, dest)
+	}
+
+	var pairs []transferPair
+	var err error
+	if *tf.recursive {
+		pairs, err = a.expandRecursive(src, dest)
+	} else {
+		pairs, err = a.expandSingle(src, dest)
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, pair := range pairs {
+		if err := a.transferOne(pair, tf); err != nil {
+			return fmt.Errorf("%s -> %s: %v", pair.src, pair.dest, err)
+		}
+		if move {
+			if err := a.removeSource(pair.src); err != nil {
+				return err
+			}
+		}
+		if a.flags.output != "json" {
+			fmt.Fprintf(a.stdout, "%s -> %s\n", pair.src, pair.dest)
+		}
+	}
+	if a.flags.output == "json" {
+		out := make([]map[string]interface{}, 0, len(pairs))
+		for _, p := range pairs {
+			out = append(out, map[string]interface{}{"src": p.src, "dest": p.dest, "size": p.size})
+		}
+		return a.printJSON(out)
+	}
+	return nil
+}
+
+// expandSingle resolves the destination of a single file or object
+func (a *app) expandSingle(src, dest string) ([]transferPair, error) {
+	base := ""
+	var size int64
+	if isOOSURL(src) {
+		u, err := parseOOSURL(src)
+		if err != nil {
+			return nil, err
+		}
+		if u.Key == "" || strings.HasSuffix(u.Key, "/") {
+			return nil, fmt.Errorf("%s is a prefix, use -r", src)
+		}
+		base = path.Base(u.Key)
+	} else {
+		st, err := os.Stat(src)
+		if err != nil {
+			return nil, err
+		}
+		if st.IsDir() {
+			return nil, fmt.Errorf("%s is a directory, use -r", src)
+		}
+		base = filepath.Base(src)
+		size = st.Size()
+	}
+
+	if isOOSURL(dest) {
+		u, err := parseOOSURL(dest)
+		if err != nil {
+			return nil, err
+		}
+		if u.Key == "" || strings.HasSuffix(u.Key, "/") {
+			dest = oosURL{u.Bucket, u.Key + base}.String()
+		}
+	} else if st, err := os.Stat(dest); (err == nil && st.IsDir()) || strings.HasSuffix(dest, string(os.PathSeparator)) {
+		dest = filepath.Join(dest, base)
+	}
+	return []transferPair{{src, dest, size}}, nil
+}
+
+// expandRecursive lists all the files or objects under src
+func (a *app) expandRecursive(src, dest string) ([]transferPair, error) {
+	pairs := []transferPair{}
+	join := func(rel string) string {
+		if isOOSURL(dest) {
+			u, _ := parseOOSURL(dest)
+			prefix := u.Key
+			if prefix != "" && !strings.HasSuffix(prefix, "/") {
+				prefix += "/"
+			}
+			return oosURL{u.Bucket, prefix + rel}.String()
+		}
+		return filepath.Join(dest, filepath.FromSlash(rel))
+	}
+
+	if !isOOSURL(src) {
+		err := filepath.Walk(src, func(p string, info os.FileInfo, err error) error {
+			if err != nil || !info.Mode().IsRegular() {
+				return err
+			}
+			rel, err := filepath.Rel(src, p)
+			if err != nil {
+				return err
+			}
+			pairs = append(pairs, transferPair{p, join(filepath.ToSlash(rel)), info.Size()})
+			return nil
+		})
+		return pairs, err
+	}
+
+	u, err := parseOOSURL(src)
+	if err != nil {
+		return nil, err
+	}
+	bucket, err := a.bucket(u.Bucket)
+	if err != nil {
+		return nil, err
+	}
+	prefix := u.Key
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	it := bucket.NewObjectIterator(oos.Prefix(prefix))
+	for it.Next() {
+		o := it.Object()
+		if strings.HasSuffix(o.Key, "/") {
+			continue
+		}
+		pairs = append(pairs, transferPair{oosURL{u.Bucket, o.Key}.String(), join(strings.TrimPrefix(o.Key, prefix)), o.Size})
+	}
+	return pairs, it.Err()
+}
+
+// transferOne uploads, downloads or copies one file or object
+func (a *app) transferOne(pair transferPair, tf *transferFlags) error {
+	partSize := *tf.partSize * 1024 * 1024
+	options := []oos.Option{oos.Routines(*tf.routines)}
+	if !*tf.quiet && a.flags.output != "json" {
+		options = append(options, oos.Progress(newProgressBar(a.stderr, pair.src)))
+	}
+
+	switch {
+	case !isOOSURL(pair.src):
+		u, _ := parseOOSURL(pair.dest)
+		bucket, err := a.bucket(u.Bucket)
+		if err != nil {
+			return err
+		}
+		if *tf.acl != "" {
+			options = append(options, oos.ObjectACL(oos.ACLType(*tf.acl)))
+		}
+		if pair.size > partSize {
+			return bucket.UploadFile(u.Key, pair.src, partSize, options...)
+		}
+		return bucket.PutObjectFromFile(u.Key, pair.src, options...)
+
+	case !isOOSURL(pair.dest):
+		u, _ := parseOOSURL(pair.src)
+		bucket, err := a.bucket(u.Bucket)
+		if err != nil {
+			return err
+		}
+		if err = os.MkdirAll(filepath.Dir(pair.dest), 0755); err != nil {
+			return err
+		}
+		meta, err := bucket.GetObjectMeta(u.Key)
+		if err != nil {
+			return err
+		}
+		size, _ := strconv.ParseInt(meta.Get(oos.HTTPHeaderContentLength), 10, 64)
+		if size > partSize {
+			return bucket.DownloadFile(u.Key, pair.dest, partSize, options...)
+		}
+		return bucket.GetObjectToFile(u.Key, pair.dest, options...)
+
+	default:
+		su, _ := parseOOSURL(pair.src)
+		du, _ := parseOOSURL(pair.dest)
+		bucket, err := a.bucket(su.Bucket)
+		if err != nil {
+			return err
+		}
+		var copyOptions []oos.Option
+		if *tf.acl != "" {
+			copyOptions = append(copyOptions, oos.ObjectACL(oos.ACLType(*tf.acl)))
+		}
+		if su.Bucket == du.Bucket {
+			_, err = bucket.CopyObject(su.Key, du.Key, copyOptions...)
+		} else {
+			_, err = bucket.CopyObjectTo(du.Bucket, du.Key, su.Key, copyOptions...)
+		}
+		return err
+	}
+}
+
+// removeSource deletes the source of mv
+func (a *app) removeSource(src string) error {
+	if !isOOSURL(src) {
+		return os.Remove(src)
+	}
+	u, _ := parseOOSURL(src)
+	bucket, err := a.bucket(u.Bucket)
+	if err != nil {
+		return err
+	}
+	return bucket.DeleteObject(u.Key)
+}
+
+func cmdRm(a *app, args []string) error {
+	fs := a.newFlags("rm")
+	recursive := fs.Bool("r", false, "delete all objects under the prefix")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 1 {
+		return errUsage
+	}
+	u, err := parseOOSURL(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	bucket, err := a.bucket(u.Bucket)
+	if err != nil {
+		return err
+	}
+
+	if !*recursive {
+		if u.Key == "" {
+			return fmt.Errorf("%s has no key, use -r to delete a prefix", fs.Arg(0))
+		}
+		if err = bucket.DeleteObject(u.Key); err != nil {
+			return err
+		}
+		return a.print([]string{u.Key}, func(w io.Writer) { fmt.Fprintln(w, "deleted", u) })
+	}
+
+	deleted, failed := []string{}, []string{}
+	marker := ""
+	for {
+		// The keys which can't be deleted stay in the listing, so continue after the last key instead of listing
+		// from the beginning
+		lor, err := bucket.ListObjects(oos.Prefix(u.Key), oos.Marker(marker), oos.MaxKeys(1000))
+		if err != nil {
+			return err
+		}
+		if len(lor.Objects) == 0 {
+			break
+		}
+		keys := make([]string, 0, len(lor.Objects))
+		for _, o := range lor.Objects {
+			keys = append(keys, o.Key)
+		}
+		// Not quiet, the result lists the deleted keys, the others failed
+		res, err := bucket.DeleteObjects(keys)
+		if err != nil {
+			return err
+		}
+		done := make(map[string]bool, len(res.DeletedObjects))
+		for _, key := range res.DeletedObjects {
+			done[key] = true
+		}
+		for _, key := range keys {
+			if done[key] {
+				deleted = append(deleted, key)
+			} else {
+				failed = append(failed, key)
+			}
+		}
+		if !lor.IsTruncated {
+			break
+		}
+		marker = keys[len(keys)-1]
+	}
+	err = a.print(deleted, func(w io.Writer) {
+		for _, key := range deleted {
+			fmt.Fprintf(w, "deleted oos://%s/%s\n", u.Bucket, key)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	for _, key := range failed {
+		fmt.Fprintf(a.stderr, "failed to delete oos://%s/%s\n", u.Bucket, key)
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d objects under %s were not deleted", len(failed), u)
+	}
+	return nil
+}
+
+func cmdStat(a *app, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	u, err := parseOOSURL(args[0])
+	if err != nil {
+		return err
+	}
+	if u.Key == "" {
+		return errUsage
+	}
+	bucket, err := a.bucket(u.Bucket)
+	if err != nil {
+		return err
+	}
+	meta, err := bucket.HeadObject(u.Key)
+	if err != nil {
+		return err
+	}
+	return a.print(meta, func(w io.Writer) {
+		printHeader(w, meta)
+	})
+}
+
+func printHeader(w io.Writer, h http.Header) {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(w, "%-30s %s\n", k+":", strings.Join(h[k], ", "))
+	}
+}
+
+func cmdPresign(a *app, args []string) error {
+	fs := a.newFlags("presign")
+	method := fs.String("method", "GET", "HTTP method the URL is signed for")
+	expires := fs.Int64("expires", 3600, "seconds the URL stays valid")
+	contentType := fs.String("content-type", "", "Content-Type the upload must use")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 1 {
+		return errUsage
+	}
+	u, err := parseOOSURL(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	bucket, err := a.bucket(u.Bucket)
+	if err != nil {
+		return err
+	}
+	var options []oos.Option
+	if *contentType != "" {
+		options = append(options, oos.ContentType(*contentType))
+	}
+	signed, err := bucket.SignURL(u.Key, oos.HTTPMethod(strings.ToUpper(*method)), *expires, options...)
+	if err != nil {
+		return err
+	}
+	return a.print(map[string]string{"url": signed}, func(w io.Writer) { fmt.Fprintln(w, signed) })
+}
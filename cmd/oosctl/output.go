@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/teamssix/oos-go-sdk/oos"
+)
+
+// printJSON prints v as indented JSON
+func (a *app) printJSON(v interface{}) error {
+	enc := json.NewEncoder(a.stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// print prints v as JSON with --output json, otherwise calls text
+func (a *app) print(v interface{}, text func(w io.Writer)) error {
+	if a.flags.output == "json" {
+		return a.printJSON(v)
+	}
+	text(a.stdout)
+	return nil
+}
+
+// progressBar prints the transfer progress on stderr
+type progressBar struct {
+	mu      sync.Mutex
+	w       io.Writer
+	name    string
+	percent int64
+	done    bool
+}
+
+func newProgressBar(w io.Writer, name string) *progressBar {
+	return &progressBar{w: w, name: name, percent: -1}
+}
+
+// ProgressChanged implements oos.ProgressListener
+func (p *progressBar) ProgressChanged(event *oos.ProgressEvent) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch event.EventType {
+	case oos.TransferDataEvent:
+		if event.TotalBytes <= 0 {
+			return
+		}
+		percent := event.ConsumedBytes * 100 / event.TotalBytes
+		if percent != p.percent && !p.done {
+			p.percent = percent
+			fmt.Fprintf(p.w, "\r%s  %3d%%  %s/%s", p.name, percent, humanBytes(event.ConsumedBytes), humanBytes(event.TotalBytes))
+			if event.ConsumedBytes >= event.TotalBytes {
+				// Downloads don't publish the completed event
+				p.done = true
+				fmt.Fprintln(p.w)
+			}
+		}
+	case oos.TransferCompletedEvent:
+		if p.percent >= 0 && !p.done {
+			p.done = true
+			fmt.Fprintln(p.w)
+		}
+	case oos.TransferFailedEvent:
+		fmt.Fprintf(p.w, "\r%s  failed\n", p.name)
+	}
+}
+
+func humanBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/teamssix/oos-go-sdk/oos"
+)
+
+func cmdSTS(a *app, args []string) error {
+	if len(args) < 1 {
+		return errUsage
+	}
+	fs := a.newFlags("sts " + args[0])
+	duration := fs.Int64("duration", 3600, "seconds the credentials stay valid")
+	session := fs.String("session", "oosctl", "role session name")
+	policy := fs.String("policy", "", "JSON policy which limits the session further")
+	if err := fs.Parse(args[1:]); err != nil {
+		return errUsage
+	}
+	client, err := a.iamClient()
+	if err != nil {
+		return err
+	}
+
+	var creds oos.Credentials
+	switch args[0] {
+	case "session-token":
+		if fs.NArg() != 0 {
+			return errUsage
+		}
+		out, err := client.GetSessionToken(*duration)
+		if err != nil {
+			return err
+		}
+		creds = out.Credentials
+	case "assume-role":
+		if fs.NArg() != 1 {
+			return errUsage
+		}
+		out, err := client.AssumeRole(fs.Arg(0), *session, *duration, *policy)
+		if err != nil {
+			return err
+		}
+		creds = out.Credentials
+	default:
+		return errUsage
+	}
+	return a.print(creds, func(w io.Writer) {
+		fmt.Fprintf(w, "export %s=%s\n", envAccessKeyID, creds.AccessKeyId)
+		fmt.Fprintf(w, "export %s=%s\n", envAccessKeySecret, creds.SecretAccessKey)
+		fmt.Fprintf(w, "export %s=%s\n", envSecurityToken, creds.SessionToken)
+		fmt.Fprintf(w, "# expires at %s\n", creds.Expiration.Format("2006-01-02T15:04:05Z07:00"))
+	})
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/teamssix/oos-go-sdk/oos"
+)
+
+func cmdUser(a *app, args []string) error {
+	if len(args) < 1 {
+		return errUsage
+	}
+	fs := a.newFlags("user " + args[0])
+	maxItems := fs.Int("max", 100, "max items to list")
+	if err := fs.Parse(args[1:]); err != nil {
+		return errUsage
+	}
+	client, err := a.iamClient()
+	if err != nil {
+		return err
+	}
+
+	if args[0] == "ls" {
+		var users []oos.IAMUser
+		marker := ""
+		for {
+			out, err := client.ListUsers(*maxItems, marker)
+			if err != nil {
+				return err
+			}
+			users = append(users, out.Users...)
+			if !out.IsTruncated || out.Marker == "" {
+				break
+			}
+			marker = out.Marker
+		}
+		return a.print(users, func(w io.Writer) {
+			for _, u := range users {
+				fmt.Fprintf(w, "%-24s %s\n", u.UserName, u.Arn)
+			}
+		})
+	}
+
+	if fs.NArg() < 1 {
+		return errUsage
+	}
+	name := fs.Arg(0)
+	switch args[0] {
+	case "create", "get":
+		var user oos.IAMUser
+		if args[0] == "create" {
+			user, err = client.CreateUser(name)
+		} else {
+			user, err = client.GetUser(name)
+		}
+		if err != nil {
+			return err
+		}
+		return a.print(user, func(w io.Writer) {
+			fmt.Fprintf(w, "UserName: %s\nUserId:   %s\nArn:      %s\n", user.UserName, user.UserId, user.Arn)
+		})
+	case "delete":
+		err = client.DeleteUser(name)
+	case "groups":
+		out, err := client.ListGroupsForUser(name, *maxItems, "")
+		if err != nil {
+			return err
+		}
+		return a.print(out.Groups, func(w io.Writer) {
+			for _, g := range out.Groups {
+				fmt.Fprintln(w, g.GroupName)
+			}
+		})
+	case "policies":
+		out, err := client.ListAttachedUserPolicies(name, *maxItems, "")
+		if err != nil {
+			return err
+		}
+		return a.print(out.AttachedPolicies, func(w io.Writer) {
+			for _, p := range out.AttachedPolicies {
+				fmt.Fprintf(w, "%-24s %s\n", p.PolicyName, p.PolicyArn)
+			}
+		})
+	case "add-to-group", "remove-from-group", "attach", "detach":
+		if fs.NArg() != 2 {
+			return errUsage
+		}
+		switch args[0] {
+		case "add-to-group":
+			err = client.AddUserToGroup(name, fs.Arg(1))
+		case "remove-from-group":
+			err = client.RemoveUserFromGroup(name, fs.Arg(1))
+		case "attach":
+			err = client.AttachUserPolicy(name, fs.Arg(1))
+		case "detach":
+			err = client.DetachUserPolicy(name, fs.Arg(1))
+		}
+	default:
+		return errUsage
+	}
+	if err != nil {
+		return err
+	}
+	return a.print(map[string]string{"user": name, "action": args[0]}, func(w io.Writer) {
+		fmt.Fprintln(w, args[0], name)
+	})
+}
+
+func cmdPolicy(a *app, args []string) error {
+	if len(args) < 1 || args[0] != "create" {
+		return errUsage
+	}
+	fs := a.newFlags("policy create")
+	description := fs.String("description", "", "policy description")
+	if err := fs.Parse(args[1:]); err != nil || fs.NArg() != 2 {
+		return errUsage
+	}
+	document, err := ioutil.ReadFile(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	client, err := a.iamClient()
+	if err != nil {
+		return err
+	}
+	policy, err := client.CreatePolicy(fs.Arg(0), string(document), *description)
+	if err != nil {
+		return err
+	}
+	return a.print(policy, func(w io.Writer) {
+		fmt.Fprintf(w, "PolicyName: %s\nArn:        %s\n", policy.PolicyName, policy.Arn)
+	})
+}
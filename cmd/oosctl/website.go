@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/teamssix/oos-go-sdk/oos"
+)
+
+// cmdDeploy publishes a local directory as the bucket website, or switches to a deployed release with -activate
+func cmdDeploy(a *app, args []string) error {
+	fs := a.newFlags("deploy")
+	release := fs.String("release", "", "release name (default the UTC time)")
+	activate := fs.String("activate", "", "switch the website to this deployed release instead of deploying, such as to roll back")
+	index := fs.String("index", "", "index document (default index.html)")
+	errorDoc := fs.String("error-document", "", "page served for the missing keys (default the index document)")
+	exclude := fs.String("exclude", "", "comma separated glob patterns of the files not to deploy")
+	keep := fs.Int("keep", 2, "releases kept, including the new one")
+	routines := fs.Int("routines", 5, "concurrent uploads")
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if *activate == "" && fs.NArg() != 2 || *activate != "" && fs.NArg() != 1 {
+		return errUsage
+	}
+	u, err := parseOOSURL(fs.Arg(fs.NArg() - 1))
+	if err != nil {
+		return err
+	}
+	bucket, err := a.bucket(u.Bucket)
+	if err != nil {
+		return err
+	}
+
+	opts := &oos.DeployWebsiteOptions{Release: *release, IndexDocument: *index, ErrorDocument: *errorDoc,
+		KeepReleases: *keep, Routines: *routines}
+	if *exclude != "" {
+		opts.Exclude = strings.Split(*exclude, ",")
+	}
+	if *activate != "" {
+		if err = bucket.ActivateWebsiteRelease(*activate, opts); err != nil {
+			return err
+		}
+		return a.print(map[string]string{"bucket": u.Bucket, "release": *activate}, func(w io.Writer) {
+			fmt.Fprintf(w, "activated %s oos://%s\n", *activate, u.Bucket)
+		})
+	}
+
+	res, err := bucket.DeployWebsite(fs.Arg(0), opts)
+	for _, f := range res.Failed {
+		fmt.Fprintf(a.stderr, "failed %s %s: %v\n", f.Action.Type, f.Action.Key, f.Err)
+	}
+	if err != nil {
+		return err
+	}
+	return a.print(res, func(w io.Writer) {
+		fmt.Fprintf(w, "deployed %s to oos://%s/%s uploaded:%d copied:%d bytes:%d\n",
+			res.Release, u.Bucket, res.Prefix, res.Uploaded, res.Copied, res.UploadedBytes)
+		for _, r := range res.DeletedReleases {
+			fmt.Fprintln(w, "deleted release", r)
+		}
+	})
+}
@@ -0,0 +1,413 @@
+package oos
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	accessLogUserAgentField = 16 // The user agent is the last quoted field, the ones after it aren't quoted
+	accessLogTimeFormat     = "02/Jan/2006:15:04:05 -0700"
+	accessLogKeyTimeFormat  = "2006-01-02-15-04-05"
+	accessLogMaxLine        = 1024 * 1024 // The longest line read, the user agents and the URIs may be long
+)
+
+// AccessLogDeliveryDelay is how long after a request its log object may be written. The log objects named up to the
+// delay after the end of the window are read by AccessLogReader.
+var AccessLogDeliveryDelay = time.Hour
+
+// AccessLogRecord is a line of the server access log written by SetBucketLogging. The fields logged as "-" are empty.
+type AccessLogRecord struct {
+	BucketOwner    string        // Canonical user ID of the bucket owner
+	Bucket         string        // The bucket of the request
+	Time           time.Time     // Time the request was received
+	RemoteIP       string        // The IP of the requester
+	Requester      string        // Canonical user ID or ARN of the requester, empty for the anonymous requests
+	RequestID      string        // The request ID
+	Operation      string        // The operation, such as REST.GET.OBJECT
+	Key            string        // The object key, empty for the bucket operations
+	RequestURI     string        // The Request-URI line, such as "GET /bucket/key HTTP/1.1"
+	HTTPStatus     int           // The HTTP status code of the response
+	ErrorCode      string        // The error code, such as NoSuchKey, empty if no error
+	BytesSent      int64         // Bytes of the response body
+	ObjectSize     int64         // Size of the object
+	TotalTime      time.Duration // Time from the request received to the response sent
+	TurnAroundTime time.Duration // Time the server spent processing the request
+	Referer        string        // The Referer header
+	UserAgent      string        // The User-Agent header
+	VersionID      string        // The version ID of the request
+	Extra          []string      // The fields after the version ID, such as the host ID and the signature version
+}
+
+// ParseAccessLogLine parses a line of the server access log, such as
+//
+//	owner bucket [06/Feb/2024:00:00:38 +0000] 192.0.2.3 requester 3E57427F3EXAMPLE REST.GET.OBJECT photos/a.jpg
+//	"GET /bucket/photos/a.jpg HTTP/1.1" 200 - 2662992 2662992 70 10 "-" "curl/7.15.1" -
+//
+// line    the log line.
+//
+// AccessLogRecord    the record, only valid when error is nil.
+// error    it's nil if no error, otherwise it's an error object.
+func ParseAccessLogLine(line string) (AccessLogRecord, error) {
+	var rec AccessLogRecord
+	fields, err := splitAccessLogLine(line)
+	if err != nil {
+		return rec, err
+	}
+	if len(fields) < 17 {
+		return rec, fmt.Errorf("oos: access log line has %d fields, at least 17 are expected", len(fields))
+	}
+	for i, field := range fields {
+		if field == "-" {
+			fields[i] = ""
+		}
+	}
+
+	rec.BucketOwner, rec.Bucket = fields[0], fields[1]
+	if rec.Time, err = time.Parse(accessLogTimeFormat, fields[2]); err != nil {
+		return rec, fmt.Errorf("oos: invalid access log time %q", fields[2])
+	}
+	rec.RemoteIP, rec.Requester, rec.RequestID, rec.Operation = fields[3], fields[4], fields[5], fields[6]
+	rec.Key = fields[7]
+	if key, err := url.PathUnescape(rec.Key); err == nil {
+		rec.Key = key
+	}
+	rec.RequestURI, rec.ErrorCode = fields[8], fields[10]
+
+	numbers := []struct {
+		name  string
+		field string
+		value *int64
+	}{
+		{"bytes sent", fields[11], &rec.BytesSent},
+		{"object size", fields[12], &rec.ObjectSize},
+	}
+	for _, n := range numbers {
+		if n.field == "" {
+			continue
+		}
+		if *n.value, err = strconv.ParseInt(n.field, 10, 64); err != nil {
+			return rec, fmt.Errorf("oos: invalid access log %s %q", n.name, n.field)
+		}
+	}
+	if fields[9] != "" {
+		if rec.HTTPStatus, err = strconv.Atoi(fields[9]); err != nil {
+			return rec, fmt.Errorf("oos: invalid access log status %q", fields[9])
+		}
+	}
+	durations := []struct {
+		name  string
+		field string
+		value *time.Duration
+	}{
+		{"total time", fields[13], &rec.TotalTime},
+		{"turn-around time", fields[14], &rec.TurnAroundTime},
+	}
+	for _, d := range durations {
+		if d.field == "" {
+			continue
+		}
+		ms, err := strconv.ParseInt(d.field, 10, 64)
+		if err != nil {
+			return rec, fmt.Errorf("oos: invalid access log %s %q", d.name, d.field)
+		}
+		*d.value = time.Duration(ms) * time.Millisecond
+	}
+	rec.Referer, rec.UserAgent = fields[15], fields[16]
+	if len(fields) > 17 {
+		rec.VersionID = fields[17]
+	}
+	if len(fields) > 18 {
+		rec.Extra = fields[18:]
+	}
+	return rec, nil
+}
+
+// splitAccessLogLine splits the line by the spaces, the fields in [] and "" may contain the spaces. The quotes in the
+// user agents aren't escaped, so it ends at the last quote of the line, and the quotes in the unquoted fields are errors
+// as the fields would be shifted.
+func splitAccessLogLine(line string) ([]string, error) {
+	var fields []string
+	line = strings.TrimRight(line, "\r\n")
+	for i := 0; i < len(line); {
+		if line[i] == ' ' {
+			i++
+			continue
+		}
+		switch line[i] {
+		case '[':
+			end := strings.IndexByte(line[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("oos: access log field at %d has no ']'", i)
+			}
+			fields = append(fields, line[i+1:i+end])
+			i += end + 1
+		case '"':
+			// The other fields end at the quote before a space
+			end := i + 1
+			if len(fields) == accessLogUserAgentField {
+				if end = strings.LastIndexByte(line, '"'); end == i || end+1 < len(line) && line[end+1] != ' ' {
+					end = len(line)
+				}
+			}
+			for ; end < len(line); end++ {
+				if line[end] == '"' && (end+1 == len(line) || line[end+1] == ' ') {
+					break
+				}
+			}
+			if end == len(line) {
+				return nil, fmt.Errorf("oos: access log field at %d has no closing quote", i)
+			}
+			fields = append(fields, line[i+1:end])
+			i = end + 1
+		default:
+			end := strings.IndexByte(line[i:], ' ')
+			if end < 0 {
+				end = len(line) - i
+			}
+			if strings.IndexByte(line[i:i+end], '"') >= 0 {
+				return nil, fmt.Errorf("oos: access log field at %d has an unexpected quote", i)
+			}
+			fields = append(fields, line[i:i+end])
+			i += end
+		}
+	}
+	return fields, nil
+}
+
+// AccessLogReader reads the records of the log objects under the logging prefix in a time window, such as
+//
+//	r := logBucket.NewAccessLogReader("logs/", time.Now().Add(-24*time.Hour), time.Now())
+//	defer r.Close()
+//	for r.Next() {
+//		record := r.Record()
+//	}
+//	if err := r.Err(); err != nil {
+//	}
+type AccessLogReader struct {
+	bucket       Object
+	prefix       string
+	start, end   time.Time
+	objects      *ObjectIterator
+	body         io.ReadCloser
+	scanner      *bufio.Scanner
+	record       AccessLogRecord
+	err          error
+	SkippedLines int // The lines which can't be parsed, they're skipped
+}
+
+// NewAccessLogReader creates the reader of the access logs. The log objects are named by the time they're written,
+// such as logs/2024-02-06-00-05-12-UNIQUEID, so only the objects from the start to AccessLogDeliveryDelay after the
+// end are read.
+//
+// prefix    the TargetPrefix of SetBucketLogging.
+// start    the start of the window, the records before it are skipped.
+// end    the end of the window, the records at or after it are skipped. It's unlimited if it's zero.
+//
+// *AccessLogReader    the reader, close it after use.
+func (bucket Object) NewAccessLogReader(prefix string, start, end time.Time) *AccessLogReader {
+	r := &AccessLogReader{bucket: bucket, prefix: prefix, start: start, end: end}
+	options := []Option{Prefix(prefix)}
+	if !start.IsZero() {
+		// The keys before the marker were written before the window
+		options = append(options, Marker(prefix+start.UTC().Format(accessLogKeyTimeFormat)))
+	}
+	r.objects = bucket.NewObjectIterator(options...)
+	return r
+}
+
+// Next moves to the next record in the window, it opens the next log object when the current one is done.
+// It returns false when there is no more record or the reading fails, check Err then.
+func (r *AccessLogReader) Next() bool {
+	for r.err == nil {
+		if r.scanner == nil && !r.openNext() {
+			return false
+		}
+		for r.scanner.Scan() {
+			line := r.scanner.Text()
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			rec, err := ParseAccessLogLine(line)
+			if err != nil {
+				r.SkippedLines++
+				continue
+			}
+			if rec.Time.Before(r.start) || !r.end.IsZero() && !rec.Time.Before(r.end) {
+				continue
+			}
+			r.record = rec
+			return true
+		}
+		r.err = r.scanner.Err()
+		r.closeBody()
+	}
+	return false
+}
+
+// openNext opens the next log object in the window, it returns false when there is no more object
+func (r *AccessLogReader) openNext() bool {
+	for r.objects.Next() {
+		key := r.objects.Object().Key
+		if !r.end.IsZero() {
+			name := strings.TrimPrefix(key, r.prefix)
+			if len(name) >= len(accessLogKeyTimeFormat) {
+				written, err := time.Parse(accessLogKeyTimeFormat, name[:len(accessLogKeyTimeFormat)])
+				if err == nil && written.After(r.end.Add(AccessLogDeliveryDelay)) {
+					// The keys are in order, the rest are written later
+					return false
+				}
+			}
+		}
+		body, err := r.bucket.GetObject(key)
+		if err != nil {
+			r.err = err
+			return false
+		}
+		r.body = body
+		r.scanner = bufio.NewScanner(body)
+		r.scanner.Buffer(make([]byte, 64*1024), accessLogMaxLine)
+		return true
+	}
+	r.err = r.objects.Err()
+	return false
+}
+
+func (r *AccessLogReader) closeBody() {
+	if r.body != nil {
+		r.body.Close()
+	}
+	r.body, r.scanner = nil, nil
+}
+
+// Record returns the current record, it's valid after Next returns true
+func (r *AccessLogReader) Record() AccessLogRecord {
+	return r.record
+}
+
+// Err returns the error which stopped the reading, or nil
+func (r *AccessLogReader) Err() error {
+	return r.err
+}
+
+// Close closes the log object being read
+func (r *AccessLogReader) Close() error {
+	r.closeBody()
+	return nil
+}
+
+// AccessLogCounter counts the requests, the errors and the bytes sent
+type AccessLogCounter struct {
+	Requests  int64 // Number of the requests
+	Errors    int64 // Number of the requests with the 4XX or 5XX status
+	BytesSent int64 // Bytes of the response bodies
+}
+
+// ErrorRate returns the ratio of the errors to the requests, it's 0 if there is no request
+func (c AccessLogCounter) ErrorRate() float64 {
+	if c.Requests == 0 {
+		return 0
+	}
+	return float64(c.Errors) / float64(c.Requests)
+}
+
+func (c *AccessLogCounter) add(rec AccessLogRecord) {
+	c.Requests++
+	if rec.HTTPStatus >= 400 {
+		c.Errors++
+	}
+	c.BytesSent += rec.BytesSent
+}
+
+// AccessLogRank is an entry of the top lists of AccessLogStats
+type AccessLogRank struct {
+	Name    string           // The key or the requester, "-" for the anonymous requester
+	Counter AccessLogCounter // The counts
+}
+
+// AccessLogStats aggregates the access log records
+type AccessLogStats struct {
+	Total       AccessLogCounter            // All the records
+	ByKey       map[string]AccessLogCounter // The object requests by key
+	ByRequester map[string]AccessLogCounter // The requests by requester, "-" for the anonymous requests
+	ByOperation map[string]AccessLogCounter // The requests by operation
+	ByStatus    map[int]int64               // Number of the requests by status code
+	ByErrorCode map[string]int64            // Number of the requests by error code
+}
+
+// NewAccessLogStats creates the empty aggregation
+func NewAccessLogStats() *AccessLogStats {
+	return &AccessLogStats{
+		ByKey:       map[string]AccessLogCounter{},
+		ByRequester: map[string]AccessLogCounter{},
+		ByOperation: map[string]AccessLogCounter{},
+		ByStatus:    map[int]int64{},
+		ByErrorCode: map[string]int64{},
+	}
+}
+
+// Add counts the record
+func (s *AccessLogStats) Add(rec AccessLogRecord) {
+	s.Total.add(rec)
+	addAccessLogCounter(s.ByOperation, rec.Operation, rec)
+	requester := rec.Requester
+	if requester == "" {
+		requester = "-"
+	}
+	addAccessLogCounter(s.ByRequester, requester, rec)
+	if rec.Key != "" {
+		addAccessLogCounter(s.ByKey, rec.Key, rec)
+	}
+	s.ByStatus[rec.HTTPStatus]++
+	if rec.ErrorCode != "" {
+		s.ByErrorCode[rec.ErrorCode]++
+	}
+}
+
+func addAccessLogCounter(counters map[string]AccessLogCounter, name string, rec AccessLogRecord) {
+	c := counters[name]
+	c.add(rec)
+	counters[name] = c
+}
+
+// TopKeys returns the n keys with the most requests, all of them if n is negative
+func (s *AccessLogStats) TopKeys(n int) []AccessLogRank {
+	return topAccessLogRanks(s.ByKey, n, func(c AccessLogCounter) int64 { return c.Requests })
+}
+
+// TopErrorKeys returns the n keys with the most errors, all of them if n is negative
+func (s *AccessLogStats) TopErrorKeys(n int) []AccessLogRank {
+	return topAccessLogRanks(s.ByKey, n, func(c AccessLogCounter) int64 { return c.Errors })
+}
+
+// TopRequestersByBytes returns the n requesters with the most bytes sent, all of them if n is negative
+func (s *AccessLogStats) TopRequestersByBytes(n int) []AccessLogRank {
+	return topAccessLogRanks(s.ByRequester, n, func(c AccessLogCounter) int64 { return c.BytesSent })
+}
+
+// topAccessLogRanks sorts the counters by the value in descending order then by the name, the zero values are left out
+func topAccessLogRanks(counters map[string]AccessLogCounter, n int, value func(AccessLogCounter) int64) []AccessLogRank {
+	ranks := make([]AccessLogRank, 0, len(counters))
+	for name, c := range counters {
+		if value(c) > 0 {
+			ranks = append(ranks, AccessLogRank{Name: name, Counter: c})
+		}
+	}
+	sort.Slice(ranks, func(i, j int) bool {
+		vi, vj := value(ranks[i].Counter), value(ranks[j].Counter)
+		if vi != vj {
+			return vi > vj
+		}
+		return ranks[i].Name < ranks[j].Name
+	})
+	if n >= 0 && len(ranks) > n {
+		ranks = ranks[:n]
+	}
+	return ranks
+}
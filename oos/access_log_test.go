@@ -0,0 +1,118 @@
+package oos
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseAccessLogLine(t *testing.T) {
+	const prefix = `owner bucket [06/Feb/2024:00:00:38 +0000] 192.0.2.3 requester 3E57427F3EXAMPLE REST.GET.OBJECT photos/a.jpg ` +
+		`"GET /bucket/photos/a.jpg HTTP/1.1" 200 - 2662992 2662992 70 10 "-" `
+	base := AccessLogRecord{
+		BucketOwner:    "owner",
+		Bucket:         "bucket",
+		Time:           time.Date(2024, 2, 6, 0, 0, 38, 0, time.UTC),
+		RemoteIP:       "192.0.2.3",
+		Requester:      "requester",
+		RequestID:      "3E57427F3EXAMPLE",
+		Operation:      "REST.GET.OBJECT",
+		Key:            "photos/a.jpg",
+		RequestURI:     "GET /bucket/photos/a.jpg HTTP/1.1",
+		HTTPStatus:     200,
+		BytesSent:      2662992,
+		ObjectSize:     2662992,
+		TotalTime:      70 * time.Millisecond,
+		TurnAroundTime: 10 * time.Millisecond,
+		UserAgent:      "curl/7.15.1",
+	}
+	with := func(change func(rec *AccessLogRecord)) AccessLogRecord {
+		rec := base
+		change(&rec)
+		return rec
+	}
+
+	cases := []struct {
+		name string
+		line string
+		want AccessLogRecord
+	}{
+		{"doc sample", prefix + `"curl/7.15.1" -`, base},
+		{"CRLF", prefix + `"curl/7.15.1" -` + "\r\n", base},
+		{"no version ID", prefix + `"curl/7.15.1"`, base},
+		{"user agent with quotes and spaces", prefix + `"Mozilla/5.0 (X11; "quoted" app) x" -`,
+			with(func(rec *AccessLogRecord) { rec.UserAgent = `Mozilla/5.0 (X11; "quoted" app) x` })},
+		{"user agent ending with a quote", prefix + `"agent "v1"" 3HL4kqtJlcpXroDTDmJ+rmSpXd3dIbrHY`,
+			with(func(rec *AccessLogRecord) {
+				rec.UserAgent = `agent "v1"`
+				rec.VersionID = "3HL4kqtJlcpXroDTDmJ+rmSpXd3dIbrHY"
+			})},
+		{"trailing fields", prefix + `"curl/7.15.1" v1 s9lzHYrFp76ZVxRcpX9+5cjAnEH2ROuNkd2BHfIa6UkFVdtjf5mKR3/eTPFvsiP/XV/VLi31234= SigV4 ECDHE-RSA-AES128-GCM-SHA256 AuthHeader bucket.oos-cn.ctyunapi.cn TLSv1.2`,
+			with(func(rec *AccessLogRecord) {
+				rec.VersionID = "v1"
+				rec.Extra = []string{"s9lzHYrFp76ZVxRcpX9+5cjAnEH2ROuNkd2BHfIa6UkFVdtjf5mKR3/eTPFvsiP/XV/VLi31234=", "SigV4",
+					"ECDHE-RSA-AES128-GCM-SHA256", "AuthHeader", "bucket.oos-cn.ctyunapi.cn", "TLSv1.2"}
+			})},
+		{"dash fields",
+			`owner bucket [06/Feb/2024:00:00:38 +0000] 192.0.2.3 - 3E57427F3EXAMPLE REST.GET.BUCKET - "GET /bucket HTTP/1.1" - - - - - - "-" "-" -`,
+			AccessLogRecord{BucketOwner: "owner", Bucket: "bucket", Time: base.Time, RemoteIP: "192.0.2.3",
+				RequestID: "3E57427F3EXAMPLE", Operation: "REST.GET.BUCKET", RequestURI: "GET /bucket HTTP/1.1"}},
+		{"escaped key and error",
+			`owner bucket [06/Feb/2024:08:00:38 +0800] 192.0.2.3 requester 3E57427F3EXAMPLE REST.GET.OBJECT a%20b/%E4%B8%AD.txt "GET /bucket/a%20b/%E4%B8%AD.txt HTTP/1.1" 404 NoSuchKey 243 - 5 - "https://example.com/" "curl/7.15.1" -`,
+			with(func(rec *AccessLogRecord) {
+				rec.Key = "a b/中.txt"
+				rec.RequestURI = "GET /bucket/a%20b/%E4%B8%AD.txt HTTP/1.1"
+				rec.HTTPStatus, rec.ErrorCode = 404, "NoSuchKey"
+				rec.BytesSent, rec.ObjectSize = 243, 0
+				rec.TotalTime, rec.TurnAroundTime = 5*time.Millisecond, 0
+				rec.Referer = "https://example.com/"
+			})},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := ParseAccessLogLine(c.line)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.Time.Equal(c.want.Time) {
+				t.Errorf("time %s, want %s", got.Time, c.want.Time)
+			}
+			got.Time = c.want.Time
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("record\n got %+v\nwant %+v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestParseAccessLogLineMalformed(t *testing.T) {
+	const request = `"GET /bucket/a HTTP/1.1"`
+	cases := []struct {
+		name string
+		line string
+	}{
+		{"empty", ""},
+		{"too few fields", `owner bucket [06/Feb/2024:00:00:38 +0000] 192.0.2.3 requester id REST.GET.OBJECT a ` + request + ` 200`},
+		{"unclosed time", `owner bucket [06/Feb/2024:00:00:38 +0000 192.0.2.3`},
+		{"invalid time", `owner bucket [2024-02-06 00:00:38] 192.0.2.3 requester id REST.GET.OBJECT a ` + request +
+			` 200 - 1 1 1 1 "-" "curl" -`},
+		{"unclosed request", `owner bucket [06/Feb/2024:00:00:38 +0000] 192.0.2.3 requester id REST.GET.OBJECT a "GET /bucket/a`},
+		{"quote in an unquoted field", `owner bucket [06/Feb/2024:00:00:38 +0000] 192.0.2.3 requester id REST.GET.OBJECT a"b ` + request +
+			` 200 - 1 1 1 1 "-" "curl" -`},
+		{"invalid status", `owner bucket [06/Feb/2024:00:00:38 +0000] 192.0.2.3 requester id REST.GET.OBJECT a ` + request +
+			` OK - 1 1 1 1 "-" "curl" -`},
+		{"invalid bytes sent", `owner bucket [06/Feb/2024:00:00:38 +0000] 192.0.2.3 requester id REST.GET.OBJECT a ` + request +
+			` 200 - 1k 1 1 1 "-" "curl" -`},
+		{"invalid total time", `owner bucket [06/Feb/2024:00:00:38 +0000] 192.0.2.3 requester id REST.GET.OBJECT a ` + request +
+			` 200 - 1 1 1.5 1 "-" "curl" -`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if rec, err := ParseAccessLogLine(c.line); err == nil {
+				t.Errorf("no error, record %+v", rec)
+			}
+		})
+	}
+}
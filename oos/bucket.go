@@ -64,6 +64,10 @@ func New(endpoint, accessKeyID, accessKeySecret string, options ...ClientOption)
 		option(client)
 	}
 
+	if config.IsStreamingSign && config.StreamingChunk < 8*1024 {
+		return nil, errors.New("oos: the streaming chunk size must be at least 8KB")
+	}
+
 	// Create HTTP connection
 	err := conn.init(config, url)
 
@@ -788,6 +792,39 @@ func SHA256ThresholdCalcInMemory(threshold int64) ClientOption {
 	}
 }
 
+// CorrectClockSkew sets whether to correct the signing time by the clock offset from the server. default is true
+// The offset is measured from the Date header of the responses, or the ServerTime of the RequestTimeTooSkewed
+// error, and a request failed with RequestTimeTooSkewed is retried once if its body can be rewound. Check out
+// Client.ClockOffset.
+//
+// isEnable    Whether to correct the signing time. true:enable ; false:disable
+func CorrectClockSkew(isEnable bool) ClientOption {
+	return func(client *Client) {
+		client.Config.IsCorrectClock = isEnable
+	}
+}
+
+// EnableStreamingSignature sets whether to sign the upload payload in aws-chunked streaming format. default is false
+// It's used with V4 signature and the sha256 payload hash. The payload is sent in signed chunks, so the uploads
+// with known length such as PutObject and UploadPart are neither buffered nor spooled to a temp file for the
+// sha256 and MD5 computing, and non-seekable readers can be used with the ContentLength option.
+//
+// isEnable    Whether to sign the payload in streaming format. true:enable ; false:disable
+func EnableStreamingSignature(isEnable bool) ClientOption {
+	return func(client *Client) {
+		client.Config.IsStreamingSign = isEnable
+	}
+}
+
+// StreamingChunkSize sets the chunk size of the streaming signature, default is 64KB.
+//
+// size    the chunk size in bytes, at least 8KB, New returns an error otherwise.
+func StreamingChunkSize(size int) ClientOption {
+	return func(client *Client) {
+		client.Config.StreamingChunk = size
+	}
+}
+
 // V4Signature set request signature . default is V4
 //
 // isV4    the signure is V4 or V2 . true:V4 ; false:V2
@@ -797,6 +834,40 @@ func V4Signature(isV4 bool) ClientOption {
 	}
 }
 
+// Region sets the V4 signing region. By default it's resolved from the endpoint, check out ResolveEndpoint.
+//
+// name    the region name such as cn.
+func Region(name string) ClientOption {
+	return func(client *Client) {
+		client.Config.Region = name
+	}
+}
+
+// SigningService sets the V4 signing service. By default it's resolved from the endpoint, check out ResolveEndpoint.
+//
+// name    the service name such as s3, sts or cloudtrail.
+func SigningService(name string) ClientOption {
+	return func(client *Client) {
+		client.Config.SigningService = name
+	}
+}
+
+// ServiceMapping maps the endpoint host label to the V4 signing service, on top of DefaultServiceMapping.
+//
+// label    the host label after the region, such as iam in oos-cn-iam.ctyunapi.cn.
+// service    the signing service such as sts.
+func ServiceMapping(label, service string) ClientOption {
+	return func(client *Client) {
+		if client.Config.ServiceMapping == nil {
+			client.Config.ServiceMapping = map[string]string{}
+			for k, v := range DefaultServiceMapping {
+				client.Config.ServiceMapping[k] = v
+			}
+		}
+		client.Config.ServiceMapping[label] = service
+	}
+}
+
 // UserAgent specifies UserAgent. The default is oos-go-sdk-go/1.2.0 (windows/-/amd64;go1.5.2).
 //
 // userAgent    the user agent string.
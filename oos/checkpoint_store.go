@@ -0,0 +1,212 @@
+package oos
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ErrCheckpointNotFound is returned by CheckpointStorage.Load when there is no checkpoint with the key
+var ErrCheckpointNotFound = errors.New("oos: checkpoint not found")
+
+// CheckpointStorage saves the checkpoints of UploadFile and DownloadFile, it's set by the CheckpointStore option.
+// The key is the checkpoint file path set by Checkpoint, or a name derived from the source and the destination.
+// The implementations must be safe for concurrent use.
+type CheckpointStorage interface {
+	// Load returns the checkpoint data, or ErrCheckpointNotFound if it doesn't exist.
+	Load(key string) ([]byte, error)
+
+	// Save creates or replaces the checkpoint data.
+	Save(key string, data []byte) error
+
+	// Delete removes the checkpoint, it's not an error if it doesn't exist.
+	Delete(key string) error
+}
+
+// FileCheckpointStore saves the checkpoints as local files, it's the default store.
+type FileCheckpointStore struct {
+	Dir string // The directory of the checkpoint files, the key is used as the file path if it's empty
+}
+
+// NewFileCheckpointStore creates the file store. The checkpoints are saved in dir, or at their keys if dir is empty.
+func NewFileCheckpointStore(dir string) *FileCheckpointStore {
+	return &FileCheckpointStore{Dir: dir}
+}
+
+// path returns the file of the key. In Dir the file name is the base name of the key with the MD5 of the whole key,
+// so that the keys such as a/x and b/x don't share the file.
+func (s *FileCheckpointStore) path(key string) string {
+	if s.Dir == "" {
+		return key
+	}
+	sum := md5.Sum([]byte(key))
+	return filepath.Join(s.Dir, filepath.Base(key)+"."+hex.EncodeToString(sum[:]))
+}
+
+// Load implements CheckpointStorage
+func (s *FileCheckpointStore) Load(key string) ([]byte, error) {
+	data, err := ioutil.ReadFile(s.path(key))
+	if os.IsNotExist(err) {
+		return nil, ErrCheckpointNotFound
+	}
+	return data, err
+}
+
+// Save implements CheckpointStorage
+func (s *FileCheckpointStore) Save(key string, data []byte) error {
+	return ioutil.WriteFile(s.path(key), data, FilePermMode)
+}
+
+// Delete implements CheckpointStorage
+func (s *FileCheckpointStore) Delete(key string) error {
+	err := os.Remove(s.path(key))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// MemoryCheckpointStore keeps the checkpoints in memory. It only resumes the transfers within the process,
+// such as the retries after network errors.
+type MemoryCheckpointStore struct {
+	mu   sync.Mutex
+	data map[string][]byte
+}
+
+// NewMemoryCheckpointStore creates the memory store
+func NewMemoryCheckpointStore() *MemoryCheckpointStore {
+	return &MemoryCheckpointStore{data: map[string][]byte{}}
+}
+
+// Load implements CheckpointStorage
+func (s *MemoryCheckpointStore) Load(key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.data[key]
+	if !ok {
+		return nil, ErrCheckpointNotFound
+	}
+	return append([]byte(nil), data...), nil
+}
+
+// Save implements CheckpointStorage
+func (s *MemoryCheckpointStore) Save(key string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data[key] = append([]byte(nil), data...)
+	return nil
+}
+
+// Delete implements CheckpointStorage
+func (s *MemoryCheckpointStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.data, key)
+	return nil
+}
+
+// ObjectCheckpointStore saves the checkpoints as objects in a bucket, so that the transfers of
+// stateless containers can resume after a restart.
+type ObjectCheckpointStore struct {
+	Bucket Object // The bucket to save the checkpoints
+	Prefix string // The key prefix of the checkpoint objects, such as "checkpoints/"
+}
+
+// NewObjectCheckpointStore creates the store which saves the checkpoints under the prefix of the bucket
+func NewObjectCheckpointStore(bucket Object, prefix string) *ObjectCheckpointStore {
+	return &ObjectCheckpointStore{Bucket: bucket, Prefix: prefix}
+}
+
+func (s *ObjectCheckpointStore) objectKey(key string) string {
+	return s.Prefix + strings.TrimLeft(filepath.ToSlash(key), "/")
+}
+
+// Load implements CheckpointStorage
+func (s *ObjectCheckpointStore) Load(key string) ([]byte, error) {
+	body, err := s.Bucket.GetObject(s.objectKey(key))
+	if err != nil {
+		if serr, ok := err.(ServiceError); ok && serr.StatusCode == 404 {
+			return nil, ErrCheckpointNotFound
+		}
+		return nil, err
+	}
+	defer body.Close()
+	return ioutil.ReadAll(body)
+}
+
+// Save implements CheckpointStorage
+func (s *ObjectCheckpointStore) Save(key string, data []byte) error {
+	return s.Bucket.PutObject(s.objectKey(key), bytes.NewReader(data))
+}
+
+// Delete implements CheckpointStorage
+func (s *ObjectCheckpointStore) Delete(key string) error {
+	return s.Bucket.DeleteObject(s.objectKey(key))
+}
+
+// defaultCheckpointStore is used when the CheckpointStore option is not set
+var defaultCheckpointStore CheckpointStorage = NewFileCheckpointStore("")
+
+// getCheckpointStore gets the checkpoint store from the options
+func getCheckpointStore(options []Option) CheckpointStorage {
+	isSet, store, _ := isOptionSet(options, checkpointStore)
+	if !isSet || store == nil {
+		return defaultCheckpointStore
+	}
+	return store.(CheckpointStorage)
+}
+
+// getCheckpointKey returns the checkpoint key, or "" if the checkpoint is disabled. The checkpoint is enabled by
+// Checkpoint or CheckpointDir, or by CheckpointStore alone. cpFilePath gets the key from the checkpoint config,
+// and the key is derived from src and dest when the config has no path.
+func getCheckpointKey(options []Option, cpFilePath func(*cpConfig) string, src, dest string) string {
+	cpConf := getCpConfig(options)
+	if cpConf == nil {
+		if isSet, _, _ := isOptionSet(options, checkpointStore); !isSet {
+			return ""
+		}
+		cpConf = &cpConfig{IsEnable: true}
+	}
+	if !cpConf.IsEnable {
+		return ""
+	}
+	if key := cpFilePath(cpConf); key != "" {
+		return key
+	}
+	return getCpFileName(src, dest)
+}
+
+// loadCheckpoint loads the checkpoint from the store and unmarshals it
+func loadCheckpoint(store CheckpointStorage, key string, cp interface{}) error {
+	contents, err := store.Load(key)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(contents, cp)
+}
+
+// dumpCheckpoint marshals the checkpoint and saves it to the store. setMD5 sets the MD5 field of the copy
+// of the checkpoint: it's cleared to calculate the MD5 of the content, then set to the result.
+func dumpCheckpoint(store CheckpointStorage, key string, cp interface{}, setMD5 func(string)) error {
+	setMD5("")
+	js, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	sum := md5.Sum(js)
+	setMD5(base64.StdEncoding.EncodeToString(sum[:]))
+
+	js, err = json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	return store.Save(key, js)
+}
@@ -0,0 +1,152 @@
+package oos
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// clockSkewResolution is the precision of the measured offset. The Date header has the second resolution and
+// the response takes some time, so the smaller offsets are ignored.
+const clockSkewResolution = 2 * time.Second
+
+// maxClockOffset bounds the offset adopted from the 2xx responses, the larger ones come from the wrong Date headers
+// such as the cached responses of the proxies. The error responses, including RequestTimeTooSkewed, aren't cached,
+// so their offsets are always adopted, even the whole hours of the hosts set to the local time.
+const maxClockOffset = time.Hour
+
+// clockOffset is the offset between the server clock and the local clock, it's shared by the copies of Conn.
+type clockOffset struct {
+	mu     sync.RWMutex
+	offset time.Duration
+}
+
+func (c *clockOffset) get() time.Duration {
+	if c == nil {
+		return 0
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.offset
+}
+
+// update measures the offset with the server time of the response received at local time. The offset of an hour
+// or more is ignored unless the response is trusted, check out maxClockOffset.
+func (c *clockOffset) update(server, local time.Time, trusted bool) {
+	if c == nil {
+		return
+	}
+	offset := server.Sub(local)
+	if !trusted && (offset <= -maxClockOffset || offset >= maxClockOffset) {
+		return
+	}
+	if offset > -clockSkewResolution && offset < clockSkewResolution {
+		offset = 0
+	}
+	c.mu.Lock()
+	c.offset = offset
+	c.mu.Unlock()
+}
+
+// now returns the signing time, which is the local time corrected by the clock offset
+func (conn Conn) now() time.Time {
+	if !conn.config.IsCorrectClock {
+		return time.Now()
+	}
+	return time.Now().Add(conn.clock.get())
+}
+
+// updateClockOffset measures the clock offset with the Date header of the response, only the 2xx responses may
+// be the cached ones of the proxies.
+func (conn Conn) updateClockOffset(resp *http.Response) {
+	if resp == nil {
+		return
+	}
+	t, err := http.ParseTime(resp.Header.Get(HTTPHeaderDate))
+	if err != nil {
+		return
+	}
+	conn.clock.update(t, time.Now(), resp.StatusCode < 200 || resp.StatusCode >= 300)
+}
+
+// updateClockOffsetBySkewError measures the clock offset with the ServerTime of the RequestTimeTooSkewed error.
+// The offset of the Date header is kept if the error body has no ServerTime.
+func (conn Conn) updateClockOffsetBySkewError(err error) {
+	serr, ok := err.(ServiceError)
+	if !ok || serr.Code != "RequestTimeTooSkewed" {
+		return
+	}
+	var body struct {
+		ServerTime string `xml:"ServerTime"`
+	}
+	if xml.Unmarshal([]byte(serr.RawMessage), &body) != nil {
+		return
+	}
+	t, err := time.Parse(time.RFC3339, body.ServerTime)
+	if err != nil {
+		return
+	}
+	conn.clock.update(t, time.Now(), true)
+}
+
+// ClockOffset returns the offset between the server clock and the local clock measured by the last response,
+// it's positive if the local clock is slow. The offsets smaller than 2 seconds are reported as 0. The offsets of
+// an hour or more are ignored for the 2xx responses, which may be cached by the proxies, but they're adopted
+// from the error responses such as RequestTimeTooSkewed.
+// The signing time is corrected by the offset unless CorrectClockSkew is disabled.
+func (client Client) ClockOffset() time.Duration {
+	return client.Conn.clock.get()
+}
+
+// isRequestTimeTooSkewed checks if the error is the clock skew error of the service
+func isRequestTimeTooSkewed(err error) bool {
+	serr, ok := err.(ServiceError)
+	return ok && serr.Code == "RequestTimeTooSkewed"
+}
+
+// bodyRewinder returns the function which rewinds the body to the current position for the retry, or nil if
+// the body can't be rewound. The readers limited by io.LimitReader are rewound with the limit, and a bytes.Buffer
+// is replaced by a bytes.Reader of its content, so the returned body must be sent instead.
+func bodyRewinder(body io.Reader) (io.Reader, func() bool) {
+	if buf, ok := body.(*bytes.Buffer); ok && buf != nil {
+		body = bytes.NewReader(buf.Bytes())
+	}
+	return body, rewinderOf(body)
+}
+
+func rewinderOf(body io.Reader) func() bool {
+	switch v := body.(type) {
+	case nil:
+		return func() bool { return true }
+	case io.Seeker:
+		pos, err := v.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil
+		}
+		return func() bool {
+			_, err := v.Seek(pos, io.SeekStart)
+			return err == nil
+		}
+	case *io.LimitedReader:
+		seeker, ok := v.R.(io.Seeker)
+		if !ok {
+			return nil
+		}
+		pos, err := seeker.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil
+		}
+		n := v.N
+		return func() bool {
+			if _, err := seeker.Seek(pos, io.SeekStart); err != nil {
+				return false
+			}
+			v.N = n
+			return true
+		}
+	}
+	return nil
+}
@@ -30,6 +30,18 @@ type Config struct {
 	IsEnableSHA256  bool        // Flag of enabling sha256 hash for upload.
 	SHA256Threshold int64       // Memory footprint threshold for each sha256 hash computation (16MB is the default), in byte. When the data is more than that, temp file is used.
 	IsV4Sign        bool        // default use V2 signature
+	IsCorrectClock  bool        // Flag of correcting the signing time by the clock offset measured from the server Date header. Default is true.
+	IsStreamingSign bool        // Flag of signing the upload payload in aws-chunked streaming format, instead of hashing the whole payload first.
+	StreamingChunk  int         // Chunk size of the streaming signature (64KB is the default), in byte.
+	Region          string      // V4 signing region, it's resolved from the endpoint if it's empty
+	SigningService  string      // V4 signing service, it's resolved from the endpoint if it's empty
+
+	ServiceMapping map[string]string // Endpoint host label to V4 signing service, DefaultServiceMapping is used if it's nil
+}
+
+// isStreamingSign reports if the upload payload is signed in aws-chunked streaming format
+func (config *Config) isStreamingSign() bool {
+	return config.IsV4Sign && config.IsEnableSHA256 && config.IsStreamingSign
 }
 
 // getDefaultoosConfig gets the default configuration.
@@ -58,6 +70,8 @@ func getDefaultoosConfig() *Config {
 	config.IsEnableSHA256 = true
 
 	config.IsV4Sign = true
+	config.IsCorrectClock = true
+	config.StreamingChunk = 64 * 1024 // 64KB
 
 	return &config
 }
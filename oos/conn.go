@@ -19,6 +19,8 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/teamssix/oos-go-sdk/signer"
 )
 
 // Conn defines oos Conn
@@ -26,15 +28,9 @@ type Conn struct {
 	config *Config
 	url    *urlMaker
 	client *http.Client
+	clock  *clockOffset
 }
 
-//var signKeyList = []string{"acl", "uploads", "location", "cors", "logging", "website", "referer", "lifecycle", "delete", "append", "tagging", "objectMeta", "uploadId", "partNumber", "security-token", "position", "img", "style", "styleName", "replication", "replicationProgress", "replicationLocation", "cname", "bucketInfo", "comp", "qos", "live", "status", "vod", "startTime", "endTime", "symlink", "x-oos-process", "response-content-type", "response-content-language", "response-expires", "response-cache-control", "response-content-disposition", "response-content-encoding", "udf", "udfName", "udfImage", "udfId", "udfImageDesc", "udfApplication", "comp", "udfApplicationLog", "restore", "callback", "callback-var"}
-
-var signKeyList = []string{"acl", "torrent", "logging", "location", "policy", "requestPayment", "versioning",
-	"versions", "versionId", "notification", "uploadId", "uploads", "partNumber", "website",
-	"delete", "lifecycle", "tagging", "cors", "restore", "response-cache-control", "response-content-disposition",
-	"response-content-type", "response-content-language", "response-content-encoding", "response-expires"}
-
 // init initializes Conn
 func (conn *Conn) init(config *Config, urlMaker *urlMaker) error {
 	// New transport
@@ -43,6 +39,9 @@ func (conn *Conn) init(config *Config, urlMaker *urlMaker) error {
 	conn.config = config
 	conn.url = urlMaker
 	conn.client = &http.Client{Transport: transport}
+	if conn.clock == nil {
+		conn.clock = &clockOffset{}
+	}
 
 	return nil
 }
@@ -51,16 +50,22 @@ func (conn *Conn) init(config *Config, urlMaker *urlMaker) error {
 func (conn Conn) Do(method, bucketName, objectName string, params map[string]interface{}, headers map[string]string,
 	data io.Reader, listener ProgressListener) (*Response, error) {
 	urlParams := conn.getURLParams(params)
-	subResource := conn.getSubResource(params)
 	uri := conn.url.getURL(bucketName, objectName, urlParams)
-	canonResource := ""
-	if conn.config.IsV4Sign {
-		canonResource = conn.url.getResourceV4(bucketName, objectName, subResource)
-	} else {
-		canonResource = conn.url.getResource(bucketName, objectName, subResource)
+	resource := conn.url.getResource(bucketName, objectName, urlParams)
+	isUpload := isUploadRequest(method, objectName, params)
+
+	// The signing time is corrected by the ServerTime or the Date of the skew error response, whatever the offset
+	// is, then the request is retried once if the body can be rewound
+	data, rewind := bodyRewinder(data)
+	resp, err := conn.doRequest(method, uri, resource, headers, data, listener, isUpload)
+	conn.updateClockOffsetBySkewError(err)
+	if err != nil && conn.config.IsCorrectClock && isRequestTimeTooSkewed(err) && rewind != nil && rewind() {
+		if resp != nil {
+			resp.Body.Close()
+		}
+		resp, err = conn.doRequest(method, uri, resource, headers, data, listener, isUpload)
 	}
-
-	return conn.doRequest(method, uri, canonResource, headers, params, data, listener)
+	return resp, err
 }
 
 // DoURL sends the request with signed URL and returns the response result.
@@ -84,7 +89,7 @@ func (conn Conn) DoURL(method HTTPMethod, signedURL string, headers map[string]s
 	}
 
 	tracker := &readerTracker{completedBytes: 0}
-	fd := conn.handleBody(req, data, listener, tracker, true)
+	fd := conn.handleBody(req, data, listener, tracker, true, false)
 	if fd != nil {
 		defer func() {
 			fd.Close()
@@ -105,6 +110,7 @@ func (conn Conn) DoURL(method HTTPMethod, signedURL string, headers map[string]s
 	publishProgress(listener, event)
 
 	resp, err := conn.client.Do(req)
+	conn.updateClockOffset(resp)
 	if err != nil {
 		// Transfer failed
 		event = newProgressEvent(TransferFailedEvent, tracker.completedBytes, req.ContentLength)
@@ -142,42 +148,22 @@ func (conn Conn) getURLParams(params map[string]interface{}) string {
 	return buf.String()
 }
 
-func (conn Conn) getSubResource(params map[string]interface{}) string {
-	// Sort
-	keys := make([]string, 0, len(params))
-	for k := range params {
-		if conn.isParamSign(k) {
-			keys = append(keys, k)
-		}
-	}
-	sort.Strings(keys)
-
-	// Serialize
-	var buf bytes.Buffer
-	for _, k := range keys {
-		if buf.Len() > 0 {
-			buf.WriteByte('&')
-		}
-		buf.WriteString(k)
-		if params[k] != nil {
-			buf.WriteString("=" + params[k].(string))
-		}
+// isUploadRequest checks if the request uploads the object data, such as PutObject and UploadPart
+func isUploadRequest(method, objectName string, params map[string]interface{}) bool {
+	if strings.ToUpper(method) != "PUT" || objectName == "" {
+		return false
 	}
-
-	return buf.String()
-}
-
-func (conn Conn) isParamSign(paramKey string) bool {
-	for _, k := range signKeyList {
-		if paramKey == k {
-			return true
+	for k := range params {
+		if k != "partNumber" && k != "uploadId" {
+			return false
 		}
 	}
-	return false
+	return true
 }
 
-func (conn Conn) doRequest(method string, uri *url.URL, canonicalizedResource string, headers map[string]string,
-	params map[string]interface{}, data io.Reader, listener ProgressListener) (*Response, error) {
+// doRequest sends the request to uri, the signature is of resource, the path-style URL of the request
+func (conn Conn) doRequest(method string, uri, resource *url.URL, headers map[string]string,
+	data io.Reader, listener ProgressListener, isUpload bool) (*Response, error) {
 	method = strings.ToUpper(method)
 	req := &http.Request{
 		Method:     method,
@@ -189,8 +175,15 @@ func (conn Conn) doRequest(method string, uri *url.URL, canonicalizedResource st
 		Host:       uri.Host,
 	}
 
+	if conn.config.isStreamingSign() {
+		// the length of the readers without size, such as the non-seekable ones, is set by the ContentLength option
+		if length, err := strconv.ParseInt(headers[HTTPHeaderContentLength], 10, 64); err == nil {
+			req.ContentLength = length
+		}
+	}
+
 	tracker := &readerTracker{completedBytes: 0}
-	fd := conn.handleBody(req, data, listener, tracker, false)
+	fd := conn.handleBody(req, data, listener, tracker, false, isUpload)
 	if fd != nil {
 		defer func() {
 			fd.Close()
@@ -198,14 +191,12 @@ func (conn Conn) doRequest(method string, uri *url.URL, canonicalizedResource st
 		}()
 	}
 
-	date := ""
+	// The same time is signed in the date header and in the V4 scope
+	now := conn.now().UTC()
 	if conn.config.IsV4Sign {
-		date = time.Now().UTC().Format("20060102T150405Z")
-		//date = "20220914T075027Z"
-		req.Header.Set(HTTPHeaderXamzDate, date)
+		req.Header.Set(HTTPHeaderXamzDate, now.Format(signer.TimeFormatV4))
 	} else {
-		date = time.Now().UTC().Format(http.TimeFormat)
-		req.Header.Set(HTTPHeaderDate, date)
+		req.Header.Set(HTTPHeaderDate, now.Format(http.TimeFormat))
 	}
 
 	req.Header.Set(HTTPHeaderHost, conn.config.Endpoint)
@@ -219,9 +210,12 @@ func (conn Conn) doRequest(method string, uri *url.URL, canonicalizedResource st
 	}
 
 	if conn.config.IsV4Sign {
-		conn.signHeaderV4(req, date, canonicalizedResource, params)
+		signature := conn.signHeaderV4(req, resource, now)
+		if req.Header.Get(HTTPHeaderoosContentSHA256) == signer.StreamingPayload {
+			conn.streamBodyV4(req, now, signature)
+		}
 	} else {
-		conn.signHeader(req, canonicalizedResource)
+		conn.signHeader(req, resource)
 	}
 
 	// Transfer started
@@ -233,6 +227,7 @@ func (conn Conn) doRequest(method string, uri *url.URL, canonicalizedResource st
 	// }
 
 	resp, err := conn.client.Do(req)
+	conn.updateClockOffset(resp)
 	if err != nil {
 		// Transfer failed
 		event = newProgressEvent(TransferFailedEvent, tracker.completedBytes, req.ContentLength)
@@ -251,14 +246,6 @@ func (conn Conn) signURL(method HTTPMethod, bucketName, objectName string, expir
 	if conn.config.SecurityToken != "" {
 		params[HTTPParamSecurityToken] = conn.config.SecurityToken
 	}
-	subResource := conn.getSubResource(params)
-
-	canonResource := ""
-	if conn.config.IsV4Sign {
-		canonResource = conn.url.getResourceV4(bucketName, objectName, subResource)
-	} else {
-		canonResource = conn.url.getResource(bucketName, objectName, subResource)
-	}
 
 	m := strings.ToUpper(string(method))
 	req := &http.Request{
@@ -266,11 +253,12 @@ func (conn Conn) signURL(method HTTPMethod, bucketName, objectName string, expir
 		Header: make(http.Header),
 	}
 
+	now := conn.now().UTC()
 	date := ""
 	if conn.config.IsV4Sign {
-		date = time.Now().UTC().Format("20060102T150405Z")
+		date = now.Format(signer.TimeFormatV4)
 	} else {
-		expiration := time.Now().UTC().Unix() + expiredInSec
+		expiration := now.Unix() + expiredInSec
 		date = strconv.FormatInt(expiration, 10)
 		req.Header.Set(HTTPHeaderDate, date)
 	}
@@ -284,36 +272,31 @@ func (conn Conn) signURL(method HTTPMethod, bucketName, objectName string, expir
 		}
 	}
 
-	_, SignedHeaders := conn.getCanonicalHeadersV4(req)
-	scope, _, _, _ := conn.getScopeV4(req)
-
 	if conn.config.IsV4Sign {
-		params[HTTPParamXAmzAlgorithm] = "AWS4-HMAC-SHA256"
+		params[HTTPParamXAmzAlgorithm] = signer.AlgorithmV4
 		params[HTTPParamXAmzExpires] = strconv.FormatInt(expiredInSec, 10)
-		params[HTTPParamXAmzCredential] = conn.config.AccessKeyID + "/" + scope
-		params[HTTPParamXAmzSignedHeaders] = SignedHeaders
+		params[HTTPParamXAmzCredential] = conn.config.AccessKeyID + "/" + conn.getScopeV4(now)
+		params[HTTPParamXAmzSignedHeaders] = strings.Join(conn.getCanonicalHeadersV4(req), ";")
 		params[HTTPParamXAmzDate] = date
 	} else {
 		params[HTTPParamExpires] = date
 		params[HTTPParamAWSAccessKeyID] = conn.config.AccessKeyID
 	}
 
-	signedStr := ""
+	resource := conn.url.getResource(bucketName, objectName, conn.getURLParams(params))
 	if conn.config.IsV4Sign {
-		signedStr, _, _ = conn.getSignedStrV4(req, date, canonResource, params, true)
-		params[HTTPParamXAmzSignature] = signedStr
+		params[HTTPParamXAmzSignature], _ = conn.getSignedStrV4(req, resource, now, signer.UnsignedPayload)
 	} else {
-		signedStr = conn.getSignedStr(req, canonResource)
-		params[HTTPParamSignature] = signedStr
+		params[HTTPParamSignature] = conn.getSignedStr(req, resource)
 	}
 
 	urlParams := conn.getURLParams(params)
 	return conn.url.getSignURL(bucketName, objectName, urlParams)
 }
 
-// handleBody handles request body
+// handleBody handles request body, isUpload is set for the object uploads which can be streamed
 func (conn Conn) handleBody(req *http.Request, body io.Reader,
-	listener ProgressListener, tracker *readerTracker, isSignUrl bool) *os.File {
+	listener ProgressListener, tracker *readerTracker, isSignUrl, isUpload bool) *os.File {
 	var file *os.File
 	reader := body
 
@@ -332,8 +315,16 @@ func (conn Conn) handleBody(req *http.Request, body io.Reader,
 	}
 	req.Header.Set(HTTPHeaderContentLength, strconv.FormatInt(req.ContentLength, 10))
 
+	// The streaming payload is signed chunk by chunk, it's not read for the MD5 and sha256.
+	// Only the object uploads are streamed, the other bodies such as the bucket configurations are signed as a whole
+	// and sent with Content-MD5.
+	streaming := isUpload && !isSignUrl && conn.config.isStreamingSign() && body != nil && req.ContentLength > 0
+
+	// The V2 presigned URLs sign Content-MD5, it's only sent if it's set by the option before signing
+	presignedV2 := isSignUrl && req.URL.Query().Get(HTTPParamXAmzSignature) == ""
+
 	// MD5
-	if body != nil && req.Header.Get(HTTPHeaderContentMD5) == "" {
+	if body != nil && !streaming && !presignedV2 && req.Header.Get(HTTPHeaderContentMD5) == "" {
 		md5 := ""
 		reader, md5, file, _ = calcMD5(body, req.ContentLength, conn.config.MD5Threshold)
 		req.Header[HTTPHeaderContentMD5] = []string{md5}
@@ -342,7 +333,10 @@ func (conn Conn) handleBody(req *http.Request, body io.Reader,
 	if isSignUrl == false && conn.config.IsV4Sign == true {
 		// v4 SHA258
 		if req.Header.Get(HTTPHeaderoosContentSHA256) == "" {
-			if conn.config.IsEnableSHA256 {
+			if streaming {
+				req.Header.Set(HTTPHeaderoosContentSHA256, signer.StreamingPayload)
+				req.Header.Set(HTTPHeaderoosDecodedContentLength, strconv.FormatInt(req.ContentLength, 10))
+			} else if conn.config.IsEnableSHA256 {
 				sha256 := ""
 				if body != nil {
 					reader, sha256, file, _ = calcSha256Hash(body, req.ContentLength, conn.config.SHA256Threshold)
@@ -366,6 +360,31 @@ func (conn Conn) handleBody(req *http.Request, body io.Reader,
 	return file
 }
 
+// streamBodyV4 replaces the body with the aws-chunked body signed with the seed signature of the request
+func (conn Conn) streamBodyV4(req *http.Request, t time.Time, seedSignature string) {
+	region, service := conn.config.signingScope()
+	chunkSize := conn.config.StreamingChunk
+	if chunkSize < 8*1024 {
+		chunkSize = signer.DefaultChunkSize
+	}
+	decodedLength := req.ContentLength
+
+	if enc := req.Header.Get(HTTPHeaderContentEncoding); enc != "" {
+		req.Header.Set(HTTPHeaderContentEncoding, "aws-chunked,"+enc)
+	} else {
+		req.Header.Set(HTTPHeaderContentEncoding, "aws-chunked")
+	}
+	req.ContentLength = signer.ChunkedContentLength(decodedLength, int64(chunkSize))
+	req.Header.Set(HTTPHeaderContentLength, strconv.FormatInt(req.ContentLength, 10))
+
+	body := signer.NewChunkedReaderV4(req.Body, decodedLength, chunkSize,
+		signer.SigningKeyV4(conn.config.AccessKeySecret, t, region, service), t, conn.getScopeV4(t), seedSignature)
+	req.Body = struct {
+		io.Reader
+		io.Closer
+	}{body, req.Body}
+}
+
 func tryGetFileSize(f *os.File) int64 {
 	fInfo, _ := f.Stat()
 	return fInfo.Size()
@@ -403,13 +422,21 @@ func (conn Conn) handleResponse(resp *http.Response) (*Response, error) {
 			Body:       ioutil.NopCloser(bytes.NewReader(respBody)), // restore the body
 		}, err
 	} else if statusCode >= 300 && statusCode <= 307 {
-		// oos use 3xx, but response has no body
-		err := fmt.Errorf("oos: service returned %d,%s", resp.StatusCode, resp.Status)
+		// oos use 3xx, the body has the error such as PermanentRedirect if it isn't empty
+		respBody, err := readResponseBody(resp)
+		if err != nil {
+			return nil, err
+		}
+		srvErr, errIn := serviceErrFromXML(respBody, resp.StatusCode, resp.Header.Get(HTTPHeaderoosRequestID))
+		if len(respBody) == 0 || errIn != nil {
+			srvErr = ServiceError{Message: resp.Status, RequestID: resp.Header.Get(HTTPHeaderoosRequestID),
+				RawMessage: string(respBody), StatusCode: resp.StatusCode}
+		}
 		return &Response{
 			StatusCode: resp.StatusCode,
 			Headers:    resp.Header,
-			Body:       resp.Body,
-		}, err
+			Body:       ioutil.NopCloser(bytes.NewReader(respBody)), // restore the body
+		}, srvErr
 	}
 
 	// 2xx, successful
@@ -723,36 +750,17 @@ func (um urlMaker) buildURL(bucket, object string) (string, string) {
 	return host, path
 }
 
-// getResource gets canonicalized resource
-func (um urlMaker) getResource(bucketName, objectName, subResource string) string {
-	resource := ""
-	if bucketName != "" {
-		resource += "/" + bucketName
-	}
-	if objectName != "" {
-		objectName = um.UriEncode(objectName, true)
-		resource += "/" + objectName
-	}
-	if subResource != "" {
-		resource += "?" + subResource
-	}
-	if resource == "" {
-		resource += "/"
-	}
-	return resource
-}
-
-func (um urlMaker) getResourceV4(bucketName, objectName, subResource string) string {
-	resource := ""
+// getResource gets the path-style URL of the signature, the V2 subresources and the V4 query are got from params
+func (um urlMaker) getResource(bucketName, objectName, params string) *url.URL {
+	path := ""
 	if bucketName != "" {
-		resource += "/" + bucketName
+		path += "/" + bucketName
 	}
 	if objectName != "" {
-		objectName = um.UriEncode(objectName, true)
-		resource += "/" + objectName
+		path += "/" + objectName
 	}
-	if resource == "" {
-		resource += "/"
+	if path == "" {
+		path = "/"
 	}
-	return resource
+	return &url.URL{Path: path, RawQuery: params}
 }
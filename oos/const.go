@@ -50,6 +50,18 @@ type BucketMode string
 
 const (
 	BucketModeCompliance BucketMode = "COMPLIANCE"
+	BucketModeGovernance BucketMode = "GOVERNANCE"
+)
+
+// LegalHoldStatusType the status of the object legal hold
+type LegalHoldStatusType string
+
+const (
+	// LegalHoldOn the object can't be overwritten or deleted until the legal hold is removed
+	LegalHoldOn LegalHoldStatusType = "ON"
+
+	// LegalHoldOff the object has no legal hold
+	LegalHoldOff LegalHoldStatusType = "OFF"
 )
 
 // PayerType the type of request payer
@@ -106,6 +118,16 @@ const (
 	HTTPHeaderIfMatch            = "If-Match"
 	HTTPHeaderIfNoneMatch        = "If-None-Match"
 	HTTPHeaderConnection         = "Connection"
+	HTTPHeaderVary               = "Vary"
+
+	HTTPHeaderACRequestMethod    = "Access-Control-Request-Method"
+	HTTPHeaderACRequestHeaders   = "Access-Control-Request-Headers"
+	HTTPHeaderACAllowOrigin      = "Access-Control-Allow-Origin"
+	HTTPHeaderACAllowMethods     = "Access-Control-Allow-Methods"
+	HTTPHeaderACAllowHeaders     = "Access-Control-Allow-Headers"
+	HTTPHeaderACAllowCredentials = "Access-Control-Allow-Credentials"
+	HTTPHeaderACExposeHeaders    = "Access-Control-Expose-Headers"
+	HTTPHeaderACMaxAge           = "Access-Control-Max-Age"
 
 	HTTPHeaderoosACL                         = "x-amz-acl"
 	HTTPHeaderoosMetaPrefix                  = "x-amz-meta-"
@@ -124,9 +146,14 @@ const (
 	HTTPHeaderoosStorageClass                = "x-amz-storage-class"
 	HTTPHeaderoosRequester                   = "x-amz-request-payer"
 	HTTPHeaderoosContentSHA256               = "x-amz-content-sha256"
+	HTTPHeaderoosDecodedContentLength        = "x-amz-decoded-content-length"
 	HTTPHeaderXamzDate                       = "x-amz-date"
 	HTTPHeaderXamzLimit                      = "x-amz-limit"
 	HTTPHeaderXctyunDataLocation             = "x-ctyun-data-location"
+	HTTPHeaderoosObjectLockMode              = "x-amz-object-lock-mode"
+	HTTPHeaderoosObjectLockRetainUntilDate   = "x-amz-object-lock-retain-until-date"
+	HTTPHeaderoosObjectLockLegalHold         = "x-amz-object-lock-legal-hold"
+	HTTPHeaderoosBypassGovernanceRetention   = "x-amz-bypass-governance-retention"
 )
 
 // HTTP Param
@@ -164,12 +191,41 @@ const (
 	VERSION                  = "Version"
 	VERSION_IAM              = "2010-05-08"
 	USER_NAME                = "UserName"
+
+	CREATE_USER                 = "CreateUser"
+	GET_USER                    = "GetUser"
+	LIST_USERS                  = "ListUsers"
+	DELETE_USER                 = "DeleteUser"
+	CREATE_GROUP                = "CreateGroup"
+	DELETE_GROUP                = "DeleteGroup"
+	LIST_GROUPS                 = "ListGroups"
+	ADD_USER_TO_GROUP           = "AddUserToGroup"
+	REMOVE_USER_FROM_GROUP      = "RemoveUserFromGroup"
+	LIST_GROUPS_FOR_USER        = "ListGroupsForUser"
+	CREATE_POLICY               = "CreatePolicy"
+	ATTACH_USER_POLICY          = "AttachUserPolicy"
+	DETACH_USER_POLICY          = "DetachUserPolicy"
+	LIST_ATTACHED_USER_POLICIES = "ListAttachedUserPolicies"
+	GROUP_NAME                  = "GroupName"
+	POLICY_NAME                 = "PolicyName"
+	POLICY_ARN                  = "PolicyArn"
+	POLICY_DOCUMENT             = "PolicyDocument"
+	POLICY_DESCRIPTION          = "Description"
+
+	GET_SESSION_TOKEN = "GetSessionToken"
+	ASSUME_ROLE       = "AssumeRole"
+	VERSION_STS       = "2011-06-15"
+	DURATION_SECONDS  = "DurationSeconds"
+	ROLE_ARN          = "RoleArn"
+	ROLE_SESSION_NAME = "RoleSessionName"
+	STS_POLICY        = "Policy"
 )
 
 // Other constants
 const (
-	MaxPartSize = 5 * 1024 * 1024 * 1024 // Max part size, 5GB
-	MinPartSize = 100 * 1024             // Min part size, 100KB
+	MaxPartSize   = 5 * 1024 * 1024 * 1024 // Max part size, 5GB
+	MinPartSize   = 100 * 1024             // Min part size, 100KB
+	MaxPartNumber = 10000                  // Max part number of the multipart upload
 
 	FilePermMode = os.FileMode(0664) // Default file permission
 
@@ -0,0 +1,228 @@
+package oos
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	corsMaxRules = 100 // The rules allowed in a bucket
+	corsVary     = "Origin, Access-Control-Request-Headers, Access-Control-Request-Method"
+)
+
+// corsMethods are the methods allowed in AllowedMethod
+var corsMethods = map[string]bool{"GET": true, "PUT": true, "POST": true, "DELETE": true, "HEAD": true}
+
+// CORSRequest is the browser request evaluated by EvaluateCORS
+type CORSRequest struct {
+	Origin    string   // The Origin header
+	Method    string   // The method of the actual request, the Access-Control-Request-Method header of the preflight
+	Headers   []string // The Access-Control-Request-Headers header of the preflight
+	Preflight bool     // The request is the OPTIONS preflight
+}
+
+// NewCORSRequest reads the CORS request from the HTTP request, the OPTIONS request with
+// Access-Control-Request-Method is the preflight.
+func NewCORSRequest(r *http.Request) CORSRequest {
+	req := CORSRequest{Origin: r.Header.Get(HTTPHeaderOrigin), Method: r.Method}
+	if method := r.Header.Get(HTTPHeaderACRequestMethod); r.Method == http.MethodOptions && method != "" {
+		req.Method, req.Preflight = method, true
+		for _, value := range r.Header[http.CanonicalHeaderKey(HTTPHeaderACRequestHeaders)] {
+			for _, header := range strings.Split(value, ",") {
+				if header = strings.TrimSpace(header); header != "" {
+					req.Headers = append(req.Headers, header)
+				}
+			}
+		}
+	}
+	return req
+}
+
+// CORSResult is the result of EvaluateCORS
+type CORSResult struct {
+	Allowed   bool        // The request is allowed by a rule
+	RuleIndex int         // The index of the first rule which allows the request, -1 if it's not allowed
+	Header    http.Header // The CORS headers of the response, it's empty if the request isn't allowed
+	Reason    string      // Why the request isn't allowed
+}
+
+// EvaluateCORS evaluates the CORS request locally as the service does: the first rule which matches the origin, the
+// method and all the request headers of the preflight allows the request. Each AllowedOrigin and AllowedHeader may
+// contain one wildcard '*' which matches any characters, the headers are matched case-insensitively.
+//
+// rules    the CORS rules, such as the ones to set, or the ones from GetBucketCors.
+// req    the browser request.
+//
+// CORSResult    the result with the response headers.
+func EvaluateCORS(rules []CORSRule, req CORSRequest) CORSResult {
+	result := CORSResult{RuleIndex: -1, Header: http.Header{}}
+	if req.Origin == "" {
+		result.Reason = "the request has no Origin header"
+		return result
+	}
+	if req.Method == "" {
+		result.Reason = "the request has no method"
+		return result
+	}
+
+	for i, rule := range rules {
+		origin, ok := corsMatchAny(rule.AllowedOrigin, req.Origin, false)
+		if !ok || !corsHasMethod(rule.AllowedMethod, req.Method) {
+			continue
+		}
+		allHeaders := true
+		for _, header := range req.Headers {
+			if _, ok := corsMatchAny(rule.AllowedHeader, header, true); !ok {
+				allHeaders = false
+				break
+			}
+		}
+		if req.Preflight && !allHeaders {
+			continue
+		}
+
+		result.Allowed, result.RuleIndex = true, i
+		h := result.Header
+		if origin == "*" {
+			h.Set(HTTPHeaderACAllowOrigin, "*")
+		} else {
+			h.Set(HTTPHeaderACAllowOrigin, req.Origin)
+			h.Set(HTTPHeaderACAllowCredentials, "true")
+		}
+		h.Set(HTTPHeaderACAllowMethods, strings.Join(rule.AllowedMethod, ", "))
+		if req.Preflight && len(req.Headers) > 0 {
+			h.Set(HTTPHeaderACAllowHeaders, strings.Join(req.Headers, ", "))
+		}
+		if len(rule.ExposeHeader) > 0 {
+			h.Set(HTTPHeaderACExposeHeaders, strings.Join(rule.ExposeHeader, ", "))
+		}
+		if rule.MaxAgeSeconds > 0 {
+			h.Set(HTTPHeaderACMaxAge, strconv.Itoa(rule.MaxAgeSeconds))
+		}
+		h.Set(HTTPHeaderVary, corsVary)
+		return result
+	}
+
+	result.Reason = fmt.Sprintf("no rule allows the origin %s with the method %s", req.Origin, req.Method)
+	if req.Preflight && len(req.Headers) > 0 {
+		result.Reason += " and the headers " + strings.Join(req.Headers, ", ")
+	}
+	return result
+}
+
+// CORSValidationError is returned by ValidateCORSRules, it lists all the problems found
+type CORSValidationError struct {
+	Problems []string
+}
+
+// Error implements interface error
+func (e CORSValidationError) Error() string {
+	return "oos: invalid CORS: " + strings.Join(e.Problems, "; ")
+}
+
+// ValidateCORSRules checks the rules locally before SetBucketCors: the number of the rules, the methods, and the
+// wildcards of the origins and the headers.
+//
+// rules    the CORS rules.
+//
+// error    it's nil if the rules are valid, otherwise it's a CORSValidationError.
+func ValidateCORSRules(rules []CORSRule) error {
+	var problems []string
+	if len(rules) == 0 {
+		problems = append(problems, "no rule")
+	}
+	if len(rules) > corsMaxRules {
+		problems = append(problems, fmt.Sprintf("%d rules, at most %d are allowed", len(rules), corsMaxRules))
+	}
+
+	for i, rule := range rules {
+		add := func(format string, args ...interface{}) {
+			problems = append(problems, fmt.Sprintf("rule %d: ", i)+fmt.Sprintf(format, args...))
+		}
+		if len(rule.AllowedOrigin) == 0 {
+			add("no AllowedOrigin")
+		}
+		for _, origin := range rule.AllowedOrigin {
+			if origin == "" {
+				add("empty AllowedOrigin")
+			} else if strings.Count(origin, "*") > 1 {
+				add("AllowedOrigin %q has more than one wildcard", origin)
+			}
+		}
+		if len(rule.AllowedMethod) == 0 {
+			add("no AllowedMethod")
+		}
+		for _, method := range rule.AllowedMethod {
+			if !corsMethods[method] {
+				add("AllowedMethod %q isn't one of GET, PUT, POST, DELETE and HEAD", method)
+			}
+		}
+		for _, header := range rule.AllowedHeader {
+			if header == "" {
+				add("empty AllowedHeader")
+			} else if strings.Count(header, "*") > 1 {
+				add("AllowedHeader %q has more than one wildcard", header)
+			}
+		}
+		for _, header := range rule.ExposeHeader {
+			if header == "" {
+				add("empty ExposeHeader")
+			} else if strings.Contains(header, "*") {
+				add("ExposeHeader %q can't have the wildcard", header)
+			}
+		}
+		if rule.MaxAgeSeconds < 0 {
+			add("MaxAgeSeconds is negative")
+		}
+	}
+
+	if len(problems) > 0 {
+		return CORSValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// corsMatchAny returns the first pattern which matches the value
+func corsMatchAny(patterns []string, value string, foldCase bool) (string, bool) {
+	for _, pattern := range patterns {
+		if corsMatch(pattern, value, foldCase) {
+			return pattern, true
+		}
+	}
+	return "", false
+}
+
+// corsMatch matches the value with the pattern in which '*' matches any characters
+func corsMatch(pattern, value string, foldCase bool) bool {
+	if foldCase {
+		pattern, value = strings.ToLower(pattern), strings.ToLower(value)
+	}
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == value
+	}
+	if !strings.HasPrefix(value, parts[0]) {
+		return false
+	}
+	value = value[len(parts[0]):]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(value, part)
+		if i < 0 {
+			return false
+		}
+		value = value[i+len(part):]
+	}
+	return strings.HasSuffix(value, parts[len(parts)-1])
+}
+
+// corsHasMethod checks if the method is allowed, the methods are case-sensitive
+func corsHasMethod(methods []string, method string) bool {
+	for _, m := range methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
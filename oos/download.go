@@ -7,7 +7,6 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -19,7 +18,7 @@ import (
 // objectKey    the object key.
 // filePath    the local file to download from objectKey in oos.
 // partSize    the part size in bytes.
-// options    object's constraints, check out GetObject for the reference.
+// options    object's constraints, check out GetObject for the reference. Checkpoint, CheckpointDir or CheckpointStore enables the resumable download.
 //
 // error    it's nil when the call succeeds, otherwise it's an error object.
 func (bucket Object) DownloadFile(objectKey, filePath string, partSize int64, options ...Option) error {
@@ -43,6 +42,15 @@ func (bucket Object) DownloadFile(objectKey, filePath string, partSize int64, op
 
 	routines := getRoutines(options)
 
+	absPath, _ := filepath.Abs(filePath)
+	src := fmt.Sprintf("oos://%v/%v", bucket.BucketName, objectKey)
+	cpKey := getCheckpointKey(options, func(cpConf *cpConfig) string {
+		return getDownloadCpFilePath(cpConf, bucket.BucketName, objectKey, filePath)
+	}, src, absPath)
+	if cpKey != "" {
+		return bucket.downloadFileWithCp(objectKey, filePath, partSize, options, cpKey, routines, uRange)
+	}
+
 	return bucket.downloadFile(objectKey, filePath, partSize, options, routines, uRange)
 }
 
@@ -327,39 +335,15 @@ func (cp downloadCheckpoint) isValid(meta http.Header, uRange *unpackedRange) (b
 	return true, nil
 }
 
-// load checkpoint from local file
-func (cp *downloadCheckpoint) load(filePath string) error {
-	contents, err := ioutil.ReadFile(filePath)
-	if err != nil {
-		return err
-	}
-
-	err = json.Unmarshal(contents, cp)
-	return err
+// load loads the checkpoint from the checkpoint store
+func (cp *downloadCheckpoint) load(store CheckpointStorage, key string) error {
+	return loadCheckpoint(store, key, cp)
 }
 
-// dump funciton dumps to file
-func (cp *downloadCheckpoint) dump(filePath string) error {
+// dump dumps the checkpoint to the checkpoint store
+func (cp *downloadCheckpoint) dump(store CheckpointStorage, key string) error {
 	bcp := *cp
-
-	// Calculate MD5
-	bcp.MD5 = ""
-	js, err := json.Marshal(bcp)
-	if err != nil {
-		return err
-	}
-	sum := md5.Sum(js)
-	b64 := base64.StdEncoding.EncodeToString(sum[:])
-	bcp.MD5 = b64
-
-	// Serialize
-	js, err = json.Marshal(bcp)
-	if err != nil {
-		return err
-	}
-
-	// Dump
-	return ioutil.WriteFile(filePath, js, FilePermMode)
+	return dumpCheckpoint(store, key, &bcp, func(md5 string) { bcp.MD5 = md5 })
 }
 
 // todoParts gets unfinished parts
@@ -410,15 +394,16 @@ func (cp *downloadCheckpoint) prepare(meta http.Header, bucket *Object, objectKe
 	return nil
 }
 
-func (cp *downloadCheckpoint) complete(cpFilePath, downFilepath string) error {
-	os.Remove(cpFilePath)
+func (cp *downloadCheckpoint) complete(store CheckpointStorage, cpKey, downFilepath string) error {
+	store.Delete(cpKey)
 	return os.Rename(downFilepath, cp.FilePath)
 }
 
 // downloadFileWithCp downloads files with checkpoint.
-func (bucket Object) downloadFileWithCp(objectKey, filePath string, partSize int64, options []Option, cpFilePath string, routines int, uRange *unpackedRange) error {
+func (bucket Object) downloadFileWithCp(objectKey, filePath string, partSize int64, options []Option, cpKey string, routines int, uRange *unpackedRange) error {
 	tempFilePath := filePath + TempFileSuffix
 	listener := getProgressListener(options)
+	store := getCheckpointStore(options)
 
 	payerOptions := []Option{}
 	payer := getPayer(options)
@@ -428,9 +413,9 @@ func (bucket Object) downloadFileWithCp(objectKey, filePath string, partSize int
 
 	// Load checkpoint data.
 	dcp := downloadCheckpoint{}
-	err := dcp.load(cpFilePath)
+	err := dcp.load(store, cpKey)
 	if err != nil {
-		os.Remove(cpFilePath)
+		store.Delete(cpKey)
 	}
 
 	// Get the object detailed meta.
@@ -445,7 +430,7 @@ func (bucket Object) downloadFileWithCp(objectKey, filePath string, partSize int
 		if err = dcp.prepare(meta, &bucket, objectKey, filePath, partSize, uRange); err != nil {
 			return err
 		}
-		os.Remove(cpFilePath)
+		store.Delete(cpKey)
 	}
 
 	// Create the file if not exists. Otherwise the parts download will overwrite it.
@@ -482,7 +467,7 @@ func (bucket Object) downloadFileWithCp(objectKey, filePath string, partSize int
 		case part := <-results:
 			completed++
 			dcp.PartStat[part.Index] = true
-			dcp.dump(cpFilePath)
+			dcp.dump(store, cpKey)
 			completedBytes += (part.End - part.Start + 1)
 			event = newProgressEvent(TransferDataEvent, completedBytes, dcp.ObjStat.Size)
 			publishProgress(listener, event)
@@ -501,5 +486,5 @@ func (bucket Object) downloadFileWithCp(objectKey, filePath string, partSize int
 	event = newProgressEvent(TransferCompletedEvent, completedBytes, dcp.ObjStat.Size)
 	publishProgress(listener, event)
 
-	return dcp.complete(cpFilePath, tempFilePath)
+	return dcp.complete(store, cpKey, tempFilePath)
 }
@@ -1,85 +1,67 @@
 package oos
 
 import (
-	"bytes"
 	"errors"
-	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
 )
 
 // CreateAccessKey	 Create a pair of regular AccessKey and SecretKey.
 //
-// bucketName    the bucket name.
+// userName    the IAM user name, the caller if it's empty.
 //
 // error    it's nil if no error, otherwise it's an error object.
 func (client Client) CreateAccessKey(userName string) (CreateAccessKeyResponse, error) {
 	var out CreateAccessKeyResponse
 
-	body := fmt.Sprintf("%s=%s&%s=%s", ACCESS_KEY_ACTION, CREATE_ACCESS_KEY, VERSION, VERSION_IAM)
-	if userName != "" {
-		body = body + "&" + USER_NAME + "=" + userName
-	}
-	buffer := new(bytes.Buffer)
-	buffer.Write([]byte(body))
-
-	resp, err := client.do("POST", "", nil, nil, buffer)
-	if err != nil {
-		return out, err
-	}
-	defer resp.Body.Close()
-	err = xmlUnmarshal(resp.Body, &out)
+	form := iamForm(CREATE_ACCESS_KEY)
+	setIAMParam(form, USER_NAME, userName)
+	err := client.doIAM(form, &out)
 	return out, err
 }
 
 // DeleteAccessKey	 Delete a pair of regular AccessKey and SecretKey.
 //
-// bucketName    the bucket name.
+// accessKeyId    the access key id.
+// userName    the IAM user name, the caller if it's empty.
 //
 // error    it's nil if no error, otherwise it's an error object.
 func (client Client) DeleteAccessKey(accessKeyId, userName string) (DeleteAccessKeyResponse, error) {
 	var out DeleteAccessKeyResponse
 
 	if accessKeyId == "" {
-		return out, errors.New("the parameter is invalid: bucket's name is empty")
+		return out, errors.New("the parameter is invalid: access key id is empty")
 	}
 
-	body := fmt.Sprintf("%s=%s&%s=%s&%s=%s", ACCESS_KEY_ACTION, DELETE_ACCESS_KEY, ACCESS_KEY_ID,
-		accessKeyId, VERSION, VERSION_IAM)
-	if userName != "" {
-		body = body + "&" + USER_NAME + "=" + userName
-	}
-	buffer := new(bytes.Buffer)
-	buffer.Write([]byte(body))
-
-	resp, err := client.do("POST", "", nil, nil, buffer)
-	if err != nil {
-		return out, err
-	}
-	defer resp.Body.Close()
-	err = xmlUnmarshal(resp.Body, &out)
+	form := iamForm(DELETE_ACCESS_KEY)
+	form.Set(ACCESS_KEY_ID, accessKeyId)
+	setIAMParam(form, USER_NAME, userName)
+	err := client.doIAM(form, &out)
 	return out, err
 }
 
+// GetAccessKeyLastUsed	 Get the last time the AccessKey was used.
+//
+// accessKeyId    the access key id.
+//
+// error    it's nil if no error, otherwise it's an error object.
 func (client Client) GetAccessKeyLastUsed(accessKeyId string) (GetAccessKeyLastUsedResponse, error) {
 	var out GetAccessKeyLastUsedResponse
 
-	body := fmt.Sprintf("%s=%s&%s=%s", ACCESS_KEY_ACTION, GET_ACCESS_KEY_LAST_USED,
-		ACCESS_KEY_ID, accessKeyId)
-
-	buffer := new(bytes.Buffer)
-	buffer.Write([]byte(body))
-
-	resp, err := client.do("POST", "", nil, nil, buffer)
-	if err != nil {
-		return out, err
-	}
-	defer resp.Body.Close()
-	err = xmlUnmarshal(resp.Body, &out)
+	// The action is sent without Version
+	form := url.Values{}
+	form.Set(ACCESS_KEY_ACTION, GET_ACCESS_KEY_LAST_USED)
+	form.Set(ACCESS_KEY_ID, accessKeyId)
+	err := client.doIAM(form, &out)
 	return out, err
 }
 
 // ListAccessKey	 List all  pair of regular AccessKey and SecretKey.
 //
-// bucketName    the bucket name.
+// maxCount    the max count of the keys, 100 if it's negative.
+// Marker    the marker returned by the previous page.
+// userName    the IAM user name, the caller if it's empty.
 //
 // error    it's nil if no error, otherwise it's an error object.
 func (client Client) ListAccessKey(maxCount int, Marker, userName string) (ListAccessKeysResponse, error) {
@@ -88,42 +70,24 @@ func (client Client) ListAccessKey(maxCount int, Marker, userName string) (ListA
 	if maxCount < 0 {
 		maxCount = 100
 	}
-	var body string
-	if Marker != "" {
-		body = fmt.Sprintf("%s=%s&%s=%d&%s=%s", ACCESS_KEY_ACTION, LIST_ACCESS_KEY,
-			ACCESS_KEY_MAXITEM, maxCount, ACCESS_KEY_MARKER, Marker)
-	} else {
-		body = fmt.Sprintf("%s=%s&%s=%d", ACCESS_KEY_ACTION, LIST_ACCESS_KEY,
-			ACCESS_KEY_MAXITEM, maxCount)
-	}
-
-	if userName != "" {
-		body = body + "&" + USER_NAME + "=" + userName
-	}
-
-	body = body + "&" + VERSION + "=" + VERSION_IAM
-
-	buffer := new(bytes.Buffer)
-	buffer.Write([]byte(body))
-
-	resp, err := client.do("POST", "", nil, nil, buffer)
-	if err != nil {
-		return out, err
-	}
-	defer resp.Body.Close()
-	err = xmlUnmarshal(resp.Body, &out)
+	form := iamForm(LIST_ACCESS_KEY)
+	form.Set(ACCESS_KEY_MAXITEM, strconv.Itoa(maxCount))
+	setIAMParam(form, ACCESS_KEY_MARKER, Marker)
+	setIAMParam(form, USER_NAME, userName)
+	err := client.doIAM(form, &out)
 	return out, err
 }
 
 // UpdateAccessKey	 Update  regular AccessKey 's Status.
 //
-// bucketName    the bucket name.
+// accessKeyId    the access key id.
+// bActive    true to activate the key, false to deactivate it.
 //
 // error    it's nil if no error, otherwise it's an error object.
 func (client Client) UpdateAccessKey(accessKeyId string, bActive bool) error {
 
 	if accessKeyId == "" {
-		return errors.New("the parameter is invalid: bucket's name is empty")
+		return errors.New("the parameter is invalid: access key id is empty")
 	}
 
 	var sStatus string
@@ -132,15 +96,307 @@ func (client Client) UpdateAccessKey(accessKeyId string, bActive bool) error {
 	} else {
 		sStatus = ACCESS_KEY_INACTIVE
 	}
-	body := fmt.Sprintf("%s=%s&%s=%s&%s=%s&%s=%s", ACCESS_KEY_ACTION, UPDATE_ACCESS_KEY,
-		ACCESS_KEY_ID, accessKeyId, ACCESS_KEY_STATUS, sStatus, VERSION, VERSION_IAM)
-	buffer := new(bytes.Buffer)
-	buffer.Write([]byte(body))
+	form := iamForm(UPDATE_ACCESS_KEY)
+	form.Set(ACCESS_KEY_ID, accessKeyId)
+	form.Set(ACCESS_KEY_STATUS, sStatus)
+	return client.doIAM(form, nil)
+}
 
-	resp, err := client.do("POST", "", nil, nil, buffer)
+// UpdateAccessKeyPrimary sets whether the access key is primary.
+//
+// accessKeyId    the access key id.
+// isPrimary    true to make the key primary, false to make it a regular key.
+//
+// error    it's nil if no error, otherwise it's an error object.
+func (client Client) UpdateAccessKeyPrimary(accessKeyId string, isPrimary bool) error {
+	if accessKeyId == "" {
+		return errors.New("the parameter is invalid: access key id is empty")
+	}
+
+	form := iamForm(UPDATE_ACCESS_KEY)
+	form.Set(ACCESS_KEY_ID, accessKeyId)
+	form.Set(ACCESS_KEY_ISPRIMARY, strconv.FormatBool(isPrimary))
+	return client.doIAM(form, nil)
+}
+
+// CreateUser creates the IAM user.
+//
+// userName    the user name.
+//
+// IAMUser    the created user, only valid when error is nil.
+// error    it's nil if no error, otherwise it's an error object.
+func (client Client) CreateUser(userName string) (IAMUser, error) {
+	var out CreateUserResponse
+	if userName == "" {
+		return out.User, errors.New("the parameter is invalid: user name is empty")
+	}
+
+	form := iamForm(CREATE_USER)
+	form.Set(USER_NAME, userName)
+	err := client.doIAM(form, &out)
+	return out.User, err
+}
+
+// GetUser gets the IAM user.
+//
+// userName    the user name, the caller if it's empty.
+//
+// IAMUser    the user, only valid when error is nil.
+// error    it's nil if no error, otherwise it's an error object.
+func (client Client) GetUser(userName string) (IAMUser, error) {
+	var out GetUserResponse
+
+	form := iamForm(GET_USER)
+	setIAMParam(form, USER_NAME, userName)
+	err := client.doIAM(form, &out)
+	return out.User, err
+}
+
+// ListUsers lists the IAM users. Call it with the Marker of the result until IsTruncated is false to list all users.
+//
+// maxItems    the max count of the users, 100 if it's not positive.
+// marker    the marker returned by the previous page, empty for the first page.
+//
+// ListUsersResult    the users, only valid when error is nil.
+// error    it's nil if no error, otherwise it's an error object.
+func (client Client) ListUsers(maxItems int, marker string) (ListUsersResult, error) {
+	var out ListUsersResponse
+
+	form := iamForm(LIST_USERS)
+	setIAMMaxItems(form, maxItems)
+	setIAMParam(form, ACCESS_KEY_MARKER, marker)
+	err := client.doIAM(form, &out)
+	return out.ListUsersResult, err
+}
+
+// DeleteUser deletes the IAM user. The access keys, group memberships and attached policies of the user
+// must be removed first.
+//
+// userName    the user name.
+//
+// error    it's nil if no error, otherwise it's an error object.
+func (client Client) DeleteUser(userName string) error {
+	if userName == "" {
+		return errors.New("the parameter is invalid: user name is empty")
+	}
+
+	form := iamForm(DELETE_USER)
+	form.Set(USER_NAME, userName)
+	return client.doIAM(form, nil)
+}
+
+// CreateGroup creates the IAM group.
+//
+// groupName    the group name.
+//
+// IAMGroup    the created group, only valid when error is nil.
+// error    it's nil if no error, otherwise it's an error object.
+func (client Client) CreateGroup(groupName string) (IAMGroup, error) {
+	var out CreateGroupResponse
+	if groupName == "" {
+		return out.Group, errors.New("the parameter is invalid: group name is empty")
+	}
+
+	form := iamForm(CREATE_GROUP)
+	form.Set(GROUP_NAME, groupName)
+	err := client.doIAM(form, &out)
+	return out.Group, err
+}
+
+// DeleteGroup deletes the IAM group. The group must have no users.
+//
+// groupName    the group name.
+//
+// error    it's nil if no error, otherwise it's an error object.
+func (client Client) DeleteGroup(groupName string) error {
+	if groupName == "" {
+		return errors.New("the parameter is invalid: group name is empty")
+	}
+
+	form := iamForm(DELETE_GROUP)
+	form.Set(GROUP_NAME, groupName)
+	return client.doIAM(form, nil)
+}
+
+// ListGroups lists the IAM groups.
+//
+// maxItems    the max count of the groups, 100 if it's not positive.
+// marker    the marker returned by the previous page, empty for the first page.
+//
+// ListGroupsResult    the groups, only valid when error is nil.
+// error    it's nil if no error, otherwise it's an error object.
+func (client Client) ListGroups(maxItems int, marker string) (ListGroupsResult, error) {
+	var out ListGroupsResponse
+
+	form := iamForm(LIST_GROUPS)
+	setIAMMaxItems(form, maxItems)
+	setIAMParam(form, ACCESS_KEY_MARKER, marker)
+	err := client.doIAM(form, &out)
+	return out.ListGroupsResult, err
+}
+
+// AddUserToGroup adds the user to the group.
+//
+// userName    the user name.
+// groupName    the group name.
+//
+// error    it's nil if no error, otherwise it's an error object.
+func (client Client) AddUserToGroup(userName, groupName string) error {
+	return client.updateGroupMember(ADD_USER_TO_GROUP, userName, groupName)
+}
+
+// RemoveUserFromGroup removes the user from the group.
+//
+// userName    the user name.
+// groupName    the group name.
+//
+// error    it's nil if no error, otherwise it's an error object.
+func (client Client) RemoveUserFromGroup(userName, groupName string) error {
+	return client.updateGroupMember(REMOVE_USER_FROM_GROUP, userName, groupName)
+}
+
+func (client Client) updateGroupMember(action, userName, groupName string) error {
+	if userName == "" || groupName == "" {
+		return errors.New("the parameter is invalid: user name or group name is empty")
+	}
+
+	form := iamForm(action)
+	form.Set(USER_NAME, userName)
+	form.Set(GROUP_NAME, groupName)
+	return client.doIAM(form, nil)
+}
+
+// ListGroupsForUser lists the groups the user belongs to.
+//
+// userName    the user name.
+// maxItems    the max count of the groups, 100 if it's not positive.
+// marker    the marker returned by the previous page, empty for the first page.
+//
+// ListGroupsResult    the groups, only valid when error is nil.
+// error    it's nil if no error, otherwise it's an error object.
+func (client Client) ListGroupsForUser(userName string, maxItems int, marker string) (ListGroupsResult, error) {
+	var out ListGroupsForUserResponse
+	if userName == "" {
+		return out.ListGroupsResult, errors.New("the parameter is invalid: user name is empty")
+	}
+
+	form := iamForm(LIST_GROUPS_FOR_USER)
+	form.Set(USER_NAME, userName)
+	setIAMMaxItems(form, maxItems)
+	setIAMParam(form, ACCESS_KEY_MARKER, marker)
+	err := client.doIAM(form, &out)
+	return out.ListGroupsResult, err
+}
+
+// CreatePolicy creates the managed IAM policy.
+//
+// policyName    the policy name.
+// policyDocument    the policy document in JSON.
+// description    the description of the policy, it's optional.
+//
+// IAMPolicy    the created policy, its Arn is used to attach it. Only valid when error is nil.
+// error    it's nil if no error, otherwise it's an error object.
+func (client Client) CreatePolicy(policyName, policyDocument, description string) (IAMPolicy, error) {
+	var out CreatePolicyResponse
+	if policyName == "" || policyDocument == "" {
+		return out.Policy, errors.New("the parameter is invalid: policy name or document is empty")
+	}
+
+	form := iamForm(CREATE_POLICY)
+	form.Set(POLICY_NAME, policyName)
+	form.Set(POLICY_DOCUMENT, policyDocument)
+	setIAMParam(form, POLICY_DESCRIPTION, description)
+	err := client.doIAM(form, &out)
+	return out.Policy, err
+}
+
+// AttachUserPolicy attaches the managed policy to the user.
+//
+// userName    the user name.
+// policyArn    the Arn of the policy.
+//
+// error    it's nil if no error, otherwise it's an error object.
+func (client Client) AttachUserPolicy(userName, policyArn string) error {
+	return client.updateUserPolicy(ATTACH_USER_POLICY, userName, policyArn)
+}
+
+// DetachUserPolicy detaches the managed policy from the user.
+//
+// userName    the user name.
+// policyArn    the Arn of the policy.
+//
+// error    it's nil if no error, otherwise it's an error object.
+func (client Client) DetachUserPolicy(userName, policyArn string) error {
+	return client.updateUserPolicy(DETACH_USER_POLICY, userName, policyArn)
+}
+
+func (client Client) updateUserPolicy(action, userName, policyArn string) error {
+	if userName == "" || policyArn == "" {
+		return errors.New("the parameter is invalid: user name or policy arn is empty")
+	}
+
+	form := iamForm(action)
+	form.Set(USER_NAME, userName)
+	form.Set(POLICY_ARN, policyArn)
+	return client.doIAM(form, nil)
+}
+
+// ListAttachedUserPolicies lists the managed policies attached to the user.
+//
+// userName    the user name.
+// maxItems    the max count of the policies, 100 if it's not positive.
+// marker    the marker returned by the previous page, empty for the first page.
+//
+// ListAttachedUserPoliciesResult    the policies, only valid when error is nil.
+// error    it's nil if no error, otherwise it's an error object.
+func (client Client) ListAttachedUserPolicies(userName string, maxItems int,
+	marker string) (ListAttachedUserPoliciesResult, error) {
+	var out ListAttachedUserPoliciesResponse
+	if userName == "" {
+		return out.ListAttachedUserPoliciesResult, errors.New("the parameter is invalid: user name is empty")
+	}
+
+	form := iamForm(LIST_ATTACHED_USER_POLICIES)
+	form.Set(USER_NAME, userName)
+	setIAMMaxItems(form, maxItems)
+	setIAMParam(form, ACCESS_KEY_MARKER, marker)
+	err := client.doIAM(form, &out)
+	return out.ListAttachedUserPoliciesResult, err
+}
+
+// iamForm returns the form of the IAM action with the API version
+func iamForm(action string) url.Values {
+	form := url.Values{}
+	form.Set(ACCESS_KEY_ACTION, action)
+	form.Set(VERSION, VERSION_IAM)
+	return form
+}
+
+// setIAMParam sets the optional parameter if it's not empty
+func setIAMParam(form url.Values, key, value string) {
+	if value != "" {
+		form.Set(key, value)
+	}
+}
+
+// setIAMMaxItems sets MaxItems, 100 if it's not positive
+func setIAMMaxItems(form url.Values, maxItems int) {
+	if maxItems <= 0 {
+		maxItems = 100
+	}
+	form.Set(ACCESS_KEY_MAXITEM, strconv.Itoa(maxItems))
+}
+
+// doIAM posts the form of the IAM or STS action, every value is URL-encoded. The response is unmarshalled
+// into out unless it's nil.
+func (client Client) doIAM(form url.Values, out interface{}) error {
+	resp, err := client.do("POST", "", nil, nil, strings.NewReader(form.Encode()))
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
-	return err
+	if out == nil {
+		return nil
+	}
+	return xmlUnmarshal(resp.Body, out)
 }
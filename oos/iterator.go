@@ -0,0 +1,64 @@
+package oos
+
+// ObjectIterator iterates the objects of the bucket page by page with ListObjects, such as
+//
+//	it := bucket.NewObjectIterator(oos.Prefix("logs/"))
+//	for it.Next() {
+//		object := it.Object()
+//	}
+//	if err := it.Err(); err != nil {
+//	}
+type ObjectIterator struct {
+	bucket  Object
+	options []Option
+	page    []ObjectProperties
+	index   int
+	marker  string
+	done    bool
+	err     error
+}
+
+// NewObjectIterator creates the iterator of the objects.
+//
+// options    the options of ListObjects such as Prefix and MaxKeys, Marker sets the start point.
+func (bucket Object) NewObjectIterator(options ...Option) *ObjectIterator {
+	it := &ObjectIterator{bucket: bucket, options: append([]Option(nil), options...)}
+	if isSet, marker, _ := isOptionSet(options, "marker"); isSet {
+		it.marker, _ = marker.(string)
+	}
+	return it
+}
+
+// Next moves to the next object, it lists the next page when the current page is done.
+// It returns false when there is no more object or the listing fails, check Err then.
+func (it *ObjectIterator) Next() bool {
+	it.index++
+	for it.index >= len(it.page) {
+		if it.done || it.err != nil {
+			return false
+		}
+		// the Marker option appended last overrides the one in the options
+		lor, err := it.bucket.ListObjects(append(it.options, Marker(it.marker))...)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		it.page, it.index = lor.Objects, 0
+		it.marker = lor.NextMarker
+		if it.marker == "" && len(lor.Objects) > 0 {
+			it.marker = lor.Objects[len(lor.Objects)-1].Key
+		}
+		it.done = !lor.IsTruncated || it.marker == ""
+	}
+	return true
+}
+
+// Object returns the current object, it's valid after Next returns true
+func (it *ObjectIterator) Object() ObjectProperties {
+	return it.page[it.index]
+}
+
+// Err returns the listing error which stopped the iteration, or nil
+func (it *ObjectIterator) Err() error {
+	return it.err
+}
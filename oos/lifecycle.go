@@ -0,0 +1,484 @@
+package oos
+
+import (
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MarshalXML omits the rule's Prefix when Filter is set, the service rejects the rules with both. The deprecated
+// Transition is sent with Transitions.
+func (rule LifecycleRule) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	type plainRule LifecycleRule
+	rule.Transitions = rule.transitions()
+	if rule.Filter == nil {
+		return e.EncodeElement(plainRule(rule), start)
+	}
+	return e.EncodeElement(struct {
+		plainRule
+		Prefix string `xml:"Prefix,omitempty"`
+	}{plainRule: plainRule(rule)}, start)
+}
+
+// transitions returns Transitions, with the deprecated Transition first if Transitions doesn't have it
+func (rule LifecycleRule) transitions() []LifecycleTransition {
+	if rule.Transition == nil {
+		return rule.Transitions
+	}
+	for _, t := range rule.Transitions {
+		if t.Days == rule.Transition.Days && t.Date == rule.Transition.Date && t.StorageClass == rule.Transition.StorageClass {
+			return rule.Transitions
+		}
+	}
+	return append([]LifecycleTransition{*rule.Transition}, rule.Transitions...)
+}
+
+// KeyPrefix returns the object key prefix the rule applies to, from Filter if it's set
+func (rule LifecycleRule) KeyPrefix() string {
+	if rule.Filter == nil {
+		return rule.Prefix
+	}
+	if rule.Filter.And != nil {
+		return rule.Filter.And.Prefix
+	}
+	return rule.Filter.Prefix
+}
+
+// FilterTags returns the object tags the rule applies to, all of them must match
+func (rule LifecycleRule) FilterTags() []Tag {
+	switch {
+	case rule.Filter == nil:
+		return nil
+	case rule.Filter.And != nil:
+		return rule.Filter.And.Tags
+	case rule.Filter.Tag != nil:
+		return []Tag{*rule.Filter.Tag}
+	}
+	return nil
+}
+
+// IsEnabled checks if the rule's status is Enabled
+func (rule LifecycleRule) IsEnabled() bool {
+	return rule.Status == "Enabled"
+}
+
+// LifecycleRuleBuilder builds the lifecycle rule with several actions, such as
+//
+//	rule, err := oos.NewLifecycleRuleBuilder("logs").Prefix("logs/").
+//		TransitionAfterDays(30, oos.StorageClassStandardIA).ExpireAfterDays(365).
+//		AbortIncompleteUploadAfterDays(7).Build()
+type LifecycleRuleBuilder struct {
+	rule LifecycleRule
+	tags []Tag
+}
+
+// NewLifecycleRuleBuilder creates the builder of the enabled rule which applies to all objects.
+//
+// id    the rule ID, it's unique in the bucket.
+func NewLifecycleRuleBuilder(id string) *LifecycleRuleBuilder {
+	return &LifecycleRuleBuilder{rule: LifecycleRule{ID: id, Status: "Enabled"}}
+}
+
+// Prefix sets the object key prefix the rule applies to
+func (b *LifecycleRuleBuilder) Prefix(prefix string) *LifecycleRuleBuilder {
+	b.rule.Prefix = prefix
+	return b
+}
+
+// Tag adds the object tag the rule applies to, the objects must have all the tags
+func (b *LifecycleRuleBuilder) Tag(key, value string) *LifecycleRuleBuilder {
+	b.tags = append(b.tags, Tag{Key: key, Value: value})
+	return b
+}
+
+// Enabled sets the rule's status
+func (b *LifecycleRuleBuilder) Enabled(enabled bool) *LifecycleRuleBuilder {
+	b.rule.Status = "Enabled"
+	if !enabled {
+		b.rule.Status = "Disabled"
+	}
+	return b
+}
+
+// ExpireAfterDays expires the objects in the days after they are last modified
+func (b *LifecycleRuleBuilder) ExpireAfterDays(days int) *LifecycleRuleBuilder {
+	b.expiration().Days = days
+	return b
+}
+
+// ExpireOnDate expires the objects on the date, it must be midnight UTC
+func (b *LifecycleRuleBuilder) ExpireOnDate(date time.Time) *LifecycleRuleBuilder {
+	b.expiration().Date = date.UTC().Format(lifecycleDateFormat)
+	return b
+}
+
+// ExpireDeleteMarkers removes the delete markers which have no noncurrent versions
+func (b *LifecycleRuleBuilder) ExpireDeleteMarkers() *LifecycleRuleBuilder {
+	b.expiration().ExpiredObjectDeleteMarker = true
+	return b
+}
+
+func (b *LifecycleRuleBuilder) expiration() *LifecycleExpiration {
+	if b.rule.Expiration == nil {
+		b.rule.Expiration = &LifecycleExpiration{}
+	}
+	return b.rule.Expiration
+}
+
+// TransitionAfterDays transitions the objects to the storage class in the days after they are last modified
+func (b *LifecycleRuleBuilder) TransitionAfterDays(days int, storageClass StorageClassType) *LifecycleRuleBuilder {
+	b.rule.Transitions = append(b.rule.Transitions, LifecycleTransition{Days: days, StorageClass: string(storageClass)})
+	return b
+}
+
+// TransitionOnDate transitions the objects to the storage class on the date, it must be midnight UTC
+func (b *LifecycleRuleBuilder) TransitionOnDate(date time.Time, storageClass StorageClassType) *LifecycleRuleBuilder {
+	b.rule.Transitions = append(b.rule.Transitions,
+		LifecycleTransition{Date: date.UTC().Format(lifecycleDateFormat), StorageClass: string(storageClass)})
+	return b
+}
+
+// NoncurrentExpireAfterDays expires the noncurrent versions in the days after they become noncurrent
+func (b *LifecycleRuleBuilder) NoncurrentExpireAfterDays(days int) *LifecycleRuleBuilder {
+	b.rule.NoncurrentVersionExpiration = &LifecycleNoncurrentVersionExpiration{NoncurrentDays: days}
+	return b
+}
+
+// NoncurrentTransitionAfterDays transitions the noncurrent versions to the storage class in the days after they
+// become noncurrent
+func (b *LifecycleRuleBuilder) NoncurrentTransitionAfterDays(days int, storageClass StorageClassType) *LifecycleRuleBuilder {
+	b.rule.NoncurrentVersionTransitions = append(b.rule.NoncurrentVersionTransitions,
+		LifecycleNoncurrentVersionTransition{NoncurrentDays: days, StorageClass: string(storageClass)})
+	return b
+}
+
+// AbortIncompleteUploadAfterDays aborts the multipart uploads not completed in the days after they are initiated
+func (b *LifecycleRuleBuilder) AbortIncompleteUploadAfterDays(days int) *LifecycleRuleBuilder {
+	b.rule.AbortIncompleteMultipartUpload = &LifecycleAbortIncompleteMultipartUpload{DaysAfterInitiation: days}
+	return b
+}
+
+// Build validates the rule and returns it. The rule uses Filter if it has tags, otherwise Prefix.
+//
+// LifecycleRule    the rule, only valid when error is nil.
+// error    it's nil if the rule is valid, otherwise it's a LifecycleValidationError.
+func (b *LifecycleRuleBuilder) Build() (LifecycleRule, error) {
+	rule := b.rule
+	rule.Transitions = append([]LifecycleTransition(nil), b.rule.Transitions...)
+	rule.NoncurrentVersionTransitions = append([]LifecycleNoncurrentVersionTransition(nil), b.rule.NoncurrentVersionTransitions...)
+	if b.rule.Expiration != nil {
+		expiration := *b.rule.Expiration
+		rule.Expiration = &expiration
+	}
+
+	switch {
+	case len(b.tags) == 1 && rule.Prefix == "":
+		rule.Filter = &LifecycleFilter{Tag: &Tag{Key: b.tags[0].Key, Value: b.tags[0].Value}}
+	case len(b.tags) > 0:
+		rule.Filter = &LifecycleFilter{And: &LifecycleFilterAnd{Prefix: rule.Prefix, Tags: append([]Tag(nil), b.tags...)}}
+		rule.Prefix = ""
+	}
+
+	if problems := validateLifecycleRule(rule, lifecycleRuleName(rule, -1)); len(problems) > 0 {
+		return rule, LifecycleValidationError{Problems: problems}
+	}
+	return rule, nil
+}
+
+// LifecycleValidationError is returned by ValidateLifecycleRules and LifecycleRuleBuilder.Build, it lists all the
+// problems found
+type LifecycleValidationError struct {
+	Problems []string
+}
+
+// Error implements interface error
+func (e LifecycleValidationError) Error() string {
+	return "oos: invalid lifecycle: " + strings.Join(e.Problems, "; ")
+}
+
+// ValidateLifecycleRules checks the rules locally before SetBucketLifecycle: the status, the day ordering of the
+// actions, the date format, the unique IDs, and the enabled rules which overlap but set the same action differently.
+//
+// rules    the lifecycle rules.
+//
+// error    it's nil if the rules are valid, otherwise it's a LifecycleValidationError.
+func ValidateLifecycleRules(rules []LifecycleRule) error {
+	var problems []string
+	if len(rules) == 0 {
+		problems = append(problems, "no rule")
+	}
+
+	ids := map[string]bool{}
+	for i, rule := range rules {
+		problems = append(problems, validateLifecycleRule(rule, lifecycleRuleName(rule, i))...)
+		if rule.ID == "" {
+			continue
+		}
+		if ids[rule.ID] {
+			problems = append(problems, fmt.Sprintf("rule %d: duplicate ID %q", i, rule.ID))
+		}
+		ids[rule.ID] = true
+	}
+
+	for i := range rules {
+		for j := i + 1; j < len(rules); j++ {
+			if !rules[i].IsEnabled() || !rules[j].IsEnabled() || !lifecycleRulesOverlap(rules[i], rules[j]) {
+				continue
+			}
+			actions, others := lifecycleRuleActions(rules[i]), lifecycleRuleActions(rules[j])
+			names := make([]string, 0, len(actions))
+			for action := range actions {
+				names = append(names, action)
+			}
+			sort.Strings(names)
+			for _, action := range names {
+				value := actions[action]
+				if other, ok := others[action]; ok && other != value {
+					problems = append(problems, fmt.Sprintf("%s and %s overlap with conflicting %s: %s and %s",
+						lifecycleRuleName(rules[i], i), lifecycleRuleName(rules[j], j), action, value, other))
+				}
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return LifecycleValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// lifecycleRuleName names the rule in the problems by its ID, or by its index if it's not negative
+func lifecycleRuleName(rule LifecycleRule, index int) string {
+	if rule.ID != "" {
+		return fmt.Sprintf("rule %q", rule.ID)
+	}
+	if index < 0 {
+		return "rule"
+	}
+	return fmt.Sprintf("rule %d", index)
+}
+
+// validateLifecycleRule checks the rule alone
+func validateLifecycleRule(rule LifecycleRule, name string) []string {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, name+": "+fmt.Sprintf(format, args...))
+	}
+
+	if len(rule.ID) > 255 {
+		add("ID is longer than 255 characters")
+	}
+	if rule.Status != "Enabled" && rule.Status != "Disabled" {
+		add("status must be Enabled or Disabled, got %q", rule.Status)
+	}
+	if f := rule.Filter; f != nil {
+		set := 0
+		if f.Prefix != "" {
+			set++
+		}
+		if f.Tag != nil {
+			set++
+		}
+		if f.And != nil {
+			set++
+		}
+		if set > 1 {
+			add("filter must set only one of Prefix, Tag and And")
+		}
+		if rule.Prefix != "" {
+			add("Prefix and Filter can't be both set")
+		}
+		keys := map[string]bool{}
+		for _, tag := range rule.FilterTags() {
+			if tag.Key == "" {
+				add("empty tag key")
+			}
+			if keys[tag.Key] {
+				add("duplicate tag key %q", tag.Key)
+			}
+			keys[tag.Key] = true
+		}
+	}
+	tagged := len(rule.FilterTags()) > 0
+
+	if rule.Expiration == nil && len(rule.transitions()) == 0 && rule.NoncurrentVersionExpiration == nil &&
+		len(rule.NoncurrentVersionTransitions) == 0 && rule.AbortIncompleteMultipartUpload == nil {
+		add("no action")
+	}
+
+	// The current versions: the days or the dates of the actions must be in order
+	var expirationDays int
+	var expirationDate time.Time
+	usesDays, usesDates := false, false
+	if e := rule.Expiration; e != nil {
+		set := 0
+		if e.Days != 0 {
+			set++
+		}
+		if e.Date != "" {
+			set++
+		}
+		if e.ExpiredObjectDeleteMarker {
+			set++
+		}
+		if set != 1 {
+			add("expiration must set exactly one of Days, Date and ExpiredObjectDeleteMarker")
+		}
+		if e.Days < 0 {
+			add("expiration days must be positive")
+		}
+		if e.Days > 0 {
+			expirationDays, usesDays = e.Days, true
+		}
+		if e.Date != "" {
+			if t, err := parseLifecycleDate(e.Date); err != nil {
+				add("expiration %v", err)
+			} else {
+				expirationDate, usesDates = t, true
+			}
+		}
+		if e.ExpiredObjectDeleteMarker && tagged {
+			add("ExpiredObjectDeleteMarker can't be used with the tag filter")
+		}
+	}
+
+	classes := map[string]bool{}
+	for _, t := range rule.transitions() {
+		if t.StorageClass == "" {
+			add("transition has no storage class")
+		}
+		if classes[t.StorageClass] {
+			add("duplicate transition to %s", t.StorageClass)
+		}
+		classes[t.StorageClass] = true
+		if (t.Days != 0) == (t.Date != "") {
+			add("transition to %s must set exactly one of Days and Date", t.StorageClass)
+			continue
+		}
+		if t.Days != 0 {
+			usesDays = true
+			if t.Days < 0 {
+				add("transition days to %s must be positive", t.StorageClass)
+			} else if expirationDays > 0 && t.Days >= expirationDays {
+				add("transition to %s after %d days isn't before the expiration after %d days", t.StorageClass, t.Days, expirationDays)
+			}
+			continue
+		}
+		usesDates = true
+		date, err := parseLifecycleDate(t.Date)
+		if err != nil {
+			add("transition to %s %v", t.StorageClass, err)
+		} else if !expirationDate.IsZero() && !date.Before(expirationDate) {
+			add("transition to %s on %s isn't before the expiration on %s", t.StorageClass, t.Date, rule.Expiration.Date)
+		}
+	}
+	if usesDays && usesDates {
+		add("the expiration and the transitions must all use Days or all use Date")
+	}
+	times := map[string]string{}
+	for _, t := range rule.transitions() {
+		at := fmt.Sprintf("%d/%s", t.Days, t.Date)
+		if class, ok := times[at]; ok && class != t.StorageClass {
+			add("transitions to %s and %s are at the same time", class, t.StorageClass)
+		}
+		times[at] = t.StorageClass
+	}
+
+	// The noncurrent versions
+	noncurrentDays := 0
+	if e := rule.NoncurrentVersionExpiration; e != nil {
+		if e.NoncurrentDays <= 0 {
+			add("noncurrent version expiration days must be positive")
+		}
+		noncurrentDays = e.NoncurrentDays
+	}
+	classes = map[string]bool{}
+	for _, t := range rule.NoncurrentVersionTransitions {
+		if t.StorageClass == "" {
+			add("noncurrent version transition has no storage class")
+		}
+		if classes[t.StorageClass] {
+			add("duplicate noncurrent version transition to %s", t.StorageClass)
+		}
+		classes[t.StorageClass] = true
+		if t.NoncurrentDays <= 0 {
+			add("noncurrent version transition days to %s must be positive", t.StorageClass)
+		} else if noncurrentDays > 0 && t.NoncurrentDays >= noncurrentDays {
+			add("noncurrent version transition to %s after %d days isn't before the expiration after %d days",
+				t.StorageClass, t.NoncurrentDays, noncurrentDays)
+		}
+	}
+
+	if a := rule.AbortIncompleteMultipartUpload; a != nil {
+		if a.DaysAfterInitiation <= 0 {
+			add("abort incomplete multipart upload days must be positive")
+		}
+		if tagged {
+			add("AbortIncompleteMultipartUpload can't be used with the tag filter")
+		}
+	}
+	return problems
+}
+
+// parseLifecycleDate parses the date of the expiration or the transition, it must be midnight UTC
+func parseLifecycleDate(date string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, date)
+	if err != nil {
+		return t, fmt.Errorf("date %q isn't in the format %s", date, lifecycleDateFormat)
+	}
+	if t.UTC().Truncate(24*time.Hour) != t.UTC() {
+		return t, fmt.Errorf("date %q isn't midnight UTC", date)
+	}
+	return t, nil
+}
+
+// lifecycleRulesOverlap checks if an object may match both rules: the prefixes overlap and no tag key has
+// different values
+func lifecycleRulesOverlap(a, b LifecycleRule) bool {
+	pa, pb := a.KeyPrefix(), b.KeyPrefix()
+	if !strings.HasPrefix(pa, pb) && !strings.HasPrefix(pb, pa) {
+		return false
+	}
+	tags := map[string]string{}
+	for _, tag := range a.FilterTags() {
+		tags[tag.Key] = tag.Value
+	}
+	for _, tag := range b.FilterTags() {
+		if v, ok := tags[tag.Key]; ok && v != tag.Value {
+			return false
+		}
+	}
+	return true
+}
+
+// lifecycleRuleActions returns the actions of the rule and their settings, the rules which set the same action
+// differently conflict
+func lifecycleRuleActions(rule LifecycleRule) map[string]string {
+	actions := map[string]string{}
+	if e := rule.Expiration; e != nil {
+		if e.Days > 0 {
+			actions["Expiration"] = fmt.Sprintf("%d days", e.Days)
+		} else if e.Date != "" {
+			actions["Expiration"] = e.Date
+		}
+	}
+	for _, t := range rule.transitions() {
+		if t.Days > 0 {
+			actions["Transition to "+t.StorageClass] = fmt.Sprintf("%d days", t.Days)
+		} else {
+			actions["Transition to "+t.StorageClass] = t.Date
+		}
+	}
+	if e := rule.NoncurrentVersionExpiration; e != nil {
+		actions["NoncurrentVersionExpiration"] = fmt.Sprintf("%d days", e.NoncurrentDays)
+	}
+	for _, t := range rule.NoncurrentVersionTransitions {
+		actions["NoncurrentVersionTransition to "+t.StorageClass] = fmt.Sprintf("%d days", t.NoncurrentDays)
+	}
+	if a := rule.AbortIncompleteMultipartUpload; a != nil {
+		actions["AbortIncompleteMultipartUpload"] = fmt.Sprintf("%d days", a.DaysAfterInitiation)
+	}
+	return actions
+}
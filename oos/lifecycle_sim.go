@@ -0,0 +1,261 @@
+package oos
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// LifecycleActionType is the action the lifecycle takes on an object
+type LifecycleActionType string
+
+const (
+	// LifecycleActionExpire deletes the object
+	LifecycleActionExpire LifecycleActionType = "Expire"
+
+	// LifecycleActionTransition changes the storage class of the object
+	LifecycleActionTransition LifecycleActionType = "Transition"
+)
+
+// LifecycleObjectAction is an object the lifecycle would expire or transition
+type LifecycleObjectAction struct {
+	Key          string              // Object key
+	Size         int64               // Object size
+	LastModified time.Time           // Object last modified time
+	FromClass    StorageClassType    // The current storage class of the object
+	Action       LifecycleActionType // The action taken on the object
+	StorageClass StorageClassType    // The target storage class of the transition, it's empty for the expiration
+	RuleID       string              // The ID of the rule which takes the action
+	DueDate      time.Time           // The date the action is due, midnight UTC
+}
+
+// LifecycleSimulationTotal is the count and the size of the objects
+type LifecycleSimulationTotal struct {
+	Count int64 // Number of the objects
+	Bytes int64 // Total size of the objects
+}
+
+// LifecycleSimulation is the result of the lifecycle simulation
+type LifecycleSimulation struct {
+	Date         time.Time                                     // The date simulated
+	Scanned      LifecycleSimulationTotal                      // All the objects simulated
+	Expired      []LifecycleObjectAction                       // The objects to expire, in the listing order
+	Transitioned []LifecycleObjectAction                       // The objects to transition, in the listing order
+	ExpiredTotal LifecycleSimulationTotal                      // The total of Expired
+	Transitions  map[StorageClassType]LifecycleSimulationTotal // The totals of Transitioned by the target storage class
+	SkippedRules []string                                      // The rules not simulated, such as the ones with the tag filter
+}
+
+// LifecycleSimulator previews which objects the lifecycle rules would expire or transition on a date, before the rules
+// are set by SetBucketLifecycle. Feed it the objects of the listing one by one with Add, then read Result.
+//
+// Only the current versions are simulated, NoncurrentVersionExpiration, NoncurrentVersionTransitions,
+// ExpiredObjectDeleteMarker and AbortIncompleteMultipartUpload are not. The rules with the tag filter are skipped too,
+// since the listing has no object tags.
+type LifecycleSimulator struct {
+	rules  []simulatedLifecycleRule
+	result LifecycleSimulation
+}
+
+// simulatedLifecycleRule is the enabled rule with the parsed dates
+type simulatedLifecycleRule struct {
+	id             string
+	prefix         string
+	expirationDays int
+	expirationDate time.Time
+	transitions    []simulatedLifecycleTransition
+}
+
+type simulatedLifecycleTransition struct {
+	days         int
+	date         time.Time
+	storageClass StorageClassType
+}
+
+// NewLifecycleSimulator creates the simulator of the rules.
+//
+// rules    the lifecycle rules, they're checked by ValidateLifecycleRules.
+// date    the date to simulate, the actions due on or before it are taken.
+//
+// *LifecycleSimulator    the simulator, only valid when error is nil.
+// error    it's nil if no error, otherwise it's an error object.
+func NewLifecycleSimulator(rules []LifecycleRule, date time.Time) (*LifecycleSimulator, error) {
+	if err := ValidateLifecycleRules(rules); err != nil {
+		return nil, err
+	}
+
+	s := &LifecycleSimulator{result: LifecycleSimulation{Date: date,
+		Transitions: map[StorageClassType]LifecycleSimulationTotal{}}}
+	for i, rule := range rules {
+		if !rule.IsEnabled() {
+			continue
+		}
+		if len(rule.FilterTags()) > 0 {
+			s.result.SkippedRules = append(s.result.SkippedRules, lifecycleRuleName(rule, i))
+			continue
+		}
+		// The dates are checked by ValidateLifecycleRules
+		sim := simulatedLifecycleRule{id: rule.ID, prefix: rule.KeyPrefix()}
+		if e := rule.Expiration; e != nil {
+			sim.expirationDays = e.Days
+			if e.Date != "" {
+				sim.expirationDate, _ = parseLifecycleDate(e.Date)
+			}
+		}
+		for _, t := range rule.transitions() {
+			st := simulatedLifecycleTransition{days: t.Days, storageClass: StorageClassType(t.StorageClass)}
+			if t.Date != "" {
+				st.date, _ = parseLifecycleDate(t.Date)
+			}
+			sim.transitions = append(sim.transitions, st)
+		}
+		if sim.expirationDays == 0 && sim.expirationDate.IsZero() && len(sim.transitions) == 0 {
+			continue
+		}
+		s.rules = append(s.rules, sim)
+	}
+	return s, nil
+}
+
+// Add simulates the rules on the object. The object is expired if any rule's expiration is due, otherwise it's
+// transitioned by the latest transition due, unless the object is already in the target storage class.
+//
+// object    the object from the listing.
+func (s *LifecycleSimulator) Add(object ObjectProperties) {
+	s.result.Scanned.Count++
+	s.result.Scanned.Bytes += object.Size
+
+	from := StorageClassType(object.StorageClass)
+	if from == "" {
+		from = StorageClassStandard
+	}
+	var expire, transition *LifecycleObjectAction
+	for _, rule := range s.rules {
+		if !strings.HasPrefix(object.Key, rule.prefix) {
+			continue
+		}
+		if due, ok := s.lifecycleDue(object, rule.expirationDays, rule.expirationDate); ok {
+			if expire == nil || due.Before(expire.DueDate) {
+				expire = &LifecycleObjectAction{Action: LifecycleActionExpire, RuleID: rule.id, DueDate: due}
+			}
+		}
+		for _, t := range rule.transitions {
+			if t.storageClass == from {
+				continue
+			}
+			if due, ok := s.lifecycleDue(object, t.days, t.date); ok {
+				if transition == nil || due.After(transition.DueDate) {
+					transition = &LifecycleObjectAction{Action: LifecycleActionTransition, StorageClass: t.storageClass,
+						RuleID: rule.id, DueDate: due}
+				}
+			}
+		}
+	}
+
+	action := expire
+	if action == nil {
+		action = transition
+	}
+	if action == nil {
+		return
+	}
+	action.Key, action.Size, action.LastModified, action.FromClass = object.Key, object.Size, object.LastModified, from
+	if action.Action == LifecycleActionExpire {
+		s.result.Expired = append(s.result.Expired, *action)
+		s.result.ExpiredTotal.Count++
+		s.result.ExpiredTotal.Bytes += object.Size
+		return
+	}
+	s.result.Transitioned = append(s.result.Transitioned, *action)
+	total := s.result.Transitions[action.StorageClass]
+	total.Count++
+	total.Bytes += object.Size
+	s.result.Transitions[action.StorageClass] = total
+}
+
+// lifecycleDue returns the due date of the action set by days or date, and whether it's due on the simulated date.
+// The days after the last modified time are rounded up to the next midnight UTC, as the service does.
+func (s *LifecycleSimulator) lifecycleDue(object ObjectProperties, days int, date time.Time) (time.Time, bool) {
+	if days > 0 {
+		at := object.LastModified.UTC().AddDate(0, 0, days)
+		date = at.Truncate(24 * time.Hour)
+		if date.Before(at) {
+			date = date.Add(24 * time.Hour)
+		}
+	}
+	if date.IsZero() {
+		return date, false
+	}
+	return date, !date.After(s.result.Date)
+}
+
+// Result returns the result of the objects added so far
+func (s *LifecycleSimulator) Result() LifecycleSimulation {
+	return s.result
+}
+
+// String summarizes the simulation, such as
+//
+//	2024-01-01: 120 objects (65536 bytes) scanned, expire 10 objects (1024 bytes), transition 5 objects (2048 bytes) to STANDARD_IA
+func (sim LifecycleSimulation) String() string {
+	s := fmt.Sprintf("%s: %d objects (%d bytes) scanned, expire %d objects (%d bytes)", sim.Date.Format("2006-01-02"),
+		sim.Scanned.Count, sim.Scanned.Bytes, sim.ExpiredTotal.Count, sim.ExpiredTotal.Bytes)
+	classes := make([]string, 0, len(sim.Transitions))
+	for class := range sim.Transitions {
+		classes = append(classes, string(class))
+	}
+	sort.Strings(classes)
+	for _, class := range classes {
+		total := sim.Transitions[StorageClassType(class)]
+		s += fmt.Sprintf(", transition %d objects (%d bytes) to %s", total.Count, total.Bytes, class)
+	}
+	if len(sim.SkippedRules) > 0 {
+		s += ", skipped " + strings.Join(sim.SkippedRules, ", ")
+	}
+	return s
+}
+
+// SimulateLifecycle previews which objects the lifecycle rules would expire or transition on the date, see
+// LifecycleSimulator.
+//
+// rules    the lifecycle rules.
+// objects    the objects from ListObjects.
+// date    the date to simulate.
+//
+// LifecycleSimulation    the result, only valid when error is nil.
+// error    it's nil if no error, otherwise it's an error object.
+func SimulateLifecycle(rules []LifecycleRule, objects []ObjectProperties, date time.Time) (LifecycleSimulation, error) {
+	s, err := NewLifecycleSimulator(rules, date)
+	if err != nil {
+		return LifecycleSimulation{}, err
+	}
+	for _, object := range objects {
+		s.Add(object)
+	}
+	return s.Result(), nil
+}
+
+// SimulateLifecycle previews which objects of the bucket the lifecycle rules would expire or transition on the date.
+// It lists all the objects, so set the Prefix option to simulate a part of a large bucket.
+//
+// rules    the lifecycle rules, such as the ones to set, or the ones from GetBucketLifecycle.
+// date    the date to simulate.
+// options    the options of ListObjects, such as Prefix.
+//
+// LifecycleSimulation    the result, only valid when error is nil.
+// error    it's nil if no error, otherwise it's an error object.
+func (bucket Object) SimulateLifecycle(rules []LifecycleRule, date time.Time, options ...Option) (LifecycleSimulation, error) {
+	s, err := NewLifecycleSimulator(rules, date)
+	if err != nil {
+		return LifecycleSimulation{}, err
+	}
+	it := bucket.NewObjectIterator(options...)
+	for it.Next() {
+		s.Add(it.Object())
+	}
+	if err = it.Err(); err != nil {
+		return LifecycleSimulation{}, err
+	}
+	return s.Result(), nil
+}
@@ -6,9 +6,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io/ioutil"
 	"net/http"
-	"os"
 	"strconv"
 )
 
@@ -240,7 +238,7 @@ func (cp copyCheckpoint) isValid(meta http.Header) (bool, error) {
 	sum := md5.Sum(js)
 	b64 := base64.StdEncoding.EncodeToString(sum[:])
 
-	if cp.Magic != downloadCpMagic || b64 != cp.MD5 {
+	if cp.Magic != copyCpMagic || b64 != cp.MD5 {
 		return false, nil
 	}
 
@@ -259,15 +257,9 @@ func (cp copyCheckpoint) isValid(meta http.Header) (bool, error) {
 	return true, nil
 }
 
-// load loads from the checkpoint file
-func (cp *copyCheckpoint) load(filePath string) error {
-	contents, err := ioutil.ReadFile(filePath)
-	if err != nil {
-		return err
-	}
-
-	err = json.Unmarshal(contents, cp)
-	return err
+// load loads from the checkpoint store
+func (cp *copyCheckpoint) load(store CheckpointStorage, key string) error {
+	return loadCheckpoint(store, key, cp)
 }
 
 // update updates the parts status
@@ -276,28 +268,10 @@ func (cp *copyCheckpoint) update(part UploadPart) {
 	cp.PartStat[part.PartNumber-1] = true
 }
 
-// dump dumps the CP to the file
-func (cp *copyCheckpoint) dump(filePath string) error {
+// dump dumps the CP to the checkpoint store
+func (cp *copyCheckpoint) dump(store CheckpointStorage, key string) error {
 	bcp := *cp
-
-	// Calculate MD5
-	bcp.MD5 = ""
-	js, err := json.Marshal(bcp)
-	if err != nil {
-		return err
-	}
-	sum := md5.Sum(js)
-	b64 := base64.StdEncoding.EncodeToString(sum[:])
-	bcp.MD5 = b64
-
-	// Serialization
-	js, err = json.Marshal(bcp)
-	if err != nil {
-		return err
-	}
-
-	// Dump
-	return ioutil.WriteFile(filePath, js, FilePermMode)
+	return dumpCheckpoint(store, key, &bcp, func(md5 string) { bcp.MD5 = md5 })
 }
 
 // todoParts returns unfinished parts
@@ -359,13 +333,13 @@ func (cp *copyCheckpoint) prepare(meta http.Header, srcBucket *Object, srcObject
 	return nil
 }
 
-func (cp *copyCheckpoint) complete(bucket *Object, parts []UploadPart, cpFilePath string, options []Option) error {
+func (cp *copyCheckpoint) complete(bucket *Object, parts []UploadPart, store CheckpointStorage, cpKey string, options []Option) error {
 	imur := InitiateMultipartUploadResult{Bucket: cp.DestBucketName,
 		Key: cp.DestObjectKey, UploadID: cp.CopyID}
 	_, err := bucket.CompleteMultipartUpload(imur, parts, options...)
 	if err != nil {
 		return err
 	}
-	os.Remove(cpFilePath)
+	store.Delete(cpKey)
 	return err
 }
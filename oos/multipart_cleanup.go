@@ -0,0 +1,100 @@
+package oos
+
+import (
+	"sync"
+	"time"
+)
+
+// AbortUploadsReport is the result of AbortIncompleteUploads
+type AbortUploadsReport struct {
+	DryRun  bool                 // Nothing is aborted when it's true
+	Scanned int                  // Number of the listed uploads under the prefix
+	Matched []UncompletedUpload  // Uploads initiated before the cutoff
+	Aborted []UncompletedUpload  // Uploads aborted successfully, empty in dry run
+	Failed  []AbortUploadFailure // Uploads which failed to abort
+}
+
+// AbortUploadFailure is an upload which failed to abort
+type AbortUploadFailure struct {
+	Upload UncompletedUpload
+	Err    error
+}
+
+// AbortIncompleteUploads aborts the multipart uploads which were initiated before olderThan ago.
+// The uploads are listed page by page and aborted concurrently, the concurrency is 5 unless Routines is set.
+//
+// olderThan    the minimal age of the uploads to abort, 0 matches all uploads.
+// prefix    only the uploads of the keys with the prefix are aborted.
+// dryRun    only reports the matched uploads if it's true.
+// options    the options for ListMultipartUploads and AbortMultipartUpload, Routines sets the concurrency.
+//
+// AbortUploadsReport    the matched, aborted and failed uploads. It's valid even when error is not nil.
+// error    it's nil if the uploads are listed, otherwise it's an error object. Abort errors are in the report.
+func (bucket Object) AbortIncompleteUploads(olderThan time.Duration, prefix string, dryRun bool, options ...Option) (AbortUploadsReport, error) {
+	report := AbortUploadsReport{DryRun: dryRun}
+	cutoff := time.Now().Add(-olderThan)
+
+	routines := 5
+	if isSet, _, _ := isOptionSet(options, routineNum); isSet {
+		routines = getRoutines(options)
+	}
+
+	jobs := make(chan UncompletedUpload, routines)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	if !dryRun {
+		for w := 0; w < routines; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for upload := range jobs {
+					imur := InitiateMultipartUploadResult{Bucket: bucket.BucketName, Key: upload.Key, UploadID: upload.UploadID}
+					err := bucket.AbortMultipartUpload(imur, options...)
+
+					mu.Lock()
+					if err != nil {
+						report.Failed = append(report.Failed, AbortUploadFailure{Upload: upload, Err: err})
+					} else {
+						report.Aborted = append(report.Aborted, upload)
+					}
+					mu.Unlock()
+				}
+			}()
+		}
+	}
+
+	var listErr error
+	keyMarker, uploadIDMarker := "", ""
+	for {
+		lmur, err := bucket.ListMultipartUploads(Prefix(prefix), KeyMarker(keyMarker), UploadIDMarker(uploadIDMarker))
+		if err != nil {
+			listErr = err
+			break
+		}
+
+		for _, upload := range lmur.Uploads {
+			mu.Lock()
+			report.Scanned++
+			matched := olderThan <= 0 || upload.Initiated.Before(cutoff)
+			if matched {
+				report.Matched = append(report.Matched, upload)
+			}
+			mu.Unlock()
+			if matched && !dryRun {
+				jobs <- upload
+			}
+		}
+
+		if !lmur.IsTruncated {
+			break
+		}
+		keyMarker, uploadIDMarker = lmur.NextKeyMarker, lmur.NextUploadIDMarker
+		if keyMarker == "" && uploadIDMarker == "" {
+			break
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	return report, listErr
+}
@@ -0,0 +1,198 @@
+package oos
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+)
+
+// PresignUploadPart signs the URL to upload the part of the multipart upload. The backend initiates the upload and
+// hands out the part URLs, then the clients upload the parts in parallel with UploadPartWithURL or any HTTP client.
+//
+// imur    the return value of InitiateMultipartUpload.
+// partNumber    the part number, ranges from 1 to 10,000.
+// expiredInSec    the validity period of the URL in seconds.
+// options    the headers signed in the URL, such as ContentType. The clients must send the same headers.
+//
+// string    the signed URL of the PUT request, only valid when error is nil.
+// error    it's nil if no error, otherwise it's an error object.
+func (bucket Object) PresignUploadPart(imur InitiateMultipartUploadResult, partNumber int, expiredInSec int64,
+	options ...Option) (string, error) {
+	if partNumber < 1 || partNumber > MaxPartNumber {
+		return "", fmt.Errorf("the parameter is invalid: partNumber must be between 1 and %d", MaxPartNumber)
+	}
+	params := map[string]interface{}{}
+	params["partNumber"] = strconv.Itoa(partNumber)
+	return bucket.presignMultipart(HTTPPut, imur, params, expiredInSec, options)
+}
+
+// PresignCompleteMultipartUpload signs the URL to complete the multipart upload with CompleteMultipartUploadWithURL.
+//
+// imur    the return value of InitiateMultipartUpload.
+// expiredInSec    the validity period of the URL in seconds.
+// options    the headers signed in the URL.
+//
+// string    the signed URL of the POST request, only valid when error is nil.
+// error    it's nil if no error, otherwise it's an error object.
+func (bucket Object) PresignCompleteMultipartUpload(imur InitiateMultipartUploadResult, expiredInSec int64,
+	options ...Option) (string, error) {
+	return bucket.presignMultipart(HTTPPost, imur, map[string]interface{}{}, expiredInSec, options)
+}
+
+// PresignListUploadedParts signs the URL to list the uploaded parts with ListUploadedPartsWithURL.
+//
+// imur    the return value of InitiateMultipartUpload.
+// expiredInSec    the validity period of the URL in seconds.
+// options    the list parameters such as MaxParts and PartNumberMarker.
+//
+// string    the signed URL of the GET request, only valid when error is nil.
+// error    it's nil if no error, otherwise it's an error object.
+func (bucket Object) PresignListUploadedParts(imur InitiateMultipartUploadResult, expiredInSec int64,
+	options ...Option) (string, error) {
+	options = append(options, EncodingType(HTTPParamEncodingType))
+	params, err := getRawParams(options)
+	if err != nil {
+		return "", err
+	}
+	return bucket.presignMultipart(HTTPGet, imur, params, expiredInSec, options)
+}
+
+// PresignAbortMultipartUpload signs the URL to abort the multipart upload with AbortMultipartUploadWithURL.
+//
+// imur    the return value of InitiateMultipartUpload.
+// expiredInSec    the validity period of the URL in seconds.
+// options    the headers signed in the URL.
+//
+// string    the signed URL of the DELETE request, only valid when error is nil.
+// error    it's nil if no error, otherwise it's an error object.
+func (bucket Object) PresignAbortMultipartUpload(imur InitiateMultipartUploadResult, expiredInSec int64,
+	options ...Option) (string, error) {
+	return bucket.presignMultipart(HTTPDelete, imur, map[string]interface{}{}, expiredInSec, options)
+}
+
+// presignMultipart signs the URL of the multipart upload with the uploadId and the params
+func (bucket Object) presignMultipart(method HTTPMethod, imur InitiateMultipartUploadResult,
+	params map[string]interface{}, expiredInSec int64, options []Option) (string, error) {
+	if imur.Key == "" || imur.UploadID == "" {
+		return "", errors.New("the parameter is invalid: the key and the upload id of imur are required")
+	}
+	if expiredInSec <= 0 {
+		return "", fmt.Errorf("invalid expires: %d, expires must bigger than 0", expiredInSec)
+	}
+
+	headers := make(map[string]string)
+	err := handleOptions(headers, options)
+	if err != nil {
+		return "", err
+	}
+
+	params["uploadId"] = imur.UploadID
+	return bucket.Bucket.Conn.signURL(method, bucket.BucketName, imur.Key, expiredInSec, params, headers), nil
+}
+
+// UploadPartWithURL uploads the part with the URL signed by PresignUploadPart.
+//
+// signedURL    the signed URL.
+// reader    io.Reader the reader of the part data.
+// partSize    the part size.
+// options    the headers signed in the URL, and Progress.
+//
+// UploadPart    the return value consists of PartNumber and ETag, which is used by CompleteMultipartUploadWithURL.
+// error    it's nil if the operation succeeds, otherwise it's an error object.
+func (bucket Object) UploadPartWithURL(signedURL string, reader io.Reader, partSize int64, options ...Option) (UploadPart, error) {
+	var part UploadPart
+	uri, err := url.Parse(signedURL)
+	if err != nil {
+		return part, err
+	}
+	partNumber, err := strconv.Atoi(uri.Query().Get("partNumber"))
+	if err != nil {
+		return part, errors.New("the parameter is invalid: the signed URL has no partNumber")
+	}
+
+	listener := getProgressListener(options)
+	options = append(options, ContentLength(partSize))
+	resp, err := bucket.doURL(HTTPPut, signedURL, nil, options, &io.LimitedReader{R: reader, N: partSize}, listener)
+	if err != nil {
+		return part, err
+	}
+	defer resp.Body.Close()
+
+	part.ETag = resp.Headers.Get(HTTPHeaderEtag)
+	part.PartNumber = partNumber
+	return part, nil
+}
+
+// CompleteMultipartUploadWithURL completes the multipart upload with the URL signed by PresignCompleteMultipartUpload.
+//
+// signedURL    the signed URL.
+// parts    the uploaded parts, such as the return values of UploadPartWithURL.
+// options    the headers signed in the URL.
+//
+// CompleteMultipartUploadResult    the return value when the call succeeds. Only valid when the error is nil.
+// error    it's nil if the operation succeeds, otherwise it's an error object.
+func (bucket Object) CompleteMultipartUploadWithURL(signedURL string, parts []UploadPart,
+	options ...Option) (CompleteMultipartUploadResult, error) {
+	var out CompleteMultipartUploadResult
+
+	sort.Sort(uploadParts(parts))
+	cxml := completeMultipartUploadXML{}
+	cxml.Part = parts
+	bs, err := xml.Marshal(cxml)
+	if err != nil {
+		return out, err
+	}
+
+	resp, err := bucket.doURL(HTTPPost, signedURL, nil, options, bytes.NewReader(bs), nil)
+	if err != nil {
+		return out, err
+	}
+	defer resp.Body.Close()
+
+	err = xmlUnmarshal(resp.Body, &out)
+	return out, err
+}
+
+// ListUploadedPartsWithURL lists the uploaded parts with the URL signed by PresignListUploadedParts.
+//
+// signedURL    the signed URL.
+// options    the headers signed in the URL.
+//
+// ListUploadedPartsResult    the return value if it succeeds, only valid when error is nil.
+// error    it's nil if the operation succeeds, otherwise it's an error object.
+func (bucket Object) ListUploadedPartsWithURL(signedURL string, options ...Option) (ListUploadedPartsResult, error) {
+	var out ListUploadedPartsResult
+	resp, err := bucket.doURL(HTTPGet, signedURL, nil, options, nil, nil)
+	if err != nil {
+		return out, err
+	}
+	defer resp.Body.Close()
+
+	err = xmlUnmarshal(resp.Body, &out)
+	if err != nil {
+		return out, err
+	}
+	err = decodeListUploadedPartsResult(&out)
+	return out, err
+}
+
+// AbortMultipartUploadWithURL aborts the multipart upload with the URL signed by PresignAbortMultipartUpload.
+//
+// signedURL    the signed URL.
+// options    the headers signed in the URL.
+//
+// error    it's nil if the operation succeeds, otherwise it's an error object.
+func (bucket Object) AbortMultipartUploadWithURL(signedURL string, options ...Option) error {
+	resp, err := bucket.doURL(HTTPDelete, signedURL, nil, options, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return checkRespCode(resp.StatusCode, []int{http.StatusNoContent})
+}
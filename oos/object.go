@@ -27,7 +27,8 @@ type Object struct {
 // reader    io.Reader instance for reading the data for uploading
 // options    the options for uploading the object. The valid options here are CacheControl, ContentDisposition, ContentEncoding
 //
-//	Expires, ServerSideEncryption, ObjectACL and Meta.
+//	Expires, ServerSideEncryption, ObjectACL and Meta. ContentLength is required for the readers other than
+//	bytes.Buffer, bytes.Reader, strings.Reader, os.File and io.LimitedReader.
 //
 // error    it's nil if no error, otherwise it's an error object.
 func (bucket Object) PutObject(objectKey string, reader io.Reader, options ...Option) error {
@@ -48,6 +49,11 @@ func (bucket Object) PutObject(objectKey string, reader io.Reader, options ...Op
 		ContentLength = tryGetFileSize(v)
 	case *io.LimitedReader:
 		ContentLength = int64(v.N)
+	default:
+		// The length of the other readers, such as the non-seekable ones, is set by the ContentLength option
+		if val, _ := findOption(options, HTTPHeaderContentLength, nil); val != nil {
+			ContentLength, _ = strconv.ParseInt(val.(string), 10, 64)
+		}
 	}
 
 	if ContentLength == 0 {
@@ -310,16 +316,17 @@ func (bucket Object) copy(srcObjectKey, destBucketName, destObjectKey string, op
 // DeleteObject deletes the object.
 //
 // objectKey    the object key to delete.
+// options    the options for deleting the object, such as BypassGovernanceRetention.
 //
 // error    it's nil if no error, otherwise it's an error object.
-func (bucket Object) DeleteObject(objectKey string) error {
+func (bucket Object) DeleteObject(objectKey string, options ...Option) error {
 
 	if objectKey == "" {
 		return errors.New("the parameter is invalid: ObjectKey is empty")
 	}
 
 	params := map[string]interface{}{}
-	resp, err := bucket.do("DELETE", objectKey, params, nil, nil, nil)
+	resp, err := bucket.do("DELETE", objectKey, params, options, nil, nil)
 	if err != nil {
 		return err
 	}
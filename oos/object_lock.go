@@ -0,0 +1,112 @@
+package oos
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// PutObjectRetention sets the retention of the object, the bucket must have the object lock enabled.
+//
+// objectKey    the object key.
+// retention    the retention mode and the date it expires.
+// options    the options, BypassGovernanceRetention is required to shorten or remove the GOVERNANCE retention.
+//
+// error    it's nil if no error, otherwise it's an error object.
+func (bucket Object) PutObjectRetention(objectKey string, retention ObjectRetention, options ...Option) error {
+	if objectKey == "" {
+		return errors.New("the parameter is invalid: ObjectKey is empty")
+	}
+	if retention.Mode != BucketModeCompliance && retention.Mode != BucketModeGovernance {
+		return errors.New("the parameter is invalid: retention mode must be COMPLIANCE or GOVERNANCE")
+	}
+	if retention.RetainUntilDate.IsZero() {
+		return errors.New("the parameter is invalid: RetainUntilDate is empty")
+	}
+
+	// The service accepts the date in seconds
+	retention.RetainUntilDate = retention.RetainUntilDate.UTC().Truncate(time.Second)
+	return bucket.putObjectLockConfig(objectKey, "retention", retention, options)
+}
+
+// GetObjectRetention gets the retention of the object.
+//
+// objectKey    the object key.
+// options    the options for the request.
+//
+// ObjectRetention    the retention of the object, only valid when error is nil.
+// error    it's nil if no error, otherwise it's an error object. It's a ServiceError with 404 if the object has no retention.
+func (bucket Object) GetObjectRetention(objectKey string, options ...Option) (ObjectRetention, error) {
+	var out ObjectRetention
+	err := bucket.getObjectLockConfig(objectKey, "retention", &out, options)
+	return out, err
+}
+
+// PutObjectLegalHold turns on or off the legal hold of the object, the bucket must have the object lock enabled.
+//
+// objectKey    the object key.
+// status    LegalHoldOn or LegalHoldOff.
+// options    the options for the request.
+//
+// error    it's nil if no error, otherwise it's an error object.
+func (bucket Object) PutObjectLegalHold(objectKey string, status LegalHoldStatusType, options ...Option) error {
+	if objectKey == "" {
+		return errors.New("the parameter is invalid: ObjectKey is empty")
+	}
+	if status != LegalHoldOn && status != LegalHoldOff {
+		return errors.New("the parameter is invalid: legal hold status must be ON or OFF")
+	}
+	return bucket.putObjectLockConfig(objectKey, "legal-hold", ObjectLegalHold{Status: status}, options)
+}
+
+// GetObjectLegalHold gets the legal hold status of the object.
+//
+// objectKey    the object key.
+// options    the options for the request.
+//
+// LegalHoldStatusType    the legal hold status, only valid when error is nil.
+// error    it's nil if no error, otherwise it's an error object.
+func (bucket Object) GetObjectLegalHold(objectKey string, options ...Option) (LegalHoldStatusType, error) {
+	var out ObjectLegalHold
+	err := bucket.getObjectLockConfig(objectKey, "legal-hold", &out, options)
+	return out.Status, err
+}
+
+// putObjectLockConfig puts the XML of the object subresource with Content-MD5, which the service requires
+func (bucket Object) putObjectLockConfig(objectKey, subResource string, config interface{}, options []Option) error {
+	bs, err := xml.Marshal(config)
+	if err != nil {
+		return err
+	}
+	sum := md5.Sum(bs)
+	options = append(options, ContentType("application/xml"), ContentMD5(base64.StdEncoding.EncodeToString(sum[:])))
+
+	params := map[string]interface{}{}
+	params[subResource] = nil
+	resp, err := bucket.do("PUT", objectKey, params, options, bytes.NewReader(bs), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return checkRespCode(resp.StatusCode, []int{http.StatusOK})
+}
+
+// getObjectLockConfig gets the XML of the object subresource
+func (bucket Object) getObjectLockConfig(objectKey, subResource string, out interface{}, options []Option) error {
+	if objectKey == "" {
+		return errors.New("the parameter is invalid: ObjectKey is empty")
+	}
+
+	params := map[string]interface{}{}
+	params[subResource] = nil
+	resp, err := bucket.do("GET", objectKey, params, options, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return xmlUnmarshal(resp.Body, out)
+}
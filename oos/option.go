@@ -20,6 +20,7 @@ const (
 	deleteObjectsQuiet = "delete-objects-quiet"
 	routineNum         = "x-routine-num"
 	checkpointConfig   = "x-cp-config"
+	checkpointStore    = "x-cp-store"
 	progressListener   = "x-progress-listener"
 	storageClass       = "x-amz-storage-class"
 )
@@ -190,6 +191,26 @@ func ObjectDataLocation(location string) Option {
 	return setHeader(HTTPHeaderXctyunDataLocation, location)
 }
 
+// ObjectLockMode is an option to set the retention mode of the object when it's uploaded or copied
+func ObjectLockMode(mode BucketMode) Option {
+	return setHeader(HTTPHeaderoosObjectLockMode, string(mode))
+}
+
+// ObjectLockRetainUntilDate is an option to set the date the retention of the object expires, it's used with ObjectLockMode
+func ObjectLockRetainUntilDate(t time.Time) Option {
+	return setHeader(HTTPHeaderoosObjectLockRetainUntilDate, t.UTC().Format(time.RFC3339))
+}
+
+// ObjectLockLegalHold is an option to set the legal hold of the object when it's uploaded or copied
+func ObjectLockLegalHold(status LegalHoldStatusType) Option {
+	return setHeader(HTTPHeaderoosObjectLockLegalHold, string(status))
+}
+
+// BypassGovernanceRetention is an option to shorten or remove the GOVERNANCE retention, or to delete the object under it
+func BypassGovernanceRetention(bypass bool) Option {
+	return setHeader(HTTPHeaderoosBypassGovernanceRetention, strconv.FormatBool(bypass))
+}
+
 // StorageClass bucket storage class
 func StorageClass(value StorageClassType) Option {
 	return setHeader(storageClass, string(value))
@@ -276,6 +297,12 @@ func CheckpointDir(isEnable bool, dirPath string) Option {
 	return addArg(checkpointConfig, &cpConfig{IsEnable: isEnable, DirPath: dirPath})
 }
 
+// CheckpointStore sets the store of the checkpoints for DownloadFile/UploadFile, the default is the local files.
+// It enables the checkpoint if neither Checkpoint nor CheckpointDir is set.
+func CheckpointStore(store CheckpointStorage) Option {
+	return addArg(checkpointStore, store)
+}
+
 // Routines DownloadFile/UploadFile routine count
 func Routines(n int) Option {
 	return addArg(routineNum, n)
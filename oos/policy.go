@@ -0,0 +1,463 @@
+package oos
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PolicyEffect is the effect of a policy statement
+type PolicyEffect string
+
+const (
+	// PolicyEffectAllow allows the matched requests
+	PolicyEffectAllow PolicyEffect = "Allow"
+
+	// PolicyEffectDeny denies the matched requests, it wins over Allow
+	PolicyEffectDeny PolicyEffect = "Deny"
+)
+
+// PolicyVersion is the current policy language version
+const PolicyVersion = "2012-10-17"
+
+// Policy condition keys supported by oos
+const (
+	PolicyKeySourceIP        = "aws:SourceIp"
+	PolicyKeyReferer         = "aws:Referer"
+	PolicyKeySecureTransport = "aws:SecureTransport"
+	PolicyKeyUserAgent       = "aws:UserAgent"
+	PolicyKeyCurrentTime     = "aws:CurrentTime"
+)
+
+// PolicyDocument defines the bucket policy. It's the typed form of the text accepted by SetBucketPolicy.
+type PolicyDocument struct {
+	Version   string      `json:"Version,omitempty"` // Policy language version, PolicyVersion
+	ID        string      `json:"Id,omitempty"`      // Optional policy ID
+	Statement []Statement `json:"Statement"`         // The statements
+}
+
+// Statement defines one statement of the bucket policy
+type Statement struct {
+	Sid          string          `json:"Sid,omitempty"`          // Statement ID, unique in the document
+	Effect       PolicyEffect    `json:"Effect"`                 // Allow or Deny
+	Principal    *Principal      `json:"Principal,omitempty"`    // Who the statement applies to
+	NotPrincipal *Principal      `json:"NotPrincipal,omitempty"` // Who the statement doesn't apply to
+	Action       StringList      `json:"Action,omitempty"`       // Actions such as s3:GetObject
+	NotAction    StringList      `json:"NotAction,omitempty"`    // Actions excluded
+	Resource     StringList      `json:"Resource,omitempty"`     // Resources such as arn:aws:s3:::bucket/prefix*
+	NotResource  StringList      `json:"NotResource,omitempty"`  // Resources excluded
+	Condition    PolicyCondition `json:"Condition,omitempty"`    // Conditions, all of them must match
+}
+
+// PolicyCondition maps the condition operator to the condition key and its values,
+// such as {"IpAddress": {"aws:SourceIp": ["10.0.0.0/8"]}}.
+// It's named PolicyCondition because Condition is the routing rule condition of the bucket website.
+type PolicyCondition map[string]map[string]StringList
+
+// StringList is a list of strings which is written as a single string in JSON when it has one element.
+type StringList []string
+
+// MarshalJSON implements json.Marshaler
+func (sl StringList) MarshalJSON() ([]byte, error) {
+	if len(sl) == 1 {
+		return json.Marshal(sl[0])
+	}
+	return json.Marshal([]string(sl))
+}
+
+// UnmarshalJSON implements json.Unmarshaler, it accepts a string or an array of strings. The booleans and the numbers,
+// such as the values of the Bool and Numeric conditions, are kept as their JSON text.
+func (sl *StringList) UnmarshalJSON(data []byte) error {
+	var raw interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&raw); err != nil {
+		return err
+	}
+	values, ok := raw.([]interface{})
+	if !ok {
+		values = []interface{}{raw}
+	}
+	list := make(StringList, 0, len(values))
+	for _, v := range values {
+		switch v := v.(type) {
+		case string:
+			list = append(list, v)
+		case bool:
+			list = append(list, strconv.FormatBool(v))
+		case json.Number:
+			list = append(list, v.String())
+		default:
+			return fmt.Errorf("oos: policy value must be a string, a boolean, a number or an array of them: %s", string(data))
+		}
+	}
+	*sl = list
+	return nil
+}
+
+// Principal defines the principal of a statement. Anonymous is written as "*".
+type Principal struct {
+	All bool       // "*", everyone including anonymous users
+	AWS StringList // Account or user ARNs, or "*"
+}
+
+// PrincipalAll returns the principal "*"
+func PrincipalAll() *Principal {
+	return &Principal{All: true}
+}
+
+// PrincipalAWS returns the principal {"AWS": arns}
+func PrincipalAWS(arns ...string) *Principal {
+	return &Principal{AWS: arns}
+}
+
+// MarshalJSON implements json.Marshaler
+func (p Principal) MarshalJSON() ([]byte, error) {
+	if p.All {
+		return json.Marshal("*")
+	}
+	return json.Marshal(map[string]StringList{"AWS": p.AWS})
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (p *Principal) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		if s != "*" {
+			return fmt.Errorf("oos: invalid principal %q", s)
+		}
+		*p = Principal{All: true}
+		return nil
+	}
+	var m map[string]StringList
+	if err := json.Unmarshal(data, &m); err != nil {
+		return fmt.Errorf("oos: invalid principal: %s", string(data))
+	}
+	*p = Principal{AWS: m["AWS"]}
+	for k := range m {
+		if k != "AWS" {
+			return fmt.Errorf("oos: unsupported principal type %q", k)
+		}
+	}
+	return nil
+}
+
+// ParsePolicyDocument parses the bucket policy text, such as the result of GetBucketPolicy
+func ParsePolicyDocument(text string) (PolicyDocument, error) {
+	var doc PolicyDocument
+	err := json.Unmarshal([]byte(text), &doc)
+	return doc, err
+}
+
+// String returns the JSON text of the policy accepted by SetBucketPolicy
+func (doc PolicyDocument) String() string {
+	bs, _ := json.Marshal(doc)
+	return string(bs)
+}
+
+// NewPolicyDocument creates the policy document with the current version. The statements with the same Sid, such as
+// the ones of two BuildIPAllowStatement calls, get the number suffix to keep the Sid unique, such as IPAllow2.
+func NewPolicyDocument(statements ...Statement) PolicyDocument {
+	sids := map[string]bool{}
+	for _, st := range statements {
+		sids[st.Sid] = true
+	}
+	seen := map[string]bool{}
+	unique := make([]Statement, len(statements))
+	for i, st := range statements {
+		if st.Sid != "" && seen[st.Sid] {
+			base := st.Sid
+			for n := 2; sids[st.Sid]; n++ {
+				st.Sid = base + strconv.Itoa(n)
+			}
+			sids[st.Sid] = true
+		}
+		seen[st.Sid] = true
+		unique[i] = st
+	}
+	return PolicyDocument{Version: PolicyVersion, Statement: unique}
+}
+
+// BucketARN returns the resource ARN of the bucket, for bucket actions such as s3:ListBucket
+func BucketARN(bucketName string) string {
+	return "arn:aws:s3:::" + bucketName
+}
+
+// ObjectARN returns the resource ARN of the objects with the key pattern such as "logs/*"
+func ObjectARN(bucketName, keyPattern string) string {
+	return "arn:aws:s3:::" + bucketName + "/" + keyPattern
+}
+
+// prefixResources returns the object ARNs of the prefixes, or of the whole bucket when no prefix is given
+func prefixResources(bucketName string, prefixes []string) StringList {
+	if len(prefixes) == 0 {
+		return StringList{ObjectARN(bucketName, "*")}
+	}
+	res := StringList{}
+	for _, prefix := range prefixes {
+		res = append(res, ObjectARN(bucketName, strings.TrimSuffix(prefix, "*")+"*"))
+	}
+	return res
+}
+
+// BuildPublicReadStatement builds the statement which allows everyone to read the objects under the prefixes.
+//
+// bucketName    the bucket name.
+// prefixes    the key prefixes, the whole bucket when it's empty.
+func BuildPublicReadStatement(bucketName string, prefixes ...string) Statement {
+	return Statement{
+		Sid:       "PublicRead",
+		Effect:    PolicyEffectAllow,
+		Principal: PrincipalAll(),
+		Action:    StringList{"s3:GetObject"},
+		Resource:  prefixResources(bucketName, prefixes),
+	}
+}
+
+// BuildIPAllowStatement builds the statement which allows the actions on the objects only from the IP ranges.
+//
+// bucketName    the bucket name.
+// actions    the actions such as s3:GetObject and s3:PutObject.
+// cidrs    the source IPs or the CIDR ranges such as 10.0.0.0/8.
+func BuildIPAllowStatement(bucketName string, actions []string, cidrs ...string) Statement {
+	return Statement{
+		Sid:       "IPAllow",
+		Effect:    PolicyEffectAllow,
+		Principal: PrincipalAll(),
+		Action:    StringList(actions),
+		Resource:  StringList{ObjectARN(bucketName, "*")},
+		Condition: PolicyCondition{"IpAddress": {PolicyKeySourceIP: StringList(cidrs)}},
+	}
+}
+
+// BuildIPDenyOthersStatement builds the statement which denies all actions on the bucket from outside the IP ranges.
+// Together with other Allow statements it makes an IP allow-list.
+//
+// bucketName    the bucket name.
+// cidrs    the source IPs or the CIDR ranges which are not denied.
+func BuildIPDenyOthersStatement(bucketName string, cidrs ...string) Statement {
+	return Statement{
+		Sid:       "IPDenyOthers",
+		Effect:    PolicyEffectDeny,
+		Principal: PrincipalAll(),
+		Action:    StringList{"s3:*"},
+		Resource:  StringList{BucketARN(bucketName), ObjectARN(bucketName, "*")},
+		Condition: PolicyCondition{"NotIpAddress": {PolicyKeySourceIP: StringList(cidrs)}},
+	}
+}
+
+// BuildRefererStatement builds the statement which allows everyone to read the objects when the referer matches.
+//
+// bucketName    the bucket name.
+// referers    the referer patterns, "*" and "?" are wildcards, such as http://*.example.com/*.
+func BuildRefererStatement(bucketName string, referers ...string) Statement {
+	return Statement{
+		Sid:       "RefererAllow",
+		Effect:    PolicyEffectAllow,
+		Principal: PrincipalAll(),
+		Action:    StringList{"s3:GetObject"},
+		Resource:  StringList{ObjectARN(bucketName, "*")},
+		Condition: PolicyCondition{"StringLike": {PolicyKeyReferer: StringList(referers)}},
+	}
+}
+
+// policyConditionOperators are the supported condition operators, without the IfExists suffix
+var policyConditionOperators = []string{
+	"StringEquals", "StringNotEquals", "StringEqualsIgnoreCase", "StringNotEqualsIgnoreCase",
+	"StringLike", "StringNotLike",
+	"NumericEquals", "NumericNotEquals", "NumericLessThan", "NumericLessThanEquals",
+	"NumericGreaterThan", "NumericGreaterThanEquals",
+	"DateEquals", "DateNotEquals", "DateLessThan", "DateLessThanEquals",
+	"DateGreaterThan", "DateGreaterThanEquals",
+	"Bool", "IpAddress", "NotIpAddress", "ArnEquals", "ArnLike", "ArnNotEquals", "ArnNotLike", "Null",
+}
+
+var (
+	policyActionRegexp = regexp.MustCompile(`^(\*|s3:[A-Za-z*?]+)$`)
+	policyARNRegexp    = regexp.MustCompile(`^arn:aws:s3:::[a-z0-9*?][a-z0-9.\-*?]*(/.*)?$`)
+)
+
+// PolicyValidationError is returned by PolicyDocument.Validate and lists all the problems found
+type PolicyValidationError struct {
+	Problems []string
+}
+
+// Error implements interface error
+func (e PolicyValidationError) Error() string {
+	return "oos: invalid policy: " + strings.Join(e.Problems, "; ")
+}
+
+// Validate checks the policy locally: version, effects, actions, resource ARNs, principals and condition operators.
+//
+// error    it's nil if the policy is valid, otherwise it's a PolicyValidationError.
+func (doc PolicyDocument) Validate() error {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if doc.Version != "" && doc.Version != PolicyVersion && doc.Version != "2008-10-17" {
+		add("unknown version %q", doc.Version)
+	}
+	if len(doc.Statement) == 0 {
+		add("no statement")
+	}
+
+	sids := map[string]bool{}
+	for i, st := range doc.Statement {
+		name := fmt.Sprintf("statement %d", i)
+		if st.Sid != "" {
+			name = fmt.Sprintf("statement %q", st.Sid)
+			if sids[st.Sid] {
+				add("%s: duplicate Sid", name)
+			}
+			sids[st.Sid] = true
+		}
+
+		if st.Effect != PolicyEffectAllow && st.Effect != PolicyEffectDeny {
+			add("%s: effect must be Allow or Deny, got %q", name, st.Effect)
+		}
+
+		if (st.Principal == nil) == (st.NotPrincipal == nil) {
+			add("%s: exactly one of Principal and NotPrincipal is required", name)
+		}
+		for _, p := range []*Principal{st.Principal, st.NotPrincipal} {
+			if p != nil && !p.All && len(p.AWS) == 0 {
+				add("%s: empty principal", name)
+			}
+		}
+
+		if (len(st.Action) == 0) == (len(st.NotAction) == 0) {
+			add("%s: exactly one of Action and NotAction is required", name)
+		}
+		for _, action := range append(append(StringList{}, st.Action...), st.NotAction...) {
+			if !policyActionRegexp.MatchString(action) {
+				add("%s: invalid action %q", name, action)
+			}
+		}
+
+		if (len(st.Resource) == 0) == (len(st.NotResource) == 0) {
+			add("%s: exactly one of Resource and NotResource is required", name)
+		}
+		for _, res := range append(append(StringList{}, st.Resource...), st.NotResource...) {
+			if res != "*" && !policyARNRegexp.MatchString(res) {
+				add("%s: invalid resource ARN %q", name, res)
+			}
+		}
+
+		ops := make([]string, 0, len(st.Condition))
+		for op := range st.Condition {
+			ops = append(ops, op)
+		}
+		sort.Strings(ops)
+		for _, op := range ops {
+			kv := st.Condition[op]
+			if !isPolicyConditionOperator(op) {
+				add("%s: unknown condition operator %q", name, op)
+			}
+			if len(kv) == 0 {
+				add("%s: condition %s has no key", name, op)
+			}
+			for key, values := range kv {
+				if len(values) == 0 {
+					add("%s: condition %s %s has no value", name, op, key)
+				}
+				if strings.HasSuffix(policyConditionBase(op), "IpAddress") {
+					for _, v := range values {
+						if parsePolicyIP(v) == nil {
+							add("%s: condition %s %s: invalid IP %q", name, op, key, v)
+						}
+					}
+				}
+				if policyConditionBase(op) == "Bool" {
+					for _, v := range values {
+						if v != "true" && v != "false" {
+							add("%s: condition Bool %s: value must be true or false, got %q", name, key, v)
+						}
+					}
+				}
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return PolicyValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// policyConditionBase strips the set qualifier and the IfExists suffix of the operator
+func policyConditionBase(op string) string {
+	if i := strings.Index(op, ":"); i >= 0 {
+		op = op[i+1:]
+	}
+	return strings.TrimSuffix(op, "IfExists")
+}
+
+func isPolicyConditionOperator(op string) bool {
+	if i := strings.Index(op, ":"); i >= 0 {
+		if q := op[:i]; q != "ForAnyValue" && q != "ForAllValues" {
+			return false
+		}
+	}
+	return IsInRange(policyConditionBase(op), policyConditionOperators)
+}
+
+// parsePolicyIP parses the IP or the CIDR range
+func parsePolicyIP(v string) *net.IPNet {
+	if !strings.Contains(v, "/") {
+		ip := net.ParseIP(v)
+		if ip == nil {
+			return nil
+		}
+		if ip.To4() != nil {
+			return &net.IPNet{IP: ip.To4(), Mask: net.CIDRMask(32, 32)}
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}
+	}
+	_, ipNet, err := net.ParseCIDR(v)
+	if err != nil {
+		return nil
+	}
+	return ipNet
+}
+
+// SetBucketPolicyDocument validates the policy locally and sets it as the bucket's policy.
+//
+// bucketName    the bucket name.
+// doc    the policy document.
+//
+// error    it's nil if no error, otherwise it's an error object. It's a PolicyValidationError if the local validation fails.
+func (client Client) SetBucketPolicyDocument(bucketName string, doc PolicyDocument) error {
+	if err := doc.Validate(); err != nil {
+		return err
+	}
+	bs, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return client.SetBucketPolicy(bucketName, string(bs))
+}
+
+// GetBucketPolicyDocument gets the bucket's policy and parses it.
+//
+// bucketName    the bucket name.
+//
+// PolicyDocument    the policy, only valid when error is nil.
+// error    it's nil if no error, otherwise it's an error object.
+func (client Client) GetBucketPolicyDocument(bucketName string) (PolicyDocument, error) {
+	text, err := client.GetBucketPolicy(bucketName)
+	if err != nil {
+		return PolicyDocument{}, err
+	}
+	if strings.TrimSpace(text) == "" {
+		return PolicyDocument{}, errors.New("oos: the bucket policy is empty")
+	}
+	return ParsePolicyDocument(text)
+}
@@ -0,0 +1,345 @@
+package oos
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PolicyDecision is the result of a local policy evaluation
+type PolicyDecision string
+
+const (
+	// PolicyDecisionAllow means a policy statement or the ACL allows the request
+	PolicyDecisionAllow PolicyDecision = "Allow"
+
+	// PolicyDecisionDeny means a policy statement denies the request explicitly
+	PolicyDecisionDeny PolicyDecision = "Deny"
+
+	// PolicyDecisionNotApplicable means nothing allows the request, it's denied implicitly
+	PolicyDecisionNotApplicable PolicyDecision = "NotApplicable"
+)
+
+// ACL grantee group URIs
+const (
+	ACLGroupAllUsers           = "http://acs.amazonaws.com/groups/global/AllUsers"
+	ACLGroupAuthenticatedUsers = "http://acs.amazonaws.com/groups/global/AuthenticatedUsers"
+)
+
+// PolicyRequest describes the request to evaluate
+type PolicyRequest struct {
+	Principal       string            // Requester ARN or account ID, empty for anonymous requests
+	Action          string            // Action such as s3:GetObject
+	Bucket          string            // Bucket name
+	Key             string            // Object key, empty for bucket actions
+	Resource        string            // Resource ARN, it overrides Bucket and Key when it's set
+	SourceIP        string            // aws:SourceIp
+	Referer         string            // aws:Referer
+	SecureTransport bool              // aws:SecureTransport
+	UserAgent       string            // aws:UserAgent
+	CurrentTime     time.Time         // aws:CurrentTime, absent when it's zero
+	Context         map[string]string // Other condition keys such as s3:prefix
+}
+
+// PolicyEvaluation is the result of EvaluatePolicy
+type PolicyEvaluation struct {
+	Decision       PolicyDecision // Allow, Deny or NotApplicable
+	Statement      *Statement     // The statement which decided, nil when it's decided by the ACL or nothing matched
+	StatementIndex int            // Index of Statement in the document, -1 when Statement is nil
+	Reason         string         // Human readable reason
+}
+
+// EvaluatePolicy evaluates the request against the bucket policy and the bucket ACL locally.
+// An explicit Deny statement wins, then an Allow statement, then the ACL grants.
+// Nothing is sent to the server, so it can be used to review policy changes in unit tests.
+//
+// doc    the bucket policy.
+// acl    the bucket ACL, the result of GetBucketACL.
+// req    the request description.
+//
+// PolicyEvaluation    the decision and the statement which decided it.
+func EvaluatePolicy(doc PolicyDocument, acl GetBucketACLResult, req PolicyRequest) PolicyEvaluation {
+	resource := req.Resource
+	if resource == "" {
+		if req.Key == "" {
+			resource = BucketARN(req.Bucket)
+		} else {
+			resource = ObjectARN(req.Bucket, req.Key)
+		}
+	}
+
+	allowed := -1
+	for i := range doc.Statement {
+		st := &doc.Statement[i]
+		if !statementMatches(st, req, resource) {
+			continue
+		}
+		if st.Effect == PolicyEffectDeny {
+			return PolicyEvaluation{
+				Decision:       PolicyDecisionDeny,
+				Statement:      st,
+				StatementIndex: i,
+				Reason:         fmt.Sprintf("denied by %s", statementName(st, i)),
+			}
+		}
+		if st.Effect == PolicyEffectAllow && allowed < 0 {
+			allowed = i
+		}
+	}
+	if allowed >= 0 {
+		st := &doc.Statement[allowed]
+		return PolicyEvaluation{
+			Decision:       PolicyDecisionAllow,
+			Statement:      st,
+			StatementIndex: allowed,
+			Reason:         fmt.Sprintf("allowed by %s", statementName(st, allowed)),
+		}
+	}
+
+	if reason, ok := aclAllows(acl, req); ok {
+		return PolicyEvaluation{Decision: PolicyDecisionAllow, StatementIndex: -1, Reason: reason}
+	}
+	return PolicyEvaluation{
+		Decision:       PolicyDecisionNotApplicable,
+		StatementIndex: -1,
+		Reason:         "no statement or ACL grant matches, denied implicitly",
+	}
+}
+
+func statementName(st *Statement, i int) string {
+	if st.Sid != "" {
+		return fmt.Sprintf("statement %q", st.Sid)
+	}
+	return fmt.Sprintf("statement %d", i)
+}
+
+// statementMatches checks the principal, the action, the resource and the conditions of the statement
+func statementMatches(st *Statement, req PolicyRequest, resource string) bool {
+	if st.Principal != nil && !principalMatches(st.Principal, req.Principal) {
+		return false
+	}
+	if st.NotPrincipal != nil && principalMatches(st.NotPrincipal, req.Principal) {
+		return false
+	}
+	if len(st.Action) > 0 && !anyWildcardMatch(st.Action, req.Action, true) {
+		return false
+	}
+	if len(st.NotAction) > 0 && anyWildcardMatch(st.NotAction, req.Action, true) {
+		return false
+	}
+	if len(st.Resource) > 0 && !anyWildcardMatch(st.Resource, resource, false) {
+		return false
+	}
+	if len(st.NotResource) > 0 && anyWildcardMatch(st.NotResource, resource, false) {
+		return false
+	}
+	for op, kv := range st.Condition {
+		for key, values := range kv {
+			if !conditionMatches(op, key, values, req) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func principalMatches(p *Principal, principal string) bool {
+	if p.All {
+		return true
+	}
+	for _, arn := range p.AWS {
+		if arn == "*" {
+			return true
+		}
+		if principal == "" {
+			continue
+		}
+		if arn == principal || arn == "arn:aws:iam::"+principal+":root" {
+			return true
+		}
+	}
+	return false
+}
+
+func anyWildcardMatch(patterns []string, value string, ignoreCase bool) bool {
+	for _, pattern := range patterns {
+		if policyWildcardMatch(pattern, value, ignoreCase) {
+			return true
+		}
+	}
+	return false
+}
+
+// policyWildcardMatch matches the value with the pattern, "*" matches any sequence and "?" matches one character
+func policyWildcardMatch(pattern, value string, ignoreCase bool) bool {
+	if ignoreCase {
+		pattern, value = strings.ToLower(pattern), strings.ToLower(value)
+	}
+	p, v := []rune(pattern), []rune(value)
+	pi, vi, star, mark := 0, 0, -1, 0
+	for vi < len(v) {
+		switch {
+		case pi < len(p) && (p[pi] == '?' || p[pi] == v[vi]):
+			pi++
+			vi++
+		case pi < len(p) && p[pi] == '*':
+			star, mark = pi, vi
+			pi++
+		case star >= 0:
+			pi = star + 1
+			mark++
+			vi = mark
+		default:
+			return false
+		}
+	}
+	for pi < len(p) && p[pi] == '*' {
+		pi++
+	}
+	return pi == len(p)
+}
+
+// policyContextValue returns the value of the condition key in the request
+func policyContextValue(key string, req PolicyRequest) (string, bool) {
+	switch strings.ToLower(key) {
+	case strings.ToLower(PolicyKeySourceIP):
+		return req.SourceIP, req.SourceIP != ""
+	case strings.ToLower(PolicyKeyReferer):
+		return req.Referer, req.Referer != ""
+	case strings.ToLower(PolicyKeySecureTransport):
+		return strconv.FormatBool(req.SecureTransport), true
+	case strings.ToLower(PolicyKeyUserAgent):
+		return req.UserAgent, req.UserAgent != ""
+	case strings.ToLower(PolicyKeyCurrentTime):
+		if req.CurrentTime.IsZero() {
+			return "", false
+		}
+		return req.CurrentTime.UTC().Format(time.RFC3339), true
+	}
+	for k, v := range req.Context {
+		if strings.EqualFold(k, key) {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// conditionMatches evaluates one condition operator and key
+func conditionMatches(op, key string, values StringList, req PolicyRequest) bool {
+	base := policyConditionBase(op)
+	ifExists := strings.HasSuffix(op, "IfExists")
+	value, ok := policyContextValue(key, req)
+
+	if base == "Null" {
+		for _, v := range values {
+			if (v == "true") == !ok {
+				return true
+			}
+		}
+		return false
+	}
+
+	negated := strings.Contains(base, "Not")
+	if !ok {
+		return ifExists || negated
+	}
+
+	matched := false
+	for _, v := range values {
+		if conditionValueMatches(base, v, value) {
+			matched = true
+			break
+		}
+	}
+	if negated {
+		return !matched
+	}
+	return matched
+}
+
+// conditionValueMatches compares the request value with one policy value, negated operators are compared positively
+func conditionValueMatches(base, policyValue, value string) bool {
+	switch base {
+	case "StringEquals", "StringNotEquals", "ArnEquals", "ArnNotEquals":
+		return policyValue == value
+	case "StringEqualsIgnoreCase", "StringNotEqualsIgnoreCase":
+		return strings.EqualFold(policyValue, value)
+	case "StringLike", "StringNotLike", "ArnLike", "ArnNotLike":
+		return policyWildcardMatch(policyValue, value, false)
+	case "Bool":
+		return strings.EqualFold(policyValue, value)
+	case "IpAddress", "NotIpAddress":
+		ipNet := parsePolicyIP(policyValue)
+		ip := net.ParseIP(value)
+		return ipNet != nil && ip != nil && ipNet.Contains(ip)
+	}
+
+	if strings.HasPrefix(base, "Numeric") {
+		a, err1 := strconv.ParseFloat(value, 64)
+		b, err2 := strconv.ParseFloat(policyValue, 64)
+		if err1 != nil || err2 != nil {
+			return false
+		}
+		return compareOrdered(strings.TrimPrefix(base, "Numeric"), a, b)
+	}
+	if strings.HasPrefix(base, "Date") {
+		a, err1 := time.Parse(time.RFC3339, value)
+		b, err2 := time.Parse(time.RFC3339, policyValue)
+		if err1 != nil || err2 != nil {
+			return false
+		}
+		return compareOrdered(strings.TrimPrefix(base, "Date"), float64(a.Unix()), float64(b.Unix()))
+	}
+	return false
+}
+
+func compareOrdered(cmp string, a, b float64) bool {
+	switch cmp {
+	case "Equals", "NotEquals":
+		return a == b
+	case "LessThan":
+		return a < b
+	case "LessThanEquals":
+		return a <= b
+	case "GreaterThan":
+		return a > b
+	case "GreaterThanEquals":
+		return a >= b
+	}
+	return false
+}
+
+// aclReadActions are the actions granted by the READ permission
+var aclReadActions = []string{"s3:GetObject", "s3:ListBucket", "s3:ListBucketMultipartUploads"}
+
+// aclWriteActions are the actions granted by the WRITE permission
+var aclWriteActions = []string{"s3:PutObject", "s3:DeleteObject", "s3:AbortMultipartUpload", "s3:ListMultipartUploadParts"}
+
+// aclAllows checks whether the bucket ACL grants the request
+func aclAllows(acl GetBucketACLResult, req PolicyRequest) (string, bool) {
+	if req.Principal != "" && (req.Principal == acl.Owner.ID || req.Principal == acl.Owner.DisplayName) {
+		return "allowed as the bucket owner", true
+	}
+	for _, grant := range acl.GrantList {
+		if grant.GranteeURI != ACLGroupAllUsers && !(grant.GranteeURI == ACLGroupAuthenticatedUsers && req.Principal != "") {
+			continue
+		}
+		var actions []string
+		switch grant.Permission {
+		case "FULL_CONTROL":
+			return fmt.Sprintf("allowed by ACL grant FULL_CONTROL to %s", grant.GranteeURI), true
+		case "READ":
+			actions = aclReadActions
+		case "WRITE":
+			actions = aclWriteActions
+		}
+		for _, action := range actions {
+			if strings.EqualFold(action, req.Action) {
+				return fmt.Sprintf("allowed by ACL grant %s to %s", grant.Permission, grant.GranteeURI), true
+			}
+		}
+	}
+	return "", false
+}
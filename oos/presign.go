@@ -0,0 +1,189 @@
+package oos
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/teamssix/oos-go-sdk/signer"
+)
+
+// Signature versions of PresignedURLInfo
+const (
+	SignatureV2 = "V2"
+	SignatureV4 = "V4"
+)
+
+// PresignedURLInfo is the parsed URL signed by SignURL or the Presign functions
+type PresignedURLInfo struct {
+	URL               string            // The signed URL
+	Version           string            // Signature version, SignatureV2 or SignatureV4
+	Host              string            // Endpoint host of the URL
+	Bucket            string            // Bucket name, empty for the service-level URLs
+	Key               string            // Object key, empty for the bucket-level URLs
+	AccessKeyID       string            // Access key ID which signed the URL
+	SecurityToken     string            // STS token, if it's signed with the temporary credentials
+	SignedAt          time.Time         // Signing time, only for V4. V2 URLs don't carry it
+	Expires           time.Time         // Expiration time
+	Region            string            // V4 signing region
+	Service           string            // V4 signing service
+	SignedHeaders     []string          // V4 signed headers in lowercase, the clients must send the same values
+	SubResources      map[string]string // Signed subresources such as uploadId and partNumber
+	ResponseOverrides map[string]string // response-* parameters such as response-content-type
+	Signature         string            // The signature
+}
+
+// ParsePresignedURL parses the URL signed by SignURL or the Presign functions. The URL must be path-style,
+// such as http://oos-cn.ctyunapi.cn/bucket/key?..., which is the format of the client without the Cname option.
+//
+// signedURL    the signed URL.
+//
+// PresignedURLInfo    the parsed URL, only valid when error is nil.
+// error    it's nil if no error, otherwise it's an error object.
+func ParsePresignedURL(signedURL string) (PresignedURLInfo, error) {
+	var info PresignedURLInfo
+	uri, err := url.Parse(signedURL)
+	if err != nil {
+		return info, err
+	}
+	query := uri.Query()
+
+	info.URL = signedURL
+	info.Host = uri.Host
+	path := strings.TrimPrefix(uri.Path, "/")
+	if i := strings.Index(path, "/"); i >= 0 {
+		info.Bucket, info.Key = path[:i], path[i+1:]
+	} else {
+		info.Bucket = path
+	}
+
+	info.SubResources = map[string]string{}
+	info.ResponseOverrides = map[string]string{}
+	for k := range query {
+		if strings.HasPrefix(k, "response-") {
+			info.ResponseOverrides[k] = query.Get(k)
+			continue
+		}
+		for _, sk := range signer.SubResourcesV2 {
+			if k == sk {
+				info.SubResources[k] = query.Get(k)
+			}
+		}
+	}
+
+	switch {
+	case query.Get(HTTPParamXAmzAlgorithm) != "":
+		err = parsePresignedURLV4(&info, query)
+	case query.Get(HTTPParamSignature) != "":
+		err = parsePresignedURLV2(&info, query)
+	default:
+		err = errors.New("oos: the URL is not signed")
+	}
+	return info, err
+}
+
+func parsePresignedURLV4(info *PresignedURLInfo, query url.Values) error {
+	info.Version = SignatureV4
+	if alg := query.Get(HTTPParamXAmzAlgorithm); alg != signer.AlgorithmV4 {
+		return fmt.Errorf("oos: unsupported signature algorithm %q", alg)
+	}
+	credential := strings.Split(query.Get(HTTPParamXAmzCredential), "/")
+	if len(credential) != 5 || credential[4] != "aws4_request" {
+		return fmt.Errorf("oos: invalid %s %q", HTTPParamXAmzCredential, query.Get(HTTPParamXAmzCredential))
+	}
+	info.AccessKeyID, info.Region, info.Service = credential[0], credential[2], credential[3]
+
+	signedAt, err := time.Parse(signer.TimeFormatV4, query.Get(HTTPParamXAmzDate))
+	if err != nil {
+		return fmt.Errorf("oos: invalid %s %q", HTTPParamXAmzDate, query.Get(HTTPParamXAmzDate))
+	}
+	expires, err := strconv.ParseInt(query.Get(HTTPParamXAmzExpires), 10, 64)
+	if err != nil {
+		return fmt.Errorf("oos: invalid %s %q", HTTPParamXAmzExpires, query.Get(HTTPParamXAmzExpires))
+	}
+	info.SignedAt = signedAt
+	info.Expires = signedAt.Add(time.Duration(expires) * time.Second)
+
+	if headers := query.Get(HTTPParamXAmzSignedHeaders); headers != "" {
+		info.SignedHeaders = strings.Split(headers, ";")
+	}
+	info.SecurityToken = query.Get(HTTPParamSecurityToken)
+	if token := query.Get(signer.ParamSecurityTokenV4); token != "" {
+		info.SecurityToken = token
+	}
+	info.Signature = query.Get(HTTPParamXAmzSignature)
+	return nil
+}
+
+func parsePresignedURLV2(info *PresignedURLInfo, query url.Values) error {
+	info.Version = SignatureV2
+	info.AccessKeyID = query.Get(HTTPParamAWSAccessKeyID)
+	if info.AccessKeyID == "" {
+		return fmt.Errorf("oos: %s is missing", HTTPParamAWSAccessKeyID)
+	}
+	expires, err := strconv.ParseInt(query.Get(HTTPParamExpires), 10, 64)
+	if err != nil {
+		return fmt.Errorf("oos: invalid %s %q", HTTPParamExpires, query.Get(HTTPParamExpires))
+	}
+	info.Expires = time.Unix(expires, 0).UTC()
+	info.SecurityToken = query.Get(HTTPParamSecurityToken)
+	info.Signature = query.Get(HTTPParamSignature)
+	return nil
+}
+
+// IsExpired checks if the URL is expired at the time. Check it with a later time, such as now.Add(time.Minute),
+// to reject the URLs which are about to expire.
+func (info PresignedURLInfo) IsExpired(now time.Time) bool {
+	return !now.Before(info.Expires)
+}
+
+// TimeLeft returns the validity period left at the time, it's negative if the URL is expired.
+func (info PresignedURLInfo) TimeLeft(now time.Time) time.Duration {
+	return info.Expires.Sub(now)
+}
+
+// SignedMethod finds the HTTP method the URL is signed for, by checking the signature with the secret of the
+// access key. It only works for the URLs without the signed headers other than host, because their values
+// aren't in the URL. The expiration is not checked.
+//
+// accessKeySecret    the secret of the access key which signed the URL.
+//
+// HTTPMethod    the signed method, only valid when error is nil.
+// error    it's nil if the method is found, otherwise it's an error object.
+func (info PresignedURLInfo) SignedMethod(accessKeySecret string) (HTTPMethod, error) {
+	verifier := signer.NewVerifier(func(string) (string, error) { return accessKeySecret, nil })
+	verifier.Now = func() time.Time {
+		if info.Version == SignatureV4 {
+			return info.SignedAt
+		}
+		return info.Expires
+	}
+
+	methods := []HTTPMethod{HTTPGet, HTTPPut, HTTPHead, HTTPPost, HTTPDelete}
+	for _, method := range methods {
+		req, err := http.NewRequest(string(method), info.URL, nil)
+		if err != nil {
+			return "", err
+		}
+		if _, err = verifier.Verify(req); err == nil {
+			return method, nil
+		}
+	}
+
+	var headers []string
+	for _, h := range info.SignedHeaders {
+		if h != "host" {
+			headers = append(headers, h)
+		}
+	}
+	sort.Strings(headers)
+	if len(headers) > 0 {
+		return "", fmt.Errorf("oos: the signature doesn't match any method, the signed headers %s are unknown", strings.Join(headers, ";"))
+	}
+	return "", errors.New("oos: the signature doesn't match any method with the secret")
+}
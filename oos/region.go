@@ -0,0 +1,178 @@
+package oos
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// EndpointScope is the V4 signing region and service of an endpoint
+type EndpointScope struct {
+	Region  string // Signing region such as cn
+	Service string // Signing service, s3 if it's empty
+}
+
+// The endpoint-to-region resolver table, keyed by the endpoint host without the port.
+//
+// The hosts in the table are resolved first. The other hosts are resolved by the "oos-<region>[-<label>]"
+// host label rule, where the label is mapped to the service by the service mapping:
+//
+//	oos-cn.ctyunapi.cn               region cn, service s3
+//	oos-cn-iam.ctyunapi.cn           region cn, service sts
+//	oos-cn-cloudtrail.ctyunapi.cn    region cn, service cloudtrail
+//
+// Custom domains, IP endpoints, proxies and CNAMEs don't follow the rule. Add them with RegisterEndpoint,
+// or set the Region and SigningService options of the client.
+var (
+	endpointLock  sync.RWMutex
+	endpointTable = map[string]EndpointScope{
+		"oos-cn.ctyunapi.cn":            {Region: "cn", Service: "s3"},
+		"oos-cn-iam.ctyunapi.cn":        {Region: "cn", Service: "sts"},
+		"oos-cn-cloudtrail.ctyunapi.cn": {Region: "cn", Service: "cloudtrail"},
+	}
+)
+
+// DefaultServiceMapping maps the label of the endpoint host, the part after the region in "oos-<region>-<label>",
+// to the V4 signing service. The hosts without label are signed for s3. Use the ServiceMapping option to change it.
+var DefaultServiceMapping = map[string]string{
+	"iam":        "sts",
+	"cloudtrail": "cloudtrail",
+}
+
+// RegisterEndpoint adds the endpoint to the resolver table, or replaces it.
+//
+// host    the endpoint host such as oss.example.com or 10.0.0.1, the scheme and port are ignored.
+// region    the signing region.
+// service    the signing service, s3 if it's empty.
+func RegisterEndpoint(host, region, service string) {
+	endpointLock.Lock()
+	defer endpointLock.Unlock()
+	endpointTable[endpointHost(host)] = EndpointScope{Region: region, Service: service}
+}
+
+// ResolveEndpoint resolves the signing region and service of the endpoint by the resolver table,
+// then by the host label rule.
+//
+// endpoint    the endpoint such as https://oos-cn.ctyunapi.cn or oos-cn-iam.ctyunapi.cn:443.
+// serviceMapping    maps the host label to the service, DefaultServiceMapping is used if it's nil.
+//
+// EndpointScope    the region and service. Only the region is set if the host label is unknown.
+// error    it's nil if the region is resolved, otherwise it's an error object.
+func ResolveEndpoint(endpoint string, serviceMapping map[string]string) (EndpointScope, error) {
+	host := endpointHost(endpoint)
+
+	endpointLock.RLock()
+	scope, ok := endpointTable[host]
+	endpointLock.RUnlock()
+	if ok {
+		if scope.Service == "" {
+			scope.Service = "s3"
+		}
+		return scope, nil
+	}
+
+	if serviceMapping == nil {
+		serviceMapping = DefaultServiceMapping
+	}
+	for _, v := range strings.Split(host, ".") {
+		if !strings.HasPrefix(v, "oos-") {
+			continue
+		}
+		labels := strings.SplitN(v, "-", 3)
+		if len(labels) < 2 || labels[1] == "" {
+			break
+		}
+		scope = EndpointScope{Region: labels[1], Service: "s3"}
+		if len(labels) == 3 {
+			service, ok := serviceMapping[labels[2]]
+			if !ok {
+				return EndpointScope{Region: scope.Region}, fmt.Errorf("oos: unknown service label %q of endpoint %q", labels[2], endpoint)
+			}
+			scope.Service = service
+		}
+		return scope, nil
+	}
+	return EndpointScope{}, fmt.Errorf("oos: can't resolve the signing region of endpoint %q, set it with the Region option or RegisterEndpoint", endpoint)
+}
+
+// endpointOfRegion returns the host of the s3 endpoint of the signing region in the resolver table, the first one
+// in order if the region has several.
+func endpointOfRegion(region string) (string, bool) {
+	endpointLock.RLock()
+	defer endpointLock.RUnlock()
+	var hosts []string
+	for host, scope := range endpointTable {
+		if strings.EqualFold(scope.Region, region) && (scope.Service == "" || scope.Service == "s3") {
+			hosts = append(hosts, host)
+		}
+	}
+	if len(hosts) == 0 {
+		return "", false
+	}
+	sort.Strings(hosts)
+	return hosts[0], true
+}
+
+// endpointHost strips the scheme, the path and the port of the endpoint
+func endpointHost(endpoint string) string {
+	host := endpoint
+	if i := strings.Index(host, "://"); i >= 0 {
+		host = host[i+3:]
+	}
+	if i := strings.Index(host, "/"); i >= 0 {
+		host = host[:i]
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.ToLower(strings.Trim(host, "[]"))
+}
+
+// signingScope returns the V4 signing region and service. The Region and SigningService options win over
+// the endpoint, an unresolvable part is empty.
+func (config *Config) signingScope() (string, string) {
+	region, service := config.Region, config.SigningService
+	if region == "" || service == "" {
+		scope, _ := ResolveEndpoint(config.Endpoint, config.ServiceMapping)
+		if region == "" {
+			region = scope.Region
+		}
+		if service == "" {
+			service = scope.Service
+		}
+		if service == "" && config.Region != "" {
+			service = "s3"
+		}
+	}
+	return region, service
+}
+
+// Validate checks the configuration before the first request. With V4 signature, it reports the region or
+// the service which can't be resolved from the endpoint and isn't set by the Region or SigningService option.
+//
+// error    it's nil if the configuration is valid, otherwise it's an error object.
+func (config *Config) Validate() error {
+	if config.Endpoint == "" {
+		return errors.New("oos: endpoint is empty")
+	}
+	if (config.AccessKeyID == "") != (config.AccessKeySecret == "") {
+		return errors.New("oos: access key id and access key secret must be both set or both empty")
+	}
+	if config.IsStreamingSign && config.StreamingChunk < 8*1024 {
+		return errors.New("oos: the streaming chunk size must be at least 8KB")
+	}
+	if !config.IsV4Sign {
+		return nil
+	}
+	if config.Region != "" && config.SigningService != "" {
+		return nil
+	}
+	scope, err := ResolveEndpoint(config.Endpoint, config.ServiceMapping)
+	if err != nil && config.Region == "" && !(scope.Region != "" && config.SigningService != "") {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,259 @@
+package oos
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultRegionCacheTTL is the default TTL of the bucket locations cached by RegionRouter
+const DefaultRegionCacheTTL = time.Hour
+
+// RegionEndpoint is the endpoint serving the buckets of a metadata region
+type RegionEndpoint struct {
+	Endpoint string // The endpoint such as https://oos-cn.ctyunapi.cn
+	Region   string // The V4 signing region, it's resolved from the endpoint if it's empty
+}
+
+// RegionRouter sends the requests of each bucket to the endpoint of its metadata region. The MetaLocation of the
+// bucket is got with GetBucketLocation from the default endpoint once, and cached for TTL. The endpoint of the
+// region is the one added by AddRegionEndpoint, otherwise the s3 endpoint of the same signing region in the resolver
+// table, check out RegisterEndpoint. The buckets of the default endpoint's region, or without location, use the
+// default client, and the other regions without endpoint are errors.
+//
+// The router is safe for concurrent use.
+type RegionRouter struct {
+	TTL time.Duration    // The time the bucket location is cached, DefaultRegionCacheTTL if it's 0
+	Now func() time.Time // The clock, time.Now if it's nil
+
+	client          *Client
+	accessKeyID     string
+	accessKeySecret string
+	options         []ClientOption
+
+	mu        sync.Mutex
+	endpoints map[string]RegionEndpoint // MetaLocation to the endpoint
+	clients   map[string]*Client        // MetaLocation to the client of the endpoint
+	buckets   map[string]bucketRegion   // Bucket name to the cached location
+}
+
+// bucketRegion is the cached location of the bucket
+type bucketRegion struct {
+	location string
+	expires  time.Time
+}
+
+// NewRegionRouter creates the router with the default client.
+//
+// endpoint    the default endpoint, it serves GetBucketLocation and the buckets of its region.
+// accessKeyID    access key Id.
+// accessKeySecret    access key secret.
+// options    the client options, they're applied to the clients of all the regions.
+//
+// RegionRouter    the router, valid when error is nil.
+// error    it's nil if no error, otherwise it's an error object.
+func NewRegionRouter(endpoint, accessKeyID, accessKeySecret string, options ...ClientOption) (*RegionRouter, error) {
+	client, err := New(endpoint, accessKeyID, accessKeySecret, options...)
+	if err != nil {
+		return nil, err
+	}
+	return &RegionRouter{
+		client:          client,
+		accessKeyID:     accessKeyID,
+		accessKeySecret: accessKeySecret,
+		options:         options,
+		endpoints:       map[string]RegionEndpoint{},
+		clients:         map[string]*Client{},
+		buckets:         map[string]bucketRegion{},
+	}, nil
+}
+
+// AddRegionEndpoint routes the buckets of the metadata region to the endpoint, or replaces the endpoint.
+//
+// location    the metadata region such as ChengDu, the MetaLocation of GetBucketLocation.
+// endpoint    the endpoint of the region.
+// region    the V4 signing region of the endpoint, it's resolved from the endpoint if it's empty.
+//
+// error    it's nil if no error, otherwise it's an error object.
+func (router *RegionRouter) AddRegionEndpoint(location, endpoint, region string) error {
+	if location == "" {
+		return errors.New("the parameter is invalid: location is empty")
+	}
+	if endpoint == "" {
+		return errors.New("the parameter is invalid: endpoint is empty")
+	}
+	router.mu.Lock()
+	defer router.mu.Unlock()
+	router.endpoints[location] = RegionEndpoint{Endpoint: endpoint, Region: region}
+	delete(router.clients, location)
+	return nil
+}
+
+// Client gets the client of the bucket's metadata region.
+//
+// bucketName    the bucket name.
+//
+// Client    the client, valid when error is nil.
+// error    it's nil if no error, otherwise it's an error object.
+func (router *RegionRouter) Client(bucketName string) (*Client, error) {
+	location, err := router.Location(bucketName)
+	if err != nil {
+		return nil, err
+	}
+	return router.regionClient(location)
+}
+
+// Bucket gets the bucket instance bound to the endpoint of its metadata region.
+//
+// bucketName    the bucket name.
+//
+// Object    the bucket object, valid when error is nil.
+// error    it's nil if no error, otherwise it's an error object.
+func (router *RegionRouter) Bucket(bucketName string) (*Object, error) {
+	client, err := router.Client(bucketName)
+	if err != nil {
+		return nil, err
+	}
+	return client.Bucket(bucketName)
+}
+
+// Location gets the MetaLocation of the bucket from the cache, or with GetBucketLocation if it isn't cached or
+// it has expired.
+//
+// bucketName    the bucket name.
+//
+// string    the metadata region of the bucket, valid when error is nil.
+// error    it's nil if no error, otherwise it's an error object.
+func (router *RegionRouter) Location(bucketName string) (string, error) {
+	if bucketName == "" {
+		return "", errors.New("the parameter is invalid: bucket's name is empty")
+	}
+	now := router.now()
+	router.mu.Lock()
+	cached, ok := router.buckets[bucketName]
+	router.mu.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.location, nil
+	}
+	return router.refresh(bucketName)
+}
+
+// Invalidate removes the cached location of the bucket, the next request gets it again.
+//
+// bucketName    the bucket name.
+func (router *RegionRouter) Invalidate(bucketName string) {
+	router.mu.Lock()
+	defer router.mu.Unlock()
+	delete(router.buckets, bucketName)
+}
+
+// Do runs fn with the bucket bound to the endpoint of its metadata region. If fn returns the redirect error, checked
+// by IsRedirectError, the location of the bucket is got again, and fn is retried once if the bucket has moved to
+// the other region.
+//
+// bucketName    the bucket name.
+// fn    the requests of the bucket, such as the bucket operations of bucket.Bucket and the object operations.
+//
+// error    it's nil if no error, otherwise it's the error of fn or of the location lookup.
+func (router *RegionRouter) Do(bucketName string, fn func(bucket *Object) error) error {
+	location, err := router.Location(bucketName)
+	if err != nil {
+		return err
+	}
+	for retried := false; ; retried = true {
+		client, err := router.regionClient(location)
+		if err != nil {
+			return err
+		}
+		bucket, err := client.Bucket(bucketName)
+		if err != nil {
+			return err
+		}
+		err = fn(bucket)
+		if retried || !IsRedirectError(err) {
+			return err
+		}
+
+		newLocation, errLocation := router.refresh(bucketName)
+		if errLocation != nil || newLocation == location {
+			return err
+		}
+		location = newLocation
+	}
+}
+
+// IsRedirectError checks if the error is the redirect of the request to the other endpoint, such as
+// PermanentRedirect when the bucket isn't in the region of the endpoint.
+func IsRedirectError(err error) bool {
+	srvErr, ok := err.(ServiceError)
+	if !ok {
+		return false
+	}
+	switch srvErr.Code {
+	case "PermanentRedirect", "TemporaryRedirect", "Redirect":
+		return true
+	}
+	return srvErr.StatusCode == http.StatusMovedPermanently || srvErr.StatusCode == http.StatusTemporaryRedirect
+}
+
+// refresh gets the location of the bucket from the default endpoint and caches it
+func (router *RegionRouter) refresh(bucketName string) (string, error) {
+	out, err := router.client.GetBucketLocation(bucketName)
+	if err != nil {
+		return "", err
+	}
+	ttl := router.TTL
+	if ttl == 0 {
+		ttl = DefaultRegionCacheTTL
+	}
+	router.mu.Lock()
+	defer router.mu.Unlock()
+	router.buckets[bucketName] = bucketRegion{location: out.MetaLocation, expires: router.now().Add(ttl)}
+	return out.MetaLocation, nil
+}
+
+// regionClient returns the client of the region, it's created on the first use. The signing region is set to the
+// region of the endpoint, so the V4 scope matches the endpoint.
+func (router *RegionRouter) regionClient(location string) (*Client, error) {
+	router.mu.Lock()
+	defer router.mu.Unlock()
+	if client, ok := router.clients[location]; ok {
+		return client, nil
+	}
+	endpoint, ok := router.endpoints[location]
+	if !ok {
+		region, _ := router.client.Config.signingScope()
+		if location == "" || strings.EqualFold(location, region) {
+			return router.client, nil
+		}
+		host, ok := endpointOfRegion(location)
+		if !ok {
+			return nil, fmt.Errorf("oos: no endpoint for the bucket location %s, add it with AddRegionEndpoint", location)
+		}
+		endpoint = RegionEndpoint{Endpoint: router.client.Conn.url.Scheme + "://" + host}
+	}
+
+	options := router.options
+	if endpoint.Region != "" {
+		options = append(options[:len(options):len(options)], Region(endpoint.Region))
+	} else {
+		// The Region option of the default client is for its endpoint, the region is resolved from this one
+		options = append(options[:len(options):len(options)], Region(""))
+	}
+	client, err := New(endpoint.Endpoint, router.accessKeyID, router.accessKeySecret, options...)
+	if err != nil {
+		return nil, err
+	}
+	router.clients[location] = client
+	return client, nil
+}
+
+func (router *RegionRouter) now() time.Time {
+	if router.Now != nil {
+		return router.Now()
+	}
+	return time.Now()
+}
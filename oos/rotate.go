@@ -0,0 +1,300 @@
+package oos
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// CredentialsSink receives the new access key of RotateAccessKey, such as writing it to a file or a secret store.
+type CredentialsSink interface {
+	// Store saves the new key. The secret is only available in the run which creates the key,
+	// so the rotation doesn't continue until Store succeeds.
+	Store(key AcessKeyInfo) error
+}
+
+// CredentialsSinkFunc adapts a function to CredentialsSink
+type CredentialsSinkFunc func(key AcessKeyInfo) error
+
+// Store implements CredentialsSink
+func (f CredentialsSinkFunc) Store(key AcessKeyInfo) error {
+	return f(key)
+}
+
+// FileCredentialsSink writes the new access key to a JSON file readable only by the owner:
+//
+//	{"access_key_id": "...", "access_key_secret": "..."}
+//
+// The file is replaced atomically, so the readers never see a partial key.
+type FileCredentialsSink struct {
+	Path string // The file path
+}
+
+// Store implements CredentialsSink
+func (s FileCredentialsSink) Store(key AcessKeyInfo) error {
+	data, err := json.Marshal(map[string]string{
+		"access_key_id":     key.AccessKeyId,
+		"access_key_secret": key.SecretAccessKey,
+	})
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(s.Path), filepath.Base(s.Path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.Path)
+}
+
+// RotationStep is the next step of the access key rotation
+type RotationStep string
+
+// The steps of RotateAccessKey, in order
+const (
+	RotationStepCreate     RotationStep = "create"     // Create the new key
+	RotationStepStore      RotationStep = "store"      // Hand the new key to the sink
+	RotationStepDeactivate RotationStep = "deactivate" // Deactivate the old key once it's idle
+	RotationStepDelete     RotationStep = "delete"     // Delete the old key after the grace period
+	RotationStepDone       RotationStep = "done"       // The rotation is completed
+)
+
+// DefaultRotationIdlePeriod is the default IdlePeriod of AccessKeyRotation
+const DefaultRotationIdlePeriod = 24 * time.Hour
+
+// AccessKeyRotation is the config of RotateAccessKey
+type AccessKeyRotation struct {
+	OldAccessKeyId    string            // The key to rotate
+	UserName          string            // The IAM user of the key, the caller if it's empty
+	Sink              CredentialsSink   // Receives the new key, it's required
+	Store             CheckpointStorage // Persists the rotation state, the local file store if it's nil
+	StateKey          string            // The key of the state in Store, "<OldAccessKeyId>.rotate" if it's empty
+	IdlePeriod        time.Duration     // The old key must be unused for the period before it's deactivated, 24 hours if it's 0
+	DeleteAfter       time.Duration     // The grace period between the deactivation and the deletion
+	RequireNewKeyUsed bool              // Don't deactivate the old key until the new key has been used
+	Now               func() time.Time  // The clock, time.Now if it's nil
+}
+
+// AccessKeyRotationState is the persisted state of RotateAccessKey
+type AccessKeyRotationState struct {
+	Step           RotationStep `json:"step"`           // The next step
+	UserName       string       `json:"user_name"`      // The IAM user of the key
+	OldAccessKeyId string       `json:"old_access_key"` // The key to rotate
+	OldIsPrimary   bool         `json:"old_is_primary"` // The old key is primary, the new key is made primary too
+	NewAccessKeyId string       `json:"new_access_key"` // The new key, set by the create step
+	StartedAt      time.Time    `json:"started_at"`     // Time the rotation started
+	StoredAt       time.Time    `json:"stored_at"`      // Time the new key was stored by the sink
+	DeactivatedAt  time.Time    `json:"deactivated_at"` // Time the old key was deactivated
+	CompletedAt    time.Time    `json:"completed_at"`   // Time the old key was deleted
+}
+
+// RotationPendingError is returned by RotateAccessKey when the next step can't run yet. Run the rotation again later,
+// it resumes from the persisted state.
+type RotationPendingError struct {
+	Step    RotationStep // The blocked step
+	Reason  string       // Why the step is blocked
+	RetryAt time.Time    // The earliest time the step may run, it's zero if it's unknown
+}
+
+// Error implements error
+func (e *RotationPendingError) Error() string {
+	msg := fmt.Sprintf("oos: access key rotation step %s is pending: %s", e.Step, e.Reason)
+	if !e.RetryAt.IsZero() {
+		msg += ", retry after " + e.RetryAt.Format(time.RFC3339)
+	}
+	return msg
+}
+
+// RotateAccessKey rotates the access key in resumable steps: create the new key, hand it to the sink, deactivate the old
+// key once it's idle, then delete it after the grace period. Each step is persisted in the store, so the rotation is run
+// repeatedly, such as by a cron job, until the returned state is RotationStepDone. The steps which can't run yet return
+// *RotationPendingError, such as the deactivation while the old key shows recent use after the deployment of the new key.
+//
+// The last use of the old key includes the calls of the client, so the deactivation must be run by the client of
+// another key, such as the new key when the rotated key is the caller's own key.
+//
+// rotation    the rotation config.
+//
+// AccessKeyRotationState    the state after the run, it's valid when error is nil or *RotationPendingError.
+// error    it's nil if the rotation is completed, otherwise it's an error object.
+func (client Client) RotateAccessKey(rotation AccessKeyRotation) (AccessKeyRotationState, error) {
+	var state AccessKeyRotationState
+	if rotation.OldAccessKeyId == "" || rotation.Sink == nil {
+		return state, errors.New("the parameter is invalid: OldAccessKeyId and Sink are required")
+	}
+	if rotation.IdlePeriod < 0 || rotation.DeleteAfter < 0 {
+		return state, errors.New("the parameter is invalid: IdlePeriod and DeleteAfter can't be negative")
+	}
+	now := rotation.Now
+	if now == nil {
+		now = time.Now
+	}
+	store := rotation.Store
+	if store == nil {
+		store = defaultCheckpointStore
+	}
+	if rotation.IdlePeriod == 0 {
+		rotation.IdlePeriod = DefaultRotationIdlePeriod
+	}
+	stateKey := rotation.StateKey
+	if stateKey == "" {
+		stateKey = rotation.OldAccessKeyId + ".rotate"
+	}
+
+	err := loadCheckpoint(store, stateKey, &state)
+	if err == ErrCheckpointNotFound {
+		state = AccessKeyRotationState{Step: RotationStepCreate, UserName: rotation.UserName,
+			OldAccessKeyId: rotation.OldAccessKeyId, StartedAt: now()}
+	} else if err != nil {
+		return state, err
+	} else if state.OldAccessKeyId != rotation.OldAccessKeyId {
+		return state, fmt.Errorf("oos: the rotation state %s is for the access key %s", stateKey, state.OldAccessKeyId)
+	}
+
+	save := func() error {
+		data, err := json.Marshal(state)
+		if err != nil {
+			return err
+		}
+		return store.Save(stateKey, data)
+	}
+
+	var newKey AcessKeyInfo
+	for {
+		switch state.Step {
+		case RotationStepCreate:
+			old, err := client.findAccessKey(state.UserName, state.OldAccessKeyId)
+			if err != nil {
+				return state, err
+			}
+			out, err := client.CreateAccessKey(state.UserName)
+			if err != nil {
+				return state, err
+			}
+			newKey = out.CreateAccessKeyResult.AcessKey
+			state.OldIsPrimary = old.IsPrimary
+			state.NewAccessKeyId = newKey.AccessKeyId
+			state.Step = RotationStepStore
+
+		case RotationStepStore:
+			if newKey.SecretAccessKey == "" {
+				// The run which created the key failed before the sink stored it, and the secret is lost
+				if _, err = client.DeleteAccessKey(state.NewAccessKeyId, state.UserName); err != nil && !isNoSuchEntity(err) {
+					return state, err
+				}
+				state.NewAccessKeyId = ""
+				state.Step = RotationStepCreate
+				break
+			}
+			if err = rotation.Sink.Store(newKey); err != nil {
+				return state, err
+			}
+			state.StoredAt = now()
+			state.Step = RotationStepDeactivate
+
+		case RotationStepDeactivate:
+			if client.Config.AccessKeyID == state.OldAccessKeyId {
+				return state, errors.New("oos: the client is signed by the access key to rotate, " +
+					"resume the rotation with the client of the new access key")
+			}
+			if err = client.checkRotationIdle(rotation, state, now()); err != nil {
+				return state, err
+			}
+			if state.OldIsPrimary {
+				if err = client.UpdateAccessKeyPrimary(state.NewAccessKeyId, true); err != nil {
+					return state, err
+				}
+			}
+			if err = client.UpdateAccessKey(state.OldAccessKeyId, false); err != nil {
+				return state, err
+			}
+			state.DeactivatedAt = now()
+			state.Step = RotationStepDelete
+
+		case RotationStepDelete:
+			if retryAt := state.DeactivatedAt.Add(rotation.DeleteAfter); now().Before(retryAt) {
+				return state, &RotationPendingError{Step: state.Step, Reason: "the grace period after the deactivation isn't over",
+					RetryAt: retryAt}
+			}
+			if _, err = client.DeleteAccessKey(state.OldAccessKeyId, state.UserName); err != nil && !isNoSuchEntity(err) {
+				return state, err
+			}
+			state.CompletedAt = now()
+			state.Step = RotationStepDone
+			return state, store.Delete(stateKey)
+
+		case RotationStepDone:
+			return state, nil
+
+		default:
+			return state, fmt.Errorf("oos: unknown rotation step %q in %s", state.Step, stateKey)
+		}
+
+		if err = save(); err != nil {
+			return state, err
+		}
+	}
+}
+
+// checkRotationIdle checks the old key is idle and the new key is used if it's required
+func (client Client) checkRotationIdle(rotation AccessKeyRotation, state AccessKeyRotationState, now time.Time) error {
+	if rotation.RequireNewKeyUsed {
+		out, err := client.GetAccessKeyLastUsed(state.NewAccessKeyId)
+		if err != nil {
+			return err
+		}
+		if out.GetAccessKeyLastUsedResult.LastUsedDate == nil {
+			return &RotationPendingError{Step: state.Step, Reason: "the new access key hasn't been used"}
+		}
+	}
+
+	out, err := client.GetAccessKeyLastUsed(state.OldAccessKeyId)
+	if err != nil {
+		return err
+	}
+	lastUsed := out.GetAccessKeyLastUsedResult.LastUsedDate
+	if lastUsed == nil || now.Sub(*lastUsed) >= rotation.IdlePeriod {
+		return nil
+	}
+	return &RotationPendingError{Step: state.Step, Reason: "the old access key was used at " + lastUsed.Format(time.RFC3339),
+		RetryAt: lastUsed.Add(rotation.IdlePeriod)}
+}
+
+// findAccessKey finds the access key of the user in all pages
+func (client Client) findAccessKey(userName, accessKeyId string) (member, error) {
+	marker := ""
+	for {
+		out, err := client.ListAccessKey(100, marker, userName)
+		if err != nil {
+			return member{}, err
+		}
+		result := out.ListAccessKeysResult
+		for _, m := range result.MemberList {
+			if m.AccessKeyId == accessKeyId {
+				return m, nil
+			}
+		}
+		if truncated, _ := strconv.ParseBool(result.IsTruncated); !truncated || result.Marker == "" {
+			return member{}, fmt.Errorf("oos: access key %s not found", accessKeyId)
+		}
+		marker = result.Marker
+	}
+}
+
+// isNoSuchEntity checks if the error is the not found error of IAM
+func isNoSuchEntity(err error) bool {
+	serr, ok := err.(ServiceError)
+	return ok && (serr.Code == "NoSuchEntity" || serr.StatusCode == 404)
+}
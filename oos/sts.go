@@ -0,0 +1,121 @@
+package oos
+
+import (
+	"encoding/xml"
+	"errors"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Credentials defines the temporary credentials issued by STS
+type Credentials struct {
+	AccessKeyId     string    `xml:"AccessKeyId"`     // Temporary access key ID
+	SecretAccessKey string    `xml:"SecretAccessKey"` // Temporary access key secret
+	SessionToken    string    `xml:"SessionToken"`    // Security token, sent with the requests signed by the key
+	Expiration      time.Time `xml:"Expiration"`      // Expiration time of the credentials
+}
+
+// AssumedRoleUser defines the identity of the assumed role session
+type AssumedRoleUser struct {
+	Arn           string `xml:"Arn"`           // Arn of the role session, used in the policies
+	AssumedRoleId string `xml:"AssumedRoleId"` // Unique ID of the role session
+}
+
+// GetSessionTokenResponse defines the response of GetSessionToken
+type GetSessionTokenResponse struct {
+	XMLName          xml.Name         `xml:"GetSessionTokenResponse"`
+	Credentials      Credentials      `xml:"GetSessionTokenResult>Credentials"`
+	ResponseMetadata ResponseMetadata `xml:"ResponseMetadata"`
+}
+
+// AssumeRoleResponse defines the response of AssumeRole
+type AssumeRoleResponse struct {
+	XMLName          xml.Name         `xml:"AssumeRoleResponse"`
+	Credentials      Credentials      `xml:"AssumeRoleResult>Credentials"`
+	AssumedRoleUser  AssumedRoleUser  `xml:"AssumeRoleResult>AssumedRoleUser"`
+	PackedPolicySize int              `xml:"AssumeRoleResult>PackedPolicySize"`
+	ResponseMetadata ResponseMetadata `xml:"ResponseMetadata"`
+}
+
+// GetSessionToken gets the temporary credentials of the caller. The client must be created with the IAM endpoint
+// such as https://oos-cn-iam.ctyunapi.cn and the long-term access key.
+//
+// durationSeconds    the validity period of the credentials in seconds, the service default if it's 0.
+//
+// GetSessionTokenResponse    the credentials, only valid when error is nil.
+// error    it's nil if no error, otherwise it's an error object.
+func (client Client) GetSessionToken(durationSeconds int64) (GetSessionTokenResponse, error) {
+	var out GetSessionTokenResponse
+	if durationSeconds < 0 {
+		return out, errors.New("the parameter is invalid: durationSeconds is negative")
+	}
+
+	form := stsForm(GET_SESSION_TOKEN)
+	setSTSDuration(form, durationSeconds)
+	err := client.doIAM(form, &out)
+	return out, err
+}
+
+// AssumeRole gets the temporary credentials of the role. The client must be created with the IAM endpoint
+// and the access key of the user which is allowed to assume the role.
+//
+// roleArn    the Arn of the role.
+// sessionName    the name of the role session, it identifies the caller in the logs.
+// durationSeconds    the validity period of the credentials in seconds, the service default if it's 0.
+// policy    the policy in JSON which limits the permissions of the session further, it's optional.
+//
+// AssumeRoleResponse    the credentials and the session identity, only valid when error is nil.
+// error    it's nil if no error, otherwise it's an error object.
+func (client Client) AssumeRole(roleArn, sessionName string, durationSeconds int64, policy string) (AssumeRoleResponse, error) {
+	var out AssumeRoleResponse
+	if roleArn == "" || sessionName == "" {
+		return out, errors.New("the parameter is invalid: role arn or session name is empty")
+	}
+	if durationSeconds < 0 {
+		return out, errors.New("the parameter is invalid: durationSeconds is negative")
+	}
+
+	form := stsForm(ASSUME_ROLE)
+	form.Set(ROLE_ARN, roleArn)
+	form.Set(ROLE_SESSION_NAME, sessionName)
+	setSTSDuration(form, durationSeconds)
+	setIAMParam(form, STS_POLICY, policy)
+	err := client.doIAM(form, &out)
+	return out, err
+}
+
+// NewClient creates the client signed by the temporary credentials.
+//
+// endpoint    the endpoint such as https://oos-cn.ctyunapi.cn.
+// options    the client options, the SecurityToken option is set by the credentials.
+//
+// *Client    the client, only valid when error is nil.
+// error    it's nil if no error, otherwise it's an error object.
+func (creds Credentials) NewClient(endpoint string, options ...ClientOption) (*Client, error) {
+	if creds.AccessKeyId == "" || creds.SecretAccessKey == "" {
+		return nil, errors.New("the parameter is invalid: the credentials are empty")
+	}
+	options = append(options, SecurityToken(creds.SessionToken))
+	return New(endpoint, creds.AccessKeyId, creds.SecretAccessKey, options...)
+}
+
+// IsExpired checks if the credentials are expired at the time. Check it with a later time, such as
+// now.Add(5 * time.Minute), to refresh the credentials before they expire.
+func (creds Credentials) IsExpired(now time.Time) bool {
+	return !now.Before(creds.Expiration)
+}
+
+// stsForm returns the form of the STS action with the API version
+func stsForm(action string) url.Values {
+	form := url.Values{}
+	form.Set(ACCESS_KEY_ACTION, action)
+	form.Set(VERSION, VERSION_STS)
+	return form
+}
+
+func setSTSDuration(form url.Values, durationSeconds int64) {
+	if durationSeconds > 0 {
+		form.Set(DURATION_SECONDS, strconv.FormatInt(durationSeconds, 10))
+	}
+}
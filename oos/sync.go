@@ -0,0 +1,539 @@
+package oos
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SyncOptions defines the options of SyncUp and SyncDown
+type SyncOptions struct {
+	Include    []string // Glob patterns of the relative paths to sync. Empty means everything. "*" stops at "/", "**" does not.
+	Exclude    []string // Glob patterns of the relative paths to skip. Exclude wins over Include.
+	Delete     bool     // Delete the destination entries which don't exist in the source.
+	DryRun     bool     // Only build the plan, nothing is transferred or deleted.
+	CompareMD5 bool     // Compare the content MD5 instead of the size and the modified time.
+	Routines   int      // Concurrent transfers. By default it's 1.
+	PartSize   int64    // Files larger than PartSize are transferred by UploadFile/DownloadFile. By default it's 100MB.
+	Options    []Option // Options passed to every upload or download, such as ObjectACL or Progress.
+}
+
+// SyncActionType defines the action of a sync entry
+type SyncActionType string
+
+const (
+	// SyncUpload uploads the local file to the object
+	SyncUpload SyncActionType = "upload"
+
+	// SyncDownload downloads the object to the local file
+	SyncDownload SyncActionType = "download"
+
+	// SyncDeleteObject deletes the object which has no local file
+	SyncDeleteObject SyncActionType = "delete-object"
+
+	// SyncDeleteFile deletes the local file which has no object
+	SyncDeleteFile SyncActionType = "delete-file"
+)
+
+// SyncAction is one entry of the sync plan
+type SyncAction struct {
+	Type      SyncActionType // The action
+	Key       string         // Object key
+	LocalPath string         // Local file path
+	Size      int64          // Bytes to transfer, 0 for deletions
+	Reason    string         // Why the entry differs: new, size, modified, md5 or stale
+}
+
+// SyncFailure is an action which failed
+type SyncFailure struct {
+	Action SyncAction
+	Err    error
+}
+
+// SyncResult is the plan and the summary report of SyncUp and SyncDown
+type SyncResult struct {
+	Actions          []SyncAction  // The plan, sorted by key
+	Unchanged        int           // Entries already in sync
+	Uploaded         int           // Files uploaded
+	Downloaded       int           // Objects downloaded
+	Deleted          int           // Objects or files deleted
+	TransferredBytes int64         // Bytes uploaded or downloaded
+	Failed           []SyncFailure // Failed actions
+	Duration         time.Duration // Time spent
+}
+
+const (
+	syncDefaultPartSize = 100 * 1024 * 1024 // 100MB
+	syncMetaMD5         = "md5"             // Meta key holding the content MD5 of multipart uploads
+)
+
+// syncEntry is a file or an object seen by the sync
+type syncEntry struct {
+	rel     string    // Path relative to the local dir or the prefix, "/" separated
+	path    string    // Local file path or object key
+	size    int64     // Size in bytes
+	modTime time.Time // Last modified time
+	etag    string    // Object ETag without quotes
+}
+
+// SyncUp uploads the local directory to the objects under the prefix.
+//
+// A file is uploaded when the object is missing or differs in size, or when the local file is newer than the object.
+// With CompareMD5 the content MD5 is compared with the ETag instead.
+//
+// localDir    the local directory to upload.
+// prefix    the object key prefix, "/" is appended when it's missing.
+// opts    the sync options, nil uses the defaults.
+//
+// SyncResult    the plan and the summary. With DryRun only the plan is filled.
+// error    it's nil if no error, otherwise it's an error object. Failures of single actions are in SyncResult.Failed.
+func (bucket Object) SyncUp(localDir, prefix string, opts *SyncOptions) (SyncResult, error) {
+	var out SyncResult
+	start := time.Now()
+	opts = normalizeSyncOptions(opts)
+	prefix = normalizeSyncPrefix(prefix)
+
+	locals, err := listSyncFiles(localDir, opts)
+	if err != nil {
+		return out, err
+	}
+	remotes, err := bucket.listSyncObjects(prefix, opts)
+	if err != nil {
+		return out, err
+	}
+
+	for rel, local := range locals {
+		remote, ok := remotes[rel]
+		reason := ""
+		if !ok {
+			reason = "new"
+		} else {
+			reason, err = bucket.syncDiffers(local, remote, opts, true)
+			if err != nil {
+				return out, err
+			}
+		}
+		if reason == "" {
+			out.Unchanged++
+			continue
+		}
+		out.Actions = append(out.Actions, SyncAction{Type: SyncUpload, Key: prefix + rel,
+			LocalPath: local.path, Size: local.size, Reason: reason})
+	}
+
+	if opts.Delete {
+		for rel, remote := range remotes {
+			if _, ok := locals[rel]; !ok {
+				out.Actions = append(out.Actions, SyncAction{Type: SyncDeleteObject, Key: remote.path, Reason: "stale"})
+			}
+		}
+	}
+
+	sortSyncActions(out.Actions)
+	if !opts.DryRun {
+		bucket.runSyncActions(&out, opts)
+	}
+	out.Duration = time.Since(start)
+	return out, nil
+}
+
+// SyncDown downloads the objects under the prefix to the local directory.
+//
+// An object is downloaded when the local file is missing or differs in size, or when the object is newer than the local file.
+// With CompareMD5 the content MD5 is compared with the ETag instead. Downloaded files get the object's last modified time.
+// The keys which would be written outside localDir, such as the ones with ".." segments, are reported in Failed.
+//
+// prefix    the object key prefix, "/" is appended when it's missing.
+// localDir    the local directory to download to. It's created when it does not exist.
+// opts    the sync options, nil uses the defaults.
+//
+// SyncResult    the plan and the summary. With DryRun only the plan is filled.
+// error    it's nil if no error, otherwise it's an error object. Failures of single actions are in SyncResult.Failed.
+func (bucket Object) SyncDown(prefix, localDir string, opts *SyncOptions) (SyncResult, error) {
+	var out SyncResult
+	start := time.Now()
+	opts = normalizeSyncOptions(opts)
+	prefix = normalizeSyncPrefix(prefix)
+
+	if localDir == "" {
+		return out, errors.New("the parameter is invalid: localDir is empty")
+	}
+	if !opts.DryRun {
+		if err := os.MkdirAll(localDir, 0755); err != nil {
+			return out, err
+		}
+	}
+
+	remotes, err := bucket.listSyncObjects(prefix, opts)
+	if err != nil {
+		return out, err
+	}
+	locals, err := listSyncFiles(localDir, opts)
+	if err != nil && !os.IsNotExist(err) {
+		return out, err
+	}
+
+	for rel, remote := range remotes {
+		if !isSyncLocalPath(rel) {
+			action := SyncAction{Type: SyncDownload, Key: remote.path, Size: remote.size, Reason: "unsafe"}
+			out.Failed = append(out.Failed, SyncFailure{Action: action,
+				Err: fmt.Errorf("oos: the key %q escapes the local directory", remote.path)})
+			continue
+		}
+		local, ok := locals[rel]
+		reason := ""
+		if !ok {
+			reason = "new"
+		} else {
+			reason, err = bucket.syncDiffers(local, remote, opts, false)
+			if err != nil {
+				return out, err
+			}
+		}
+		if reason == "" {
+			out.Unchanged++
+			continue
+		}
+		out.Actions = append(out.Actions, SyncAction{Type: SyncDownload, Key: remote.path,
+			LocalPath: filepath.Join(localDir, filepath.FromSlash(rel)), Size: remote.size, Reason: reason})
+	}
+
+	if opts.Delete {
+		for rel, local := range locals {
+			if _, ok := remotes[rel]; !ok {
+				out.Actions = append(out.Actions, SyncAction{Type: SyncDeleteFile, Key: prefix + rel,
+					LocalPath: local.path, Reason: "stale"})
+			}
+		}
+	}
+
+	sortSyncActions(out.Actions)
+	if !opts.DryRun {
+		bucket.runSyncActions(&out, opts)
+	}
+	out.Duration = time.Since(start)
+	return out, nil
+}
+
+// normalizeSyncOptions fills the default values
+func normalizeSyncOptions(opts *SyncOptions) *SyncOptions {
+	o := SyncOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.Routines < 1 {
+		o.Routines = 1
+	} else if o.Routines > 100 {
+		o.Routines = 100
+	}
+	if o.PartSize <= 0 {
+		o.PartSize = syncDefaultPartSize
+	}
+	if o.PartSize < MinPartSize {
+		o.PartSize = MinPartSize
+	} else if o.PartSize > MaxPartSize {
+		o.PartSize = MaxPartSize
+	}
+	return &o
+}
+
+// isSyncLocalPath checks the relative path of the key stays in the local directory: it isn't absolute and
+// has no ".." segment, the backslash is a separator on Windows
+func isSyncLocalPath(rel string) bool {
+	if rel == "" || filepath.IsAbs(filepath.FromSlash(rel)) || filepath.VolumeName(filepath.FromSlash(rel)) != "" {
+		return false
+	}
+	for _, v := range strings.FieldsFunc(rel, func(r rune) bool { return r == '/' || r == '\\' }) {
+		if v == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+func normalizeSyncPrefix(prefix string) string {
+	prefix = strings.TrimLeft(prefix, "/")
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return prefix
+}
+
+// isSyncSelected checks the relative path against the include and exclude globs
+func isSyncSelected(rel string, opts *SyncOptions) bool {
+	for _, pattern := range opts.Exclude {
+		if matchGlob(pattern, rel) {
+			return false
+		}
+	}
+	if len(opts.Include) == 0 {
+		return true
+	}
+	for _, pattern := range opts.Include {
+		if matchGlob(pattern, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// listSyncFiles walks the local directory
+func listSyncFiles(localDir string, opts *SyncOptions) (map[string]syncEntry, error) {
+	entries := map[string]syncEntry{}
+	if localDir == "" {
+		return entries, errors.New("the parameter is invalid: localDir is empty")
+	}
+
+	err := filepath.Walk(localDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(localDir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if strings.HasSuffix(rel, TempFileSuffix) || !isSyncSelected(rel, opts) {
+			return nil
+		}
+		entries[rel] = syncEntry{rel: rel, path: path, size: info.Size(), modTime: info.ModTime()}
+		return nil
+	})
+	return entries, err
+}
+
+// listSyncObjects lists all the objects under the prefix
+func (bucket Object) listSyncObjects(prefix string, opts *SyncOptions) (map[string]syncEntry, error) {
+	entries := map[string]syncEntry{}
+	marker := ""
+	for {
+		lor, err := bucket.ListObjects(Prefix(prefix), Marker(marker), MaxKeys(1000))
+		if err != nil {
+			return nil, err
+		}
+		for _, object := range lor.Objects {
+			rel := strings.TrimPrefix(object.Key, prefix)
+			if rel == "" || strings.HasSuffix(rel, "/") || !isSyncSelected(rel, opts) {
+				continue
+			}
+			entries[rel] = syncEntry{rel: rel, path: object.Key, size: object.Size,
+				modTime: object.LastModified, etag: strings.Trim(object.ETag, "\"")}
+		}
+		if !lor.IsTruncated {
+			break
+		}
+		marker = lor.NextMarker
+		if marker == "" && len(lor.Objects) > 0 {
+			marker = lor.Objects[len(lor.Objects)-1].Key
+		}
+		if marker == "" {
+			break
+		}
+	}
+	return entries, nil
+}
+
+// syncDiffers returns the reason why the local file and the object differ, or "" when they are the same
+func (bucket Object) syncDiffers(local, remote syncEntry, opts *SyncOptions, isUp bool) (string, error) {
+	if local.size != remote.size {
+		return "size", nil
+	}
+
+	if opts.CompareMD5 {
+		localMD5, err := fileMD5Hex(local.path)
+		if err != nil {
+			return "", err
+		}
+		remoteMD5 := remote.etag
+		if strings.Contains(remoteMD5, "-") {
+			// The ETag of a multipart object is not the content MD5, use the meta written by the sync
+			meta, err := bucket.HeadObject(remote.path)
+			if err != nil {
+				return "", err
+			}
+			remoteMD5 = meta.Get(HTTPHeaderoosMetaPrefix + syncMetaMD5)
+		}
+		if !strings.EqualFold(localMD5, remoteMD5) {
+			return "md5", nil
+		}
+		return "", nil
+	}
+
+	// Last-Modified has a precision of seconds
+	localTime := local.modTime.Truncate(time.Second)
+	remoteTime := remote.modTime.Truncate(time.Second)
+	if isUp && localTime.After(remoteTime) {
+		return "modified", nil
+	}
+	if !isUp && remoteTime.After(localTime) {
+		return "modified", nil
+	}
+	return "", nil
+}
+
+// runSyncActions executes the plan concurrently and fills the summary
+func (bucket Object) runSyncActions(out *SyncResult, opts *SyncOptions) {
+	jobs := make(chan SyncAction, len(out.Actions))
+	for _, action := range out.Actions {
+		jobs <- action
+	}
+	close(jobs)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for w := 0; w < opts.Routines; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for action := range jobs {
+				err := bucket.runSyncAction(action, opts)
+
+				mu.Lock()
+				if err != nil {
+					out.Failed = append(out.Failed, SyncFailure{Action: action, Err: err})
+				} else {
+					switch action.Type {
+					case SyncUpload:
+						out.Uploaded++
+						out.TransferredBytes += action.Size
+					case SyncDownload:
+						out.Downloaded++
+						out.TransferredBytes += action.Size
+					default:
+						out.Deleted++
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// runSyncAction executes one entry of the plan
+func (bucket Object) runSyncAction(action SyncAction, opts *SyncOptions) error {
+	switch action.Type {
+	case SyncUpload:
+		options := opts.Options
+		if action.Size > opts.PartSize {
+			if opts.CompareMD5 {
+				md, err := fileMD5Hex(action.LocalPath)
+				if err != nil {
+					return err
+				}
+				options = append(append([]Option{}, options...), Meta(syncMetaMD5, md))
+			}
+			return bucket.UploadFile(action.Key, action.LocalPath, opts.PartSize, options...)
+		}
+		return bucket.PutObjectFromFile(action.Key, action.LocalPath, options...)
+	case SyncDownload:
+		if err := os.MkdirAll(filepath.Dir(action.LocalPath), 0755); err != nil {
+			return err
+		}
+		var err error
+		if action.Size > opts.PartSize {
+			err = bucket.DownloadFile(action.Key, action.LocalPath, opts.PartSize, opts.Options...)
+		} else {
+			err = bucket.GetObjectToFile(action.Key, action.LocalPath, opts.Options...)
+		}
+		if err != nil {
+			return err
+		}
+		// Keep the local modified time equal to the object's so the next sync sees no change
+		meta, err := bucket.GetObjectMeta(action.Key)
+		if err != nil {
+			return err
+		}
+		if lm, err := http.ParseTime(meta.Get(HTTPHeaderLastModified)); err == nil {
+			return os.Chtimes(action.LocalPath, lm, lm)
+		}
+		return nil
+	case SyncDeleteObject:
+		return bucket.DeleteObject(action.Key)
+	case SyncDeleteFile:
+		return os.Remove(action.LocalPath)
+	}
+	return errors.New("oos: unknown sync action " + string(action.Type))
+}
+
+func sortSyncActions(actions []SyncAction) {
+	sort.Slice(actions, func(i, j int) bool {
+		if actions[i].Key != actions[j].Key {
+			return actions[i].Key < actions[j].Key
+		}
+		return actions[i].Type < actions[j].Type
+	})
+}
+
+// fileMD5Hex calculates the hex encoded MD5 of the local file
+func fileMD5Hex(filePath string) (string, error) {
+	fd, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer fd.Close()
+
+	md5Ctx := md5.New()
+	if _, err = io.Copy(md5Ctx, fd); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(md5Ctx.Sum(nil)), nil
+}
+
+// matchGlob matches the "/" separated path against the glob pattern.
+// "*" and "?" don't match "/", "**" matches anything. A pattern without "/" is matched against the base name too.
+func matchGlob(pattern, name string) bool {
+	re, err := globToRegexp(pattern)
+	if err != nil {
+		return false
+	}
+	if re.MatchString(name) {
+		return true
+	}
+	if !strings.Contains(pattern, "/") {
+		return re.MatchString(name[strings.LastIndex(name, "/")+1:])
+	}
+	return false
+}
+
+func globToRegexp(pattern string) (*regexp.Regexp, error) {
+	var buf strings.Builder
+	buf.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				i++
+				if i+1 < len(pattern) && pattern[i+1] == '/' {
+					// "**/" also matches zero directories
+					i++
+					buf.WriteString("(?:.*/)?")
+				} else {
+					buf.WriteString(".*")
+				}
+			} else {
+				buf.WriteString("[^/]*")
+			}
+		case '?':
+			buf.WriteString("[^/]")
+		default:
+			buf.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	buf.WriteString("$")
+	return regexp.Compile(buf.String())
+}
@@ -56,26 +56,78 @@ type LifecycleConfiguration struct {
 
 // LifecycleRule defines Lifecycle rules
 type LifecycleRule struct {
-	XMLName    xml.Name             `xml:"Rule"`
-	ID         string               `xml:"ID,omitempty"`         // The rule ID
-	Prefix     string               `xml:"Prefix"`               // The object key prefix
-	Status     string               `xml:"Status"`               // The rule status (enabled or not)
-	Expiration *LifecycleExpiration `xml:"Expiration,omitempty"` // The expiration property
-	Transition *LifecycleTransition `xml:"Transition,omitempty"` // The Transition property
+	XMLName     xml.Name              `xml:"Rule"`
+	ID          string                `xml:"ID,omitempty"`         // The rule ID
+	Prefix      string                `xml:"Prefix"`               // The object key prefix, it's omitted if Filter is set
+	Filter      *LifecycleFilter      `xml:"Filter,omitempty"`     // The filter by prefix and tags, it replaces Prefix
+	Status      string                `xml:"Status"`               // The rule status (enabled or not)
+	Expiration  *LifecycleExpiration  `xml:"Expiration,omitempty"` // The expiration property
+	Transitions []LifecycleTransition `xml:"Transition,omitempty"` // The transitions to other storage classes
+
+	// Deprecated: use Transitions. It's sent as the first transition if Transitions doesn't have it, and
+	// GetBucketLifecycle only sets Transitions.
+	Transition *LifecycleTransition `xml:"-"`
+
+	NoncurrentVersionExpiration    *LifecycleNoncurrentVersionExpiration    `xml:"NoncurrentVersionExpiration,omitempty"`    // Expires the noncurrent versions
+	NoncurrentVersionTransitions   []LifecycleNoncurrentVersionTransition   `xml:"NoncurrentVersionTransition,omitempty"`    // Transitions the noncurrent versions
+	AbortIncompleteMultipartUpload *LifecycleAbortIncompleteMultipartUpload `xml:"AbortIncompleteMultipartUpload,omitempty"` // Aborts the stale multipart uploads
+}
+
+// LifecycleFilter defines the objects the rule applies to. Set one of Prefix, Tag and And.
+type LifecycleFilter struct {
+	XMLName xml.Name            `xml:"Filter"`
+	Prefix  string              `xml:"Prefix,omitempty"` // The object key prefix
+	Tag     *Tag                `xml:"Tag,omitempty"`    // The object tag
+	And     *LifecycleFilterAnd `xml:"And,omitempty"`    // The prefix and the tags, all of them must match
+}
+
+// Tag defines the object tag
+type Tag struct {
+	XMLName xml.Name `xml:"Tag"`
+	Key     string   `xml:"Key"`   // The tag key
+	Value   string   `xml:"Value"` // The tag value
+}
+
+// LifecycleFilterAnd defines the prefix and the tags of the filter which must all match
+type LifecycleFilterAnd struct {
+	XMLName xml.Name `xml:"And"`
+	Prefix  string   `xml:"Prefix,omitempty"` // The object key prefix
+	Tags    []Tag    `xml:"Tag,omitempty"`    // The object tags
 }
 
 // LifecycleExpiration defines the rule's expiration property
 type LifecycleExpiration struct {
-	XMLName xml.Name `xml:"Expiration"`
-	Days    int      `xml:"Days,omitempty"` // Relative expiration time: The expiration time in days after the last modified time
-	Date    string   `xml:"Date,omitempty"` // Absolute expiration time: The expiration time in date.
+	XMLName                   xml.Name `xml:"Expiration"`
+	Days                      int      `xml:"Days,omitempty"`                      // Relative expiration time: The expiration time in days after the last modified time
+	Date                      string   `xml:"Date,omitempty"`                      // Absolute expiration time: The expiration time in date.
+	ExpiredObjectDeleteMarker bool     `xml:"ExpiredObjectDeleteMarker,omitempty"` // Removes the delete markers without noncurrent versions
 }
 
+// LifecycleTransition defines the rule's transition property
 type LifecycleTransition struct {
 	XMLName      xml.Name `xml:"Transition"`
-	Days         int      `xml:"Days,omitempty"` // Relative expiration time: The expiration time in days after the last modified time
-	Date         string   `xml:"Date,omitempty"` // Absolute expiration time: The expiration time in date.
-	StorageClass string   `xml:"StorageClass"`
+	Days         int      `xml:"Days,omitempty"` // Relative transition time: The transition time in days after the last modified time
+	Date         string   `xml:"Date,omitempty"` // Absolute transition time: The transition time in date.
+	StorageClass string   `xml:"StorageClass"`   // The target storage class
+}
+
+// LifecycleNoncurrentVersionExpiration defines the expiration of the noncurrent versions
+type LifecycleNoncurrentVersionExpiration struct {
+	XMLName        xml.Name `xml:"NoncurrentVersionExpiration"`
+	NoncurrentDays int      `xml:"NoncurrentDays"` // Days after the version becomes noncurrent
+}
+
+// LifecycleNoncurrentVersionTransition defines the transition of the noncurrent versions
+type LifecycleNoncurrentVersionTransition struct {
+	XMLName        xml.Name `xml:"NoncurrentVersionTransition"`
+	NoncurrentDays int      `xml:"NoncurrentDays"` // Days after the version becomes noncurrent
+	StorageClass   string   `xml:"StorageClass"`   // The target storage class
+}
+
+// LifecycleAbortIncompleteMultipartUpload defines the rule's abort incomplete multipart upload property
+type LifecycleAbortIncompleteMultipartUpload struct {
+	XMLName             xml.Name `xml:"AbortIncompleteMultipartUpload"`
+	DaysAfterInitiation int      `xml:"DaysAfterInitiation"` // Days after the upload is initiated
 }
 
 type LifecycleXML struct {
@@ -112,7 +164,7 @@ func BuildLifecycleTransitionRuleByDays(id, prefix string, status bool, days int
 		statusStr = "Disabled"
 	}
 	return LifecycleRule{ID: id, Prefix: prefix, Status: statusStr,
-		Transition: &LifecycleTransition{Days: days, StorageClass: storageClass}}
+		Transitions: []LifecycleTransition{{Days: days, StorageClass: storageClass}}}
 }
 
 // BuildLifecycleExpirRuleByDate builds a lifecycle rule with specified expiration time.
@@ -123,7 +175,18 @@ func BuildLifecycleTransitionRuleByDate(id, prefix string, status bool, year, mo
 	}
 	date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
 	return LifecycleRule{ID: id, Prefix: prefix, Status: statusStr,
-		Transition: &LifecycleTransition{Date: date.Format(lifecycleDateFormat), StorageClass: storageClass}}
+		Transitions: []LifecycleTransition{{Date: date.Format(lifecycleDateFormat), StorageClass: storageClass}}}
+}
+
+// BuildLifecycleAbortIncompleteUploadRule builds a lifecycle rule which aborts the multipart uploads
+// not completed in the specified days after they are initiated.
+func BuildLifecycleAbortIncompleteUploadRule(id, prefix string, status bool, days int) LifecycleRule {
+	var statusStr = "Enabled"
+	if !status {
+		statusStr = "Disabled"
+	}
+	return LifecycleRule{ID: id, Prefix: prefix, Status: statusStr,
+		AbortIncompleteMultipartUpload: &LifecycleAbortIncompleteMultipartUpload{DaysAfterInitiation: days}}
 }
 
 // GetBucketLifecycleResult defines GetBucketLifecycle's result object
@@ -226,6 +289,7 @@ type Redirect struct {
 	Protocol             string   `xml:"Protocol,omitempty"`
 	ReplaceKeyPrefixWith string   `xml:"ReplaceKeyPrefixWith,omitempty"`
 	ReplaceKeyWith       string   `xml:"ReplaceKeyWith,omitempty"`
+	HttpRedirectCode     string   `xml:"HttpRedirectCode,omitempty"`
 }
 
 // GetBucketWebsiteResult defines the result from GetBucketWebsite request.
@@ -306,10 +370,125 @@ type member struct {
 	UserName    string     `xml:"UserName"`
 	AccessKeyId string     `xml:"AccessKeyId"`
 	Status      string     `xml:"Status"`
-	IsPrimary   string     `xml:"IsPrimary"`
+	IsPrimary   bool       `xml:"IsPrimary"`
 	CreateDate  *time.Time `xml:"CreateDate,omitempty"`
 }
 
+// IAMUser defines the IAM user
+type IAMUser struct {
+	Path       string     `xml:"Path"`       // Path of the user
+	UserName   string     `xml:"UserName"`   // User name
+	UserId     string     `xml:"UserId"`     // Unique ID of the user
+	Arn        string     `xml:"Arn"`        // Arn of the user, used in the policies
+	CreateDate *time.Time `xml:"CreateDate"` // Creation time
+}
+
+// IAMGroup defines the IAM group
+type IAMGroup struct {
+	Path       string     `xml:"Path"`       // Path of the group
+	GroupName  string     `xml:"GroupName"`  // Group name
+	GroupId    string     `xml:"GroupId"`    // Unique ID of the group
+	Arn        string     `xml:"Arn"`        // Arn of the group
+	CreateDate *time.Time `xml:"CreateDate"` // Creation time
+}
+
+// IAMPolicy defines the managed IAM policy
+type IAMPolicy struct {
+	PolicyName       string     `xml:"PolicyName"`       // Policy name
+	PolicyId         string     `xml:"PolicyId"`         // Unique ID of the policy
+	Arn              string     `xml:"Arn"`              // Arn of the policy, used to attach it
+	Path             string     `xml:"Path"`             // Path of the policy
+	Description      string     `xml:"Description"`      // Description of the policy
+	DefaultVersionId string     `xml:"DefaultVersionId"` // Default version of the policy document
+	AttachmentCount  int        `xml:"AttachmentCount"`  // Count of the users and groups attached to
+	IsAttachable     bool       `xml:"IsAttachable"`     // Whether the policy can be attached
+	CreateDate       *time.Time `xml:"CreateDate"`       // Creation time
+	UpdateDate       *time.Time `xml:"UpdateDate"`       // Last update time
+}
+
+// AttachedPolicy defines the policy attached to the user
+type AttachedPolicy struct {
+	PolicyName string `xml:"PolicyName"` // Policy name
+	PolicyArn  string `xml:"PolicyArn"`  // Arn of the policy
+}
+
+// CreateUserResponse defines the response of CreateUser
+type CreateUserResponse struct {
+	XMLName          xml.Name         `xml:"CreateUserResponse"`
+	User             IAMUser          `xml:"CreateUserResult>User"`
+	ResponseMetadata ResponseMetadata `xml:"ResponseMetadata"`
+}
+
+// GetUserResponse defines the response of GetUser
+type GetUserResponse struct {
+	XMLName          xml.Name         `xml:"GetUserResponse"`
+	User             IAMUser          `xml:"GetUserResult>User"`
+	ResponseMetadata ResponseMetadata `xml:"ResponseMetadata"`
+}
+
+// ListUsersResponse defines the response of ListUsers
+type ListUsersResponse struct {
+	XMLName          xml.Name         `xml:"ListUsersResponse"`
+	ListUsersResult  ListUsersResult  `xml:"ListUsersResult"`
+	ResponseMetadata ResponseMetadata `xml:"ResponseMetadata"`
+}
+
+// ListUsersResult defines the result of ListUsers
+type ListUsersResult struct {
+	Users       []IAMUser `xml:"Users>member"` // Users of the page
+	IsTruncated bool      `xml:"IsTruncated"`  // Flag indicates there are more users
+	Marker      string    `xml:"Marker"`       // Marker of the next page
+}
+
+// CreateGroupResponse defines the response of CreateGroup
+type CreateGroupResponse struct {
+	XMLName          xml.Name         `xml:"CreateGroupResponse"`
+	Group            IAMGroup         `xml:"CreateGroupResult>Group"`
+	ResponseMetadata ResponseMetadata `xml:"ResponseMetadata"`
+}
+
+// ListGroupsResponse defines the response of ListGroups
+type ListGroupsResponse struct {
+	XMLName          xml.Name         `xml:"ListGroupsResponse"`
+	ListGroupsResult ListGroupsResult `xml:"ListGroupsResult"`
+	ResponseMetadata ResponseMetadata `xml:"ResponseMetadata"`
+}
+
+// ListGroupsForUserResponse defines the response of ListGroupsForUser
+type ListGroupsForUserResponse struct {
+	XMLName          xml.Name         `xml:"ListGroupsForUserResponse"`
+	ListGroupsResult ListGroupsResult `xml:"ListGroupsForUserResult"`
+	ResponseMetadata ResponseMetadata `xml:"ResponseMetadata"`
+}
+
+// ListGroupsResult defines the result of ListGroups and ListGroupsForUser
+type ListGroupsResult struct {
+	Groups      []IAMGroup `xml:"Groups>member"` // Groups of the page
+	IsTruncated bool       `xml:"IsTruncated"`   // Flag indicates there are more groups
+	Marker      string     `xml:"Marker"`        // Marker of the next page
+}
+
+// CreatePolicyResponse defines the response of CreatePolicy
+type CreatePolicyResponse struct {
+	XMLName          xml.Name         `xml:"CreatePolicyResponse"`
+	Policy           IAMPolicy        `xml:"CreatePolicyResult>Policy"`
+	ResponseMetadata ResponseMetadata `xml:"ResponseMetadata"`
+}
+
+// ListAttachedUserPoliciesResponse defines the response of ListAttachedUserPolicies
+type ListAttachedUserPoliciesResponse struct {
+	XMLName                        xml.Name                       `xml:"ListAttachedUserPoliciesResponse"`
+	ListAttachedUserPoliciesResult ListAttachedUserPoliciesResult `xml:"ListAttachedUserPoliciesResult"`
+	ResponseMetadata               ResponseMetadata               `xml:"ResponseMetadata"`
+}
+
+// ListAttachedUserPoliciesResult defines the result of ListAttachedUserPolicies
+type ListAttachedUserPoliciesResult struct {
+	AttachedPolicies []AttachedPolicy `xml:"AttachedPolicies>member"` // Policies of the page
+	IsTruncated      bool             `xml:"IsTruncated"`             // Flag indicates there are more policies
+	Marker           string           `xml:"Marker"`                  // Marker of the next page
+}
+
 type deleteXML struct {
 	XMLName xml.Name       `xml:"Delete"`
 	Objects []DeleteObject `xml:"Object"` // Objects to delete
@@ -735,4 +914,19 @@ func BuildBucketObjectLockByYears(mode string, years int, isEnabel bool) BucketO
 	return lock
 }
 
+// ObjectRetention defines the retention of the object, the object version can't be overwritten or deleted until
+// RetainUntilDate. The GOVERNANCE retention can be changed with BypassGovernanceRetention, the COMPLIANCE one can
+// only be extended.
+type ObjectRetention struct {
+	XMLName         xml.Name   `xml:"Retention"`
+	Mode            BucketMode `xml:"Mode"`            // The retention mode, COMPLIANCE or GOVERNANCE
+	RetainUntilDate time.Time  `xml:"RetainUntilDate"` // The date the retention expires
+}
+
+// ObjectLegalHold defines the legal hold of the object, it has no expiry date
+type ObjectLegalHold struct {
+	XMLName xml.Name            `xml:"LegalHold"`
+	Status  LegalHoldStatusType `xml:"Status"` // The legal hold status, ON or OFF
+}
+
 // object lock end
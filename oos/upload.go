@@ -7,18 +7,22 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strconv"
 	"time"
 )
 
 // UploadFile is multipart file upload.
+// With the checkpoint enabled, the progress is saved in the checkpoint store (local files by default) and
+// an interrupted upload resumes from it. The checkpoint is reconciled with the parts on the server, and the
+// upload starts over if its upload ID doesn't exist on the server anymore, also once when it's aborted during the upload.
+// The upload of an invalid checkpoint, such as the one of the changed file, is aborted before starting over.
 //
 // objectKey    the object name.
 // filePath    the local file path to upload.
 // partSize    the part size in byte.
-// options    the options for uploading object.
+// options    the options for uploading object. Checkpoint, CheckpointDir or CheckpointStore enables the resumable upload.
 //
 // error    it's nil if the operation succeeds, otherwise it's an error object.
 func (bucket Object) UploadFile(objectKey, filePath string, partSize int64, options ...Option) error {
@@ -37,6 +41,15 @@ func (bucket Object) UploadFile(objectKey, filePath string, partSize int64, opti
 
 	routines := getRoutines(options)
 
+	absPath, _ := filepath.Abs(filePath)
+	dest := fmt.Sprintf("oos://%v/%v", bucket.BucketName, objectKey)
+	cpKey := getCheckpointKey(options, func(cpConf *cpConfig) string {
+		return getUploadCpFilePath(cpConf, filePath, bucket.BucketName, objectKey)
+	}, absPath, dest)
+	if cpKey != "" {
+		return bucket.uploadFileWithCp(objectKey, filePath, partSize, options, cpKey, routines)
+	}
+
 	return bucket.uploadFile(objectKey, filePath, partSize, options, routines)
 }
 
@@ -299,7 +312,7 @@ func (cp uploadCheckpoint) isValid(filePath string) (bool, error) {
 
 	// Compare the file size, file's last modified time and file's MD5
 	if cp.FileStat.Size != st.Size() ||
-		cp.FileStat.LastModified != st.ModTime() ||
+		!cp.FileStat.LastModified.Equal(st.ModTime()) ||
 		cp.FileStat.MD5 != md {
 		return false, nil
 	}
@@ -307,39 +320,15 @@ func (cp uploadCheckpoint) isValid(filePath string) (bool, error) {
 	return true, nil
 }
 
-// load loads from the file
-func (cp *uploadCheckpoint) load(filePath string) error {
-	contents, err := ioutil.ReadFile(filePath)
-	if err != nil {
-		return err
-	}
-
-	err = json.Unmarshal(contents, cp)
-	return err
+// load loads from the checkpoint store
+func (cp *uploadCheckpoint) load(store CheckpointStorage, key string) error {
+	return loadCheckpoint(store, key, cp)
 }
 
-// dump dumps to the local file
-func (cp *uploadCheckpoint) dump(filePath string) error {
+// dump dumps to the checkpoint store
+func (cp *uploadCheckpoint) dump(store CheckpointStorage, key string) error {
 	bcp := *cp
-
-	// Calculate MD5
-	bcp.MD5 = ""
-	js, err := json.Marshal(bcp)
-	if err != nil {
-		return err
-	}
-	sum := md5.Sum(js)
-	b64 := base64.StdEncoding.EncodeToString(sum[:])
-	bcp.MD5 = b64
-
-	// Serialization
-	js, err = json.Marshal(bcp)
-	if err != nil {
-		return err
-	}
-
-	// Dump
-	return ioutil.WriteFile(filePath, js, FilePermMode)
+	return dumpCheckpoint(store, key, &bcp, func(md5 string) { bcp.MD5 = md5 })
 }
 
 // updatePart updates the part status
@@ -368,6 +357,46 @@ func (cp *uploadCheckpoint) allParts() []UploadPart {
 	return ps
 }
 
+// reconcile updates the parts with the uploaded parts on the server, the server wins over the checkpoint.
+// It returns an error with code NoSuchUpload if the upload doesn't exist on the server.
+func (cp *uploadCheckpoint) reconcile(bucket *Object, options []Option) error {
+	imur := InitiateMultipartUploadResult{Bucket: bucket.BucketName, Key: cp.ObjectKey, UploadID: cp.UploadID}
+	uploaded := map[int]UploadedPart{}
+	marker := 0
+	for {
+		lupr, err := bucket.ListUploadedParts(imur, append(options, PartNumberMarker(marker))...)
+		if err != nil {
+			return err
+		}
+		for _, part := range lupr.UploadedParts {
+			uploaded[part.PartNumber] = part
+		}
+		next, _ := strconv.Atoi(lupr.NextPartNumberMarker)
+		if !lupr.IsTruncated || next <= marker {
+			break
+		}
+		marker = next
+	}
+
+	for i := range cp.Parts {
+		part, ok := uploaded[cp.Parts[i].Chunk.Number]
+		if ok && int64(part.Size) == cp.Parts[i].Chunk.Size {
+			cp.Parts[i].Part = UploadPart{PartNumber: part.PartNumber, ETag: part.ETag}
+			cp.Parts[i].IsCompleted = true
+		} else {
+			cp.Parts[i].Part = UploadPart{}
+			cp.Parts[i].IsCompleted = false
+		}
+	}
+	return nil
+}
+
+// isNoSuchUpload checks if the error is returned because the upload ID doesn't exist
+func isNoSuchUpload(err error) bool {
+	serr, ok := err.(ServiceError)
+	return ok && serr.Code == "NoSuchUpload"
+}
+
 // getCompletedBytes returns completed bytes count
 func (cp *uploadCheckpoint) getCompletedBytes() int64 {
 	var completedBytes int64
@@ -432,21 +461,22 @@ func prepare(cp *uploadCheckpoint, objectKey, filePath string, partSize int64, b
 	return nil
 }
 
-// complete completes the multipart upload and deletes the local CP files
-func complete(cp *uploadCheckpoint, bucket *Object, parts []UploadPart, cpFilePath string, options []Option) error {
+// complete completes the multipart upload and deletes the CP
+func complete(cp *uploadCheckpoint, bucket *Object, parts []UploadPart, store CheckpointStorage, cpKey string, options []Option) error {
 	imur := InitiateMultipartUploadResult{Bucket: bucket.BucketName,
 		Key: cp.ObjectKey, UploadID: cp.UploadID}
 	_, err := bucket.CompleteMultipartUpload(imur, parts, options...)
 	if err != nil {
 		return err
 	}
-	os.Remove(cpFilePath)
+	store.Delete(cpKey)
 	return err
 }
 
 // uploadFileWithCp handles concurrent upload with checkpoint
-func (bucket Object) uploadFileWithCp(objectKey, filePath string, partSize int64, options []Option, cpFilePath string, routines int) error {
+func (bucket Object) uploadFileWithCp(objectKey, filePath string, partSize int64, options []Option, cpKey string, routines int) error {
 	listener := getProgressListener(options)
+	store := getCheckpointStore(options)
 
 	payerOptions := []Option{}
 	payer := getPayer(options)
@@ -456,24 +486,81 @@ func (bucket Object) uploadFileWithCp(objectKey, filePath string, partSize int64
 
 	// Load CP data
 	ucp := uploadCheckpoint{}
-	err := ucp.load(cpFilePath)
+	err := ucp.load(store, cpKey)
 	if err != nil {
-		os.Remove(cpFilePath)
+		store.Delete(cpKey)
 	}
 
 	// Load error or the CP data is invalid.
 	valid, err := ucp.isValid(filePath)
-	if err != nil || !valid {
-		if err = prepare(&ucp, objectKey, filePath, partSize, &bucket, options); err != nil {
+	if err == nil && valid && ucp.ObjectKey != objectKey {
+		valid = false
+	}
+	if err == nil && valid {
+		// The local CP may be stale, the parts on the server are the truth
+		err = ucp.reconcile(&bucket, payerOptions)
+		if isNoSuchUpload(err) {
+			valid = false
+		} else if err != nil {
 			return err
 		}
-		os.Remove(cpFilePath)
 	}
+	if err != nil {
+		valid = false
+	}
+
+	for restarted := false; ; restarted = true {
+		if !valid {
+			// The upload of the invalid CP would be left on the server and billed
+			if err = bucket.abortCheckpointUpload(&ucp, payerOptions); err != nil {
+				return err
+			}
+			store.Delete(cpKey)
+			ucp = uploadCheckpoint{}
+			if err = prepare(&ucp, objectKey, filePath, partSize, &bucket, options); err != nil {
+				return err
+			}
+			// Keep the upload ID at once, so that a crash before the first part doesn't orphan the upload
+			if err = ucp.dump(store, cpKey); err != nil {
+				return err
+			}
+		}
 
+		err = bucket.uploadCheckpointParts(&ucp, filePath, store, cpKey, routines, payerOptions, listener)
+		if !isNoSuchUpload(err) {
+			return err
+		}
+		// The upload was aborted on the server, the CP is useless
+		store.Delete(cpKey)
+		if restarted {
+			return err
+		}
+		// Start over once with a new upload, there is nothing left to abort
+		ucp.UploadID = ""
+		valid = false
+	}
+}
+
+// abortCheckpointUpload aborts the upload of the CP, it's done if the upload doesn't exist any more
+func (bucket Object) abortCheckpointUpload(ucp *uploadCheckpoint, options []Option) error {
+	if ucp.UploadID == "" || ucp.ObjectKey == "" {
+		return nil
+	}
+	imur := InitiateMultipartUploadResult{Bucket: bucket.BucketName, Key: ucp.ObjectKey, UploadID: ucp.UploadID}
+	err := bucket.AbortMultipartUpload(imur, options...)
+	if isNoSuchUpload(err) {
+		return nil
+	}
+	return err
+}
+
+// uploadCheckpointParts uploads the parts not completed in the CP concurrently, then completes the upload
+func (bucket Object) uploadCheckpointParts(ucp *uploadCheckpoint, filePath string, store CheckpointStorage, cpKey string,
+	routines int, payerOptions []Option, listener ProgressListener) error {
 	chunks := ucp.todoParts()
 	imur := InitiateMultipartUploadResult{
 		Bucket:   bucket.BucketName,
-		Key:      objectKey,
+		Key:      ucp.ObjectKey,
 		UploadID: ucp.UploadID}
 
 	jobs := make(chan FileChunk, len(chunks))
@@ -501,7 +588,7 @@ func (bucket Object) uploadFileWithCp(objectKey, filePath string, partSize int64
 		case part := <-results:
 			completed++
 			ucp.updatePart(part)
-			ucp.dump(cpFilePath)
+			ucp.dump(store, cpKey)
 			completedBytes += ucp.Parts[part.PartNumber-1].Chunk.Size
 			event = newProgressEvent(TransferDataEvent, completedBytes, ucp.FileStat.Size)
 			publishProgress(listener, event)
@@ -521,6 +608,5 @@ func (bucket Object) uploadFileWithCp(objectKey, filePath string, partSize int64
 	publishProgress(listener, event)
 
 	// Complete the multipart upload
-	err = complete(&ucp, &bucket, ucp.allParts(), cpFilePath, payerOptions)
-	return err
+	return complete(ucp, &bucket, ucp.allParts(), store, cpKey, payerOptions)
 }
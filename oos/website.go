@@ -0,0 +1,304 @@
+package oos
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const websiteMaxRoutingRules = 50 // The routing rules allowed in a website configuration
+
+// WebsiteResult is the kind of the website response
+type WebsiteResult string
+
+const (
+	// WebsiteResultObject serves the object, or the index document of the folder
+	WebsiteResultObject WebsiteResult = "Object"
+
+	// WebsiteResultRedirect redirects the request to Location
+	WebsiteResultRedirect WebsiteResult = "Redirect"
+
+	// WebsiteResultErrorDocument serves the error document with the error status code
+	WebsiteResultErrorDocument WebsiteResult = "ErrorDocument"
+
+	// WebsiteResultError returns the error status code without the error document
+	WebsiteResultError WebsiteResult = "Error"
+)
+
+// WebsiteResolution is the result of ResolveWebsite
+type WebsiteResolution struct {
+	Result     WebsiteResult // What the website responds
+	StatusCode int           // The HTTP status code of the response
+	Key        string        // The object served, the object or the error document
+	Location   string        // The redirect target, "//host/key" if the protocol of the request is kept, "/key" if the host is kept
+	Protocol   string        // The protocol of the redirect, it's empty if the protocol of the request is kept
+	HostName   string        // The host of the redirect, it's empty if the host of the request is kept
+	RuleIndex  int           // The index of the routing rule applied, -1 if no rule is applied
+	Reason     string        // How the response is decided
+}
+
+// ResolveWebsite resolves the request to the static website locally as the service does:
+//
+//  1. RedirectAllRequestsTo redirects all the requests.
+//  2. The path ending with "/" is the folder, the IndexDocument suffix is appended.
+//  3. The first routing rule without HttpErrorCodeReturnedEquals whose KeyPrefixEquals matches redirects the request.
+//  4. The existing object is served. The folder of the missing key, "key/" with the index document, is redirected to.
+//  5. The first routing rule whose HttpErrorCodeReturnedEquals is 404 and KeyPrefixEquals matches redirects the request.
+//  6. The ErrorDocument is served with 404 if it exists.
+//
+// The redirects replace the key with ReplaceKeyWith, or the matched KeyPrefixEquals with ReplaceKeyPrefixWith. The
+// status code of the routing rules is HttpRedirectCode, 301 if it's empty.
+//
+// conf    the website configuration, such as the one to set, or the one from GetBucketWebsite.
+// path    the request path, such as "/docs/".
+// exists    checks if the object exists, such as bucket.IsObjectExist.
+//
+// WebsiteResolution    the response of the website, only valid when error is nil.
+// error    it's nil if no error, otherwise it's the error of exists.
+func ResolveWebsite(conf WebsiteConfiguration, path string, exists func(key string) (bool, error)) (WebsiteResolution, error) {
+	res := WebsiteResolution{RuleIndex: -1}
+	requestKey := strings.TrimPrefix(path, "/")
+
+	if all := conf.WebsiteAllRequestTo; all != nil {
+		res.redirect(all.Protocol, all.HostName, requestKey)
+		res.Reason = "RedirectAllRequestsTo redirects all the requests"
+		return res, nil
+	}
+
+	key := requestKey
+	if key == "" || strings.HasSuffix(key, "/") {
+		key += conf.IndexDocument.Suffix
+	}
+
+	if i, rule := websiteRoutingRule(conf.RoutingRules, requestKey, ""); rule != nil {
+		res.applyRoutingRule(i, rule, requestKey)
+		return res, nil
+	}
+
+	if key != "" {
+		found, err := exists(key)
+		if err != nil {
+			return res, err
+		}
+		if found {
+			res.Result, res.StatusCode, res.Key = WebsiteResultObject, http.StatusOK, key
+			res.Reason = "the object exists"
+			return res, nil
+		}
+		if key == requestKey && conf.IndexDocument.Suffix != "" {
+			folder := key + "/"
+			if found, err = exists(folder + conf.IndexDocument.Suffix); err != nil {
+				return res, err
+			}
+			if found {
+				res.redirect("", "", folder)
+				res.StatusCode = http.StatusFound
+				res.Reason = "the key is a folder with the index document"
+				return res, nil
+			}
+		}
+	}
+
+	if i, rule := websiteRoutingRule(conf.RoutingRules, requestKey, strconv.Itoa(http.StatusNotFound)); rule != nil {
+		res.applyRoutingRule(i, rule, requestKey)
+		return res, nil
+	}
+
+	res.StatusCode = http.StatusNotFound
+	if errorKey := conf.ErrorDocument.Key; errorKey != "" {
+		found, err := exists(errorKey)
+		if err != nil {
+			return res, err
+		}
+		if found {
+			res.Result, res.Key = WebsiteResultErrorDocument, errorKey
+			res.Reason = fmt.Sprintf("the object %s doesn't exist", key)
+			return res, nil
+		}
+	}
+	res.Result = WebsiteResultError
+	res.Reason = fmt.Sprintf("the object %s doesn't exist and there is no error document", key)
+	return res, nil
+}
+
+// websiteRoutingRule returns the first routing rule which matches the key and the error code, the error code is empty
+// before the object is fetched
+func websiteRoutingRule(rules []RoutingRule, key, errorCode string) (int, *RoutingRule) {
+	for i := range rules {
+		rule := &rules[i]
+		if rule.Redirect == nil {
+			continue
+		}
+		var cond Condition
+		if rule.Condition != nil {
+			cond = *rule.Condition
+		}
+		if cond.HttpErrorCodeReturnedEquals == errorCode && strings.HasPrefix(key, cond.KeyPrefixEquals) {
+			return i, rule
+		}
+	}
+	return -1, nil
+}
+
+// applyRoutingRule redirects the key by the routing rule
+func (res *WebsiteResolution) applyRoutingRule(index int, rule *RoutingRule, key string) {
+	res.redirect(rule.Redirect.Protocol, rule.Redirect.HostName, websiteRedirectKey(rule, key))
+	if code, err := strconv.Atoi(rule.Redirect.HttpRedirectCode); err == nil {
+		res.StatusCode = code
+	}
+	res.RuleIndex = index
+	res.Reason = fmt.Sprintf("routing rule %d redirects the request", index)
+}
+
+// redirect sets the redirect to the key
+func (res *WebsiteResolution) redirect(protocol, hostName, key string) {
+	res.Result, res.StatusCode = WebsiteResultRedirect, http.StatusMovedPermanently
+	res.Protocol, res.HostName = protocol, hostName
+	switch {
+	case hostName == "":
+		res.Location = "/" + key
+	case protocol == "":
+		res.Location = "//" + hostName + "/" + key
+	default:
+		res.Location = protocol + "://" + hostName + "/" + key
+	}
+}
+
+// websiteRedirectKey returns the key the routing rule redirects to
+func websiteRedirectKey(rule *RoutingRule, key string) string {
+	redirect := rule.Redirect
+	if redirect.ReplaceKeyWith != "" {
+		return redirect.ReplaceKeyWith
+	}
+	if redirect.ReplaceKeyPrefixWith != "" {
+		prefix := ""
+		if rule.Condition != nil {
+			prefix = rule.Condition.KeyPrefixEquals
+		}
+		return redirect.ReplaceKeyPrefixWith + strings.TrimPrefix(key, prefix)
+	}
+	return key
+}
+
+// WebsiteValidationError is returned by ValidateWebsiteConfiguration, it lists all the problems found
+type WebsiteValidationError struct {
+	Problems []string
+}
+
+// Error implements interface error
+func (e WebsiteValidationError) Error() string {
+	return "oos: invalid website: " + strings.Join(e.Problems, "; ")
+}
+
+// ValidateWebsiteConfiguration checks the configuration locally before SetBucketWebsite: RedirectAllRequestsTo
+// can't be used with the other settings, the index document, the conditions and the redirects of the routing rules,
+// the rules which never apply, and the rules which redirect to a key they match again.
+//
+// conf    the website configuration.
+//
+// error    it's nil if the configuration is valid, otherwise it's a WebsiteValidationError.
+func ValidateWebsiteConfiguration(conf WebsiteConfiguration) error {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if all := conf.WebsiteAllRequestTo; all != nil {
+		if conf.IndexDocument.Suffix != "" || conf.ErrorDocument.Key != "" || len(conf.RoutingRules) > 0 {
+			add("RedirectAllRequestsTo can't be used with IndexDocument, ErrorDocument or RoutingRules")
+		}
+		if all.HostName == "" {
+			add("RedirectAllRequestsTo has no HostName")
+		}
+		if !validWebsiteProtocol(all.Protocol) {
+			add("RedirectAllRequestsTo protocol must be http or https, got %q", all.Protocol)
+		}
+		return websiteValidationResult(problems)
+	}
+
+	if suffix := conf.IndexDocument.Suffix; suffix == "" {
+		add("no IndexDocument suffix")
+	} else if strings.Contains(suffix, "/") {
+		add("IndexDocument suffix %q can't contain '/'", suffix)
+	}
+	if strings.HasSuffix(conf.ErrorDocument.Key, "/") {
+		add("ErrorDocument key %q is a folder", conf.ErrorDocument.Key)
+	}
+	if len(conf.RoutingRules) > websiteMaxRoutingRules {
+		add("%d routing rules, at most %d are allowed", len(conf.RoutingRules), websiteMaxRoutingRules)
+	}
+
+	for i := range conf.RoutingRules {
+		rule := &conf.RoutingRules[i]
+		name := fmt.Sprintf("routing rule %d", i)
+		var cond Condition
+		if rule.Condition != nil {
+			cond = *rule.Condition
+			if cond.KeyPrefixEquals == "" && cond.HttpErrorCodeReturnedEquals == "" {
+				add("%s: condition must set KeyPrefixEquals or HttpErrorCodeReturnedEquals", name)
+			}
+			if code := cond.HttpErrorCodeReturnedEquals; code != "" {
+				if n, err := strconv.Atoi(code); err != nil || n < 400 || n > 599 {
+					add("%s: HttpErrorCodeReturnedEquals %q isn't a 4XX or 5XX status code", name, code)
+				}
+			}
+		}
+
+		redirect := rule.Redirect
+		if redirect == nil {
+			add("%s: no redirect", name)
+			continue
+		}
+		if redirect.HostName == "" && redirect.Protocol == "" && redirect.ReplaceKeyWith == "" &&
+			redirect.ReplaceKeyPrefixWith == "" {
+			add("%s: redirect must set HostName, Protocol, ReplaceKeyWith or ReplaceKeyPrefixWith", name)
+		}
+		if redirect.ReplaceKeyWith != "" && redirect.ReplaceKeyPrefixWith != "" {
+			add("%s: ReplaceKeyWith and ReplaceKeyPrefixWith can't be both set", name)
+		}
+		if !validWebsiteProtocol(redirect.Protocol) {
+			add("%s: protocol must be http or https, got %q", name, redirect.Protocol)
+		}
+		if code := redirect.HttpRedirectCode; code != "" {
+			if n, err := strconv.Atoi(code); err != nil || n < 300 || n > 399 {
+				add("%s: HttpRedirectCode %q isn't a 3XX status code", name, code)
+			}
+		}
+
+		for j := 0; j < i; j++ {
+			if earlier := conf.RoutingRules[j]; earlier.Redirect != nil && websiteRuleShadows(earlier.Condition, cond) {
+				add("%s never applies, routing rule %d matches all its requests first", name, j)
+				break
+			}
+		}
+
+		// The redirect to the same host is routed again, it loops if the rule matches the new key before the fetch
+		if redirect.HostName == "" && cond.HttpErrorCodeReturnedEquals == "" {
+			if target := websiteRedirectKey(rule, cond.KeyPrefixEquals); strings.HasPrefix(target, cond.KeyPrefixEquals) {
+				add("%s redirects to %q which it matches again", name, target)
+			}
+		}
+	}
+	return websiteValidationResult(problems)
+}
+
+// websiteRuleShadows checks if the earlier condition matches all the requests of the later one
+func websiteRuleShadows(earlier *Condition, later Condition) bool {
+	if earlier == nil {
+		return later.HttpErrorCodeReturnedEquals == ""
+	}
+	return earlier.HttpErrorCodeReturnedEquals == later.HttpErrorCodeReturnedEquals &&
+		strings.HasPrefix(later.KeyPrefixEquals, earlier.KeyPrefixEquals)
+}
+
+func validWebsiteProtocol(protocol string) bool {
+	return protocol == "" || protocol == "http" || protocol == "https"
+}
+
+func websiteValidationResult(problems []string) error {
+	if len(problems) > 0 {
+		return WebsiteValidationError{Problems: problems}
+	}
+	return nil
+}
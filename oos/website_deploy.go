@@ -0,0 +1,448 @@
+package oos
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	websiteDefaultReleasePrefix = "releases/"
+	websiteReleaseTimeFormat    = "20060102T150405Z"
+	websiteNoCache              = "no-cache"
+	websiteImmutable            = "public, max-age=31536000, immutable"
+	websiteRedirectCode         = "302" // The switch isn't cached by the browsers as the 301 redirects are
+)
+
+// WebsiteCacheRule sets the Cache-Control of the files matching the glob pattern, see SyncOptions for the patterns
+type WebsiteCacheRule struct {
+	Pattern      string // Glob pattern of the relative path, such as "assets/**" or "*.json"
+	CacheControl string // The Cache-Control header, such as "public, max-age=3600"
+}
+
+// DeployWebsiteOptions defines the options of DeployWebsite
+type DeployWebsiteOptions struct {
+	Release       string             // The release name, the UTC time such as 20240102T150405Z if it's empty. Can't contain "/".
+	ReleasePrefix string             // The key prefix of the releases, "releases/" if it's empty
+	IndexDocument string             // The index document suffix, "index.html" if it's empty
+	ErrorDocument string             // The page served for the missing keys, relative to the release, IndexDocument if it's empty
+	RoutingRules  []RoutingRule      // Extra routing rules, applied before the release rules
+	CacheControl  []WebsiteCacheRule // Cache-Control by pattern, the first match wins over the defaults
+	DefaultCache  string             // Cache-Control of the files matching no rule and no default, not set if it's empty
+	Exclude       []string           // Glob patterns of the relative paths not to deploy
+	KeepReleases  int                // The releases kept, including the new one. The ones deployed before are deleted. By default it's 2.
+	Routines      int                // Concurrent uploads. By default it's 5.
+	Options       []Option           // Options passed to every upload, such as ObjectACL
+}
+
+// DeployWebsiteResult is the summary of DeployWebsite
+type DeployWebsiteResult struct {
+	Release         string        // The deployed release
+	Prefix          string        // The key prefix of the release
+	PreviousRelease string        // The release served before, empty for the first deployment
+	Uploaded        int           // Files uploaded
+	Copied          int           // Unchanged files copied from the previous release
+	UploadedBytes   int64         // Bytes uploaded
+	DeletedReleases []string      // Old releases deleted
+	Failed          []SyncFailure // Failed uploads, copies and deletions
+	Duration        time.Duration // Time spent
+}
+
+// DeployWebsite publishes the local directory, such as the build of a single-page app, as the bucket website.
+//
+// Each deployment is a release under its own prefix, such as releases/20240102T150405Z/. The files unchanged since
+// the previous release are copied on the server, the others are uploaded with the Content-Type of their extension and
+// the Cache-Control of the rules: the HTML pages and the documents are "no-cache", the hashed assets such as
+// app.3f2a9c1e.js are immutable. The website is switched to the release only after all the files are in place, so the
+// visitors never see a partial release:
+//
+//   - the top-level files and folders of the release, such as favicon.ico and assets/, are redirected into it with
+//     302, which the browsers don't cache;
+//   - the other missing keys, such as the routes of the app and the missing keys inside the release, get the error
+//     document of the release with 404;
+//   - "/" is served by the copy of the release's index document at the root of the bucket, it's replaced right after
+//     the website configuration.
+//
+// Then the releases deployed before the last KeepReleases are deleted. Roll back with ActivateWebsiteRelease. The
+// objects at the root of the bucket take precedence over the redirects, so don't mix them with the releases.
+//
+// localDir    the local directory to publish.
+// opts    the deploy options, nil uses the defaults.
+//
+// DeployWebsiteResult    the summary.
+// error    it's nil if the release is live, otherwise it's an error object. The failed deletions of the old releases
+// are only in DeployWebsiteResult.Failed.
+func (bucket Object) DeployWebsite(localDir string, opts *DeployWebsiteOptions) (DeployWebsiteResult, error) {
+	var out DeployWebsiteResult
+	start := time.Now()
+	o, err := normalizeDeployWebsiteOptions(opts)
+	if err != nil {
+		return out, err
+	}
+	out.Release, out.Prefix = o.Release, o.ReleasePrefix+o.Release+"/"
+
+	syncOpts := &SyncOptions{Exclude: o.Exclude}
+	locals, err := listSyncFiles(localDir, syncOpts)
+	if err != nil {
+		return out, err
+	}
+	if len(locals) == 0 {
+		return out, errors.New("oos: no file to deploy in " + localDir)
+	}
+	if _, ok := locals[o.IndexDocument]; !ok {
+		return out, fmt.Errorf("oos: the index document %s isn't in %s", o.IndexDocument, localDir)
+	}
+	rels := make([]string, 0, len(locals))
+	for rel := range locals {
+		rels = append(rels, rel)
+	}
+	sort.Strings(rels)
+	// The configuration is checked before the upload, so an invalid one leaves no orphaned release
+	conf, err := websiteReleaseConfiguration(o, rels)
+	if err != nil {
+		return out, err
+	}
+
+	out.PreviousRelease, err = bucket.currentWebsiteRelease(o.ReleasePrefix)
+	if err != nil {
+		return out, err
+	}
+	previous := map[string]syncEntry{}
+	if out.PreviousRelease != "" && out.PreviousRelease != o.Release {
+		if previous, err = bucket.listSyncObjects(o.ReleasePrefix+out.PreviousRelease+"/", syncOpts); err != nil {
+			return out, err
+		}
+	}
+
+	jobs := make(chan string, len(rels))
+	for _, rel := range rels {
+		jobs <- rel
+	}
+	close(jobs)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for w := 0; w < o.Routines; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for rel := range jobs {
+				local := locals[rel]
+				action := SyncAction{Type: SyncUpload, Key: out.Prefix + rel, LocalPath: local.path, Size: local.size}
+				copied, err := bucket.deployWebsiteFile(o, rel, local, previous[rel], action.Key)
+
+				mu.Lock()
+				switch {
+				case err != nil:
+					out.Failed = append(out.Failed, SyncFailure{Action: action, Err: err})
+				case copied:
+					out.Copied++
+				default:
+					out.Uploaded++
+					out.UploadedBytes += local.size
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if len(out.Failed) > 0 {
+		out.Duration = time.Since(start)
+		return out, fmt.Errorf("oos: %d files of the release %s failed, the website isn't switched", len(out.Failed), o.Release)
+	}
+
+	if err = bucket.activateWebsiteRelease(o, conf); err != nil {
+		out.Duration = time.Since(start)
+		return out, err
+	}
+
+	out.DeletedReleases, out.Failed = bucket.deleteOldWebsiteReleases(o)
+	out.Duration = time.Since(start)
+	return out, nil
+}
+
+// ActivateWebsiteRelease switches the bucket website to the release deployed by DeployWebsite, such as to roll back to
+// the previous release.
+//
+// release    the release name.
+// opts    the options used by DeployWebsite, only the website settings are used.
+//
+// error    it's nil if no error, otherwise it's an error object.
+func (bucket Object) ActivateWebsiteRelease(release string, opts *DeployWebsiteOptions) error {
+	if release == "" {
+		return errors.New("the parameter is invalid: release is empty")
+	}
+	o := DeployWebsiteOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	o.Release = release
+	normalized, err := normalizeDeployWebsiteOptions(&o)
+	if err != nil {
+		return err
+	}
+	objects, err := bucket.listSyncObjects(normalized.ReleasePrefix+release+"/", &SyncOptions{})
+	if err != nil {
+		return err
+	}
+	if _, ok := objects[normalized.IndexDocument]; !ok {
+		return fmt.Errorf("oos: the release %s has no index document", release)
+	}
+	rels := make([]string, 0, len(objects))
+	for rel := range objects {
+		rels = append(rels, rel)
+	}
+	sort.Strings(rels)
+	conf, err := websiteReleaseConfiguration(normalized, rels)
+	if err != nil {
+		return err
+	}
+	return bucket.activateWebsiteRelease(normalized, conf)
+}
+
+// normalizeDeployWebsiteOptions fills the default values
+func normalizeDeployWebsiteOptions(opts *DeployWebsiteOptions) (*DeployWebsiteOptions, error) {
+	o := DeployWebsiteOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.Release == "" {
+		o.Release = time.Now().UTC().Format(websiteReleaseTimeFormat)
+	}
+	if strings.Contains(o.Release, "/") {
+		return nil, errors.New("the parameter is invalid: release can't contain '/'")
+	}
+	if o.ReleasePrefix == "" {
+		o.ReleasePrefix = websiteDefaultReleasePrefix
+	}
+	o.ReleasePrefix = normalizeSyncPrefix(o.ReleasePrefix)
+	if o.IndexDocument == "" {
+		o.IndexDocument = "index.html"
+	}
+	if o.ErrorDocument == "" {
+		o.ErrorDocument = o.IndexDocument
+	}
+	if o.KeepReleases < 1 {
+		o.KeepReleases = 2
+	}
+	if o.Routines < 1 {
+		o.Routines = 5
+	} else if o.Routines > 100 {
+		o.Routines = 100
+	}
+	return &o, nil
+}
+
+// deployWebsiteFile copies the file from the previous release if it's unchanged, otherwise uploads it.
+// It returns true if the file is copied.
+func (bucket Object) deployWebsiteFile(o *DeployWebsiteOptions, rel string, local, previous syncEntry, key string) (bool, error) {
+	// The Content-Type is set by the extension
+	options := addContentType(o.Options, rel)
+	if cache := websiteCacheControl(o, rel); cache != "" {
+		options = append(options, CacheControl(cache))
+	}
+
+	// The ETag of the simple upload is the content MD5
+	if previous.path != "" && previous.size == local.size && !strings.Contains(previous.etag, "-") {
+		md, err := fileMD5Hex(local.path)
+		if err != nil {
+			return false, err
+		}
+		if strings.EqualFold(md, previous.etag) {
+			// Replace the metadata, the Cache-Control rules may have changed
+			_, err = bucket.CopyObject(previous.path, key, append(options, MetadataDirective(MetaReplace))...)
+			return err == nil, err
+		}
+	}
+	return false, bucket.PutObjectFromFile(key, local.path, options...)
+}
+
+// websiteCacheControl returns the Cache-Control of the file
+func websiteCacheControl(o *DeployWebsiteOptions, rel string) string {
+	for _, rule := range o.CacheControl {
+		if matchGlob(rule.Pattern, rel) {
+			return rule.CacheControl
+		}
+	}
+	if rel == o.ErrorDocument || path.Base(rel) == o.IndexDocument || path.Ext(rel) == ".html" {
+		return websiteNoCache
+	}
+	if isHashedAsset(rel) {
+		return websiteImmutable
+	}
+	return o.DefaultCache
+}
+
+// isHashedAsset checks if the file name has the content hash of the bundlers, such as app.3f2a9c1e.js or
+// index-B2x8kQ1z.css: at least 8 letters, digits or '_' with a digit, before the extension
+func isHashedAsset(rel string) bool {
+	name := path.Base(rel)
+	name = strings.TrimSuffix(name, path.Ext(name))
+	i := strings.LastIndexAny(name, ".-")
+	if i < 0 {
+		return false
+	}
+	hash := name[i+1:]
+	if len(hash) < 8 || !strings.ContainsAny(hash, "0123456789") {
+		return false
+	}
+	for _, c := range hash {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// websiteReleaseEntries returns the top-level entries of the sorted release files, "dir/" for the folders. The entries
+// starting with an earlier one, such as app.js.map after app.js, are redirected by its rule and skipped.
+func websiteReleaseEntries(o *DeployWebsiteOptions, rels []string) ([]string, error) {
+	var entries []string
+	for _, rel := range rels {
+		entry := rel
+		if i := strings.Index(rel, "/"); i >= 0 {
+			entry = rel[:i+1]
+		}
+		if len(entries) > 0 && strings.HasPrefix(entry, entries[len(entries)-1]) {
+			continue
+		}
+		// The missing keys of the releases would be redirected into the release again
+		if strings.HasPrefix(entry, o.ReleasePrefix) || strings.HasPrefix(o.ReleasePrefix, entry) {
+			return nil, fmt.Errorf("oos: %s of the release conflicts with the release prefix %s", entry, o.ReleasePrefix)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// websiteReleaseConfiguration returns the validated website configuration serving the sorted release files: the
+// missing top-level entries are redirected into the release, the other missing keys get its error document
+func websiteReleaseConfiguration(o *DeployWebsiteOptions, rels []string) (WebsiteConfiguration, error) {
+	entries, err := websiteReleaseEntries(o, rels)
+	if err != nil {
+		return WebsiteConfiguration{}, err
+	}
+	prefix := o.ReleasePrefix + o.Release + "/"
+	conf := WebsiteConfiguration{}
+	conf.IndexDocument.Suffix = o.IndexDocument
+	conf.ErrorDocument.Key = prefix + o.ErrorDocument
+	conf.RoutingRules = append([]RoutingRule{}, o.RoutingRules...)
+	for _, entry := range entries {
+		conf.RoutingRules = append(conf.RoutingRules, RoutingRule{
+			Condition: &Condition{HttpErrorCodeReturnedEquals: "404", KeyPrefixEquals: entry},
+			Redirect:  &Redirect{ReplaceKeyPrefixWith: prefix + entry, HttpRedirectCode: websiteRedirectCode},
+		})
+	}
+	return conf, ValidateWebsiteConfiguration(conf)
+}
+
+// activateWebsiteRelease sets the website configuration of the release, then copies its index document to the root
+// of the bucket with the options of the release files, such as the ACL
+func (bucket Object) activateWebsiteRelease(o *DeployWebsiteOptions, conf WebsiteConfiguration) error {
+	if err := bucket.Bucket.SetBucketWebsite(bucket.BucketName, conf); err != nil {
+		return err
+	}
+	options := addContentType(o.Options, o.IndexDocument)
+	options = append(options, CacheControl(websiteNoCache), MetadataDirective(MetaReplace))
+	_, err := bucket.CopyObject(o.ReleasePrefix+o.Release+"/"+o.IndexDocument, o.IndexDocument, options...)
+	return err
+}
+
+// currentWebsiteRelease returns the release the website serves, the one of the error document. It's empty if the
+// website isn't deployed.
+func (bucket Object) currentWebsiteRelease(releasePrefix string) (string, error) {
+	website, err := bucket.Bucket.GetBucketWebsite(bucket.BucketName)
+	if serr, ok := err.(ServiceError); ok && serr.StatusCode == http.StatusNotFound {
+		return "", nil
+	}
+	if err != nil || website.ErrorDocument == nil {
+		return "", err
+	}
+	rest := strings.TrimPrefix(website.ErrorDocument.Key, releasePrefix)
+	if i := strings.Index(rest, "/"); i > 0 && rest != website.ErrorDocument.Key {
+		return rest[:i], nil
+	}
+	return "", nil
+}
+
+// deleteOldWebsiteReleases deletes the releases deployed before the last KeepReleases ones, the current release is
+// always kept. The deploy time of a release is the last modified time of its newest object, the unchanged files are
+// copied by each deployment too.
+func (bucket Object) deleteOldWebsiteReleases(o *DeployWebsiteOptions) ([]string, []SyncFailure) {
+	var deleted []string
+	var failed []SyncFailure
+	fail := func(key string, err error) {
+		failed = append(failed, SyncFailure{Action: SyncAction{Type: SyncDeleteObject, Key: key, Reason: "stale"}, Err: err})
+	}
+
+	var names []string
+	marker := ""
+	for {
+		lor, err := bucket.ListObjects(Prefix(o.ReleasePrefix), Delimiter("/"), Marker(marker), MaxKeys(1000))
+		if err != nil {
+			fail(o.ReleasePrefix, err)
+			return deleted, failed
+		}
+		for _, prefix := range lor.CommonPrefixes {
+			if release := strings.TrimSuffix(strings.TrimPrefix(prefix, o.ReleasePrefix), "/"); release != o.Release {
+				names = append(names, release)
+			}
+		}
+		marker = lor.NextMarker
+		if !lor.IsTruncated || marker == "" {
+			break
+		}
+	}
+
+	type websiteRelease struct {
+		name     string
+		deployed time.Time
+		keys     []string
+	}
+	var releases []websiteRelease
+	for _, name := range names {
+		objects, err := bucket.listSyncObjects(o.ReleasePrefix+name+"/", &SyncOptions{})
+		if err != nil {
+			fail(o.ReleasePrefix+name+"/", err)
+			continue
+		}
+		release := websiteRelease{name: name}
+		for _, object := range objects {
+			release.keys = append(release.keys, object.path)
+			if object.modTime.After(release.deployed) {
+				release.deployed = object.modTime
+			}
+		}
+		releases = append(releases, release)
+	}
+
+	sort.SliceStable(releases, func(i, j int) bool { return releases[i].deployed.After(releases[j].deployed) })
+	if len(releases) < o.KeepReleases {
+		return deleted, failed
+	}
+	for _, release := range releases[o.KeepReleases-1:] {
+		keys := release.keys
+		ok := true
+		for len(keys) > 0 {
+			n := len(keys)
+			if n > 1000 {
+				n = 1000
+			}
+			if _, err := bucket.DeleteObjects(keys[:n], DeleteObjectsQuiet(true)); err != nil {
+				fail(o.ReleasePrefix+release.name+"/", err)
+				ok = false
+				break
+			}
+			keys = keys[n:]
+		}
+		if ok {
+			deleted = append(deleted, release.name)
+		}
+	}
+	return deleted, failed
+}
@@ -12,10 +12,13 @@ func main() {
 	/*************** bucket test *******************/
 	sample.CreateBucketSample()
 	sample.GetBucketLocation()
+	sample.RegionRouterSample()
 	sample.BucketACLSample()
 	sample.DeleteBucketSample()
 	sample.BucketPolicySample()
+	sample.BucketPolicyDocumentSample()
 	sample.BucketWebSiteSample()
+	sample.DeployWebsiteSample()
 	sample.BucketLoggingSample()
 	sample.BucketLifecycleSample()
 	sample.BucketCorsSample()
@@ -23,20 +26,28 @@ func main() {
 
 	/*************** AccessKey test *******************/
 	sample.AccessKeySample() // 6版本 只支持 https类型的endpoint 只支持V4签名
+	sample.IAMUserSample()
+	sample.STSSample()
+	sample.RotateAccessKeySample()
 
 	/*************** object test ***************/
 	sample.PutObjectSample()
+	sample.PutObjectStreamingSample()
 	sample.DeleteObjectSample()
 	sample.ListObjectsSample()
 	sample.GetObjectSample()
 	sample.CopyObjectSample()
 	sample.SignURLSample()
+	sample.SignRequestSample()
 
 	/*************** object multipart test ***************/
 	sample.StepMultipartSample()
+	sample.AbortIncompleteUploadsSample()
+	sample.PresignedMultipartSample()
 	sample.PutObjectMultipartSample()
 	sample.GetObjectMultipartSample()
 	sample.CopyPartMultipartSample()
+	sample.SyncDirSample()
 
 	/*************** service test ***************/
 	sample.ListBucketsSample()
@@ -1,7 +1,11 @@
 package sample
 
 import (
+	"errors"
 	"fmt"
+	"time"
+
+	"oos-go-sdk/oos"
 )
 
 func AccessKeySample() {
@@ -41,3 +45,132 @@ func AccessKeySample() {
 	}
 	fmt.Printf("access key %v\n", deleteRet)
 }
+
+// IAMUserSample shows how to manage the IAM users, their groups and policies
+func IAMUserSample() {
+	client := NewIAMClient()
+	subUser := "sample-sub-user"
+	groupName := "sample-group"
+
+	user, err := client.CreateUser(subUser)
+	if err != nil {
+		HandleError(err)
+	}
+	fmt.Println("created user", user.UserName, user.Arn)
+
+	if _, err = client.CreateGroup(groupName); err != nil {
+		HandleError(err)
+	}
+	if err = client.AddUserToGroup(subUser, groupName); err != nil {
+		HandleError(err)
+	}
+
+	// Create the policy and attach it to the user
+	document := `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":["oos:GetObject"],"Resource":["arn:ctyun:oos:::` +
+		bucketName + `/*"]}]}`
+	policy, err := client.CreatePolicy("sample-read-only", document, "read the objects of the sample bucket")
+	if err != nil {
+		HandleError(err)
+	}
+	if err = client.AttachUserPolicy(subUser, policy.Arn); err != nil {
+		HandleError(err)
+	}
+
+	attached, err := client.ListAttachedUserPolicies(subUser, 0, "")
+	if err != nil {
+		HandleError(err)
+	}
+	for _, p := range attached.AttachedPolicies {
+		fmt.Println("attached policy", p.PolicyName, p.PolicyArn)
+	}
+
+	// List all users page by page
+	marker := ""
+	for {
+		users, err := client.ListUsers(100, marker)
+		if err != nil {
+			HandleError(err)
+		}
+		for _, u := range users.Users {
+			fmt.Println("user", u.UserName)
+		}
+		if !users.IsTruncated {
+			break
+		}
+		marker = users.Marker
+	}
+
+	// Clean up, the user must have no groups and policies before it's deleted
+	if err = client.DetachUserPolicy(subUser, policy.Arn); err != nil {
+		HandleError(err)
+	}
+	if err = client.RemoveUserFromGroup(subUser, groupName); err != nil {
+		HandleError(err)
+	}
+	if err = client.DeleteGroup(groupName); err != nil {
+		HandleError(err)
+	}
+	if err = client.DeleteUser(subUser); err != nil {
+		HandleError(err)
+	}
+
+	fmt.Println("IAMUserSample completed")
+}
+
+// STSSample shows how to get the temporary credentials and create the client with them
+func STSSample() {
+	client := NewIAMClient()
+
+	// The credentials of the caller, valid for an hour
+	session, err := client.GetSessionToken(3600)
+	if err != nil {
+		HandleError(err)
+	}
+	fmt.Println("session credentials expire at", session.Credentials.Expiration)
+
+	tempClient, err := session.Credentials.NewClient(endpoint, oos.V4Signature(true))
+	if err != nil {
+		HandleError(err)
+	}
+	lbr, err := tempClient.ListBuckets()
+	if err != nil {
+		HandleError(err)
+	}
+	fmt.Println("buckets listed with the temporary credentials:", len(lbr.Buckets))
+
+	// The credentials of the role, limited to reading the sample bucket
+	policy := `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":["oos:GetObject"],"Resource":["arn:ctyun:oos:::` +
+		bucketName + `/*"]}]}`
+	role, err := client.AssumeRole(roleArn, "sample-session", 900, policy)
+	if err != nil {
+		HandleError(err)
+	}
+	fmt.Println("assumed", role.AssumedRoleUser.Arn, "until", role.Credentials.Expiration)
+
+	fmt.Println("STSSample completed")
+}
+
+// RotateAccessKeySample shows how to rotate the access key of the user. Run it periodically, such as by a cron job,
+// until the rotation is done. It resumes from the state file of the previous run.
+func RotateAccessKeySample() {
+	client := NewIAMClient()
+
+	state, err := client.RotateAccessKey(oos.AccessKeyRotation{
+		OldAccessKeyId: "the access key to rotate",
+		UserName:       userName,
+		Sink:           oos.FileCredentialsSink{Path: "new-access-key.json"},
+		IdlePeriod:     24 * time.Hour,
+		DeleteAfter:    24 * time.Hour,
+	})
+	var pending *oos.RotationPendingError
+	if errors.As(err, &pending) {
+		fmt.Println("rotation is pending:", pending.Reason, "retry after", pending.RetryAt)
+		return
+	}
+	if err != nil {
+		HandleError(err)
+	}
+	fmt.Println("rotation step:", state.Step, "new access key:", state.NewAccessKeyId)
+
+	fmt.Println("RotateAccessKeySample completed")
+}
@@ -77,6 +77,9 @@ func ListBucketsSample() {
 		fmt.Printf("list bucket name :%s CreateData:%s Owner ID:%s\n", bucket.Name, bucket.CreationDate.String(),
 			lbr.Owner.ID)
 	}
+
+	// The offset between the server clock and the local clock, the signing time is corrected by it
+	fmt.Println("clock offset:", client.ClockOffset())
 }
 
 func DeleteBucketSample() {
@@ -21,6 +21,21 @@ func BucketCorsSample() {
 	rule.MaxAgeSeconds = 1000
 	corsRules := []oos.CORSRule{rule}
 
+	// Check the rules and what a browser would get before setting them
+	if err = oos.ValidateCORSRules(corsRules); err != nil {
+		HandleError(err)
+	}
+	result := oos.EvaluateCORS(corsRules, oos.CORSRequest{
+		Origin:    "http://ctyun.cn",
+		Method:    "PUT",
+		Headers:   []string{"Content-Type", "x-amz-meta-author"},
+		Preflight: true,
+	})
+	if !result.Allowed {
+		fmt.Println("The preflight is rejected:", result.Reason)
+	}
+	fmt.Println("Preflight response headers:", result.Header)
+
 	err = client.SetBucketCors(bucketName, corsRules)
 	if err != nil {
 		HandleError(err)
@@ -3,6 +3,7 @@ package sample
 import (
 	"fmt"
 	"oos-go-sdk/oos"
+	"time"
 )
 
 /*
@@ -77,6 +78,51 @@ func BucketLifecycleSample() {
 		HandleError(err)
 	}
 
+	//case 7 Abort the multipart uploads not completed in 7 days
+	var rule11 = oos.BuildLifecycleAbortIncompleteUploadRule("id5", "", true, 7)
+	rules = []oos.LifecycleRule{rule11}
+	err = client.SetBucketLifecycle(bucketName, rules)
+	if err != nil {
+		HandleError(err)
+	}
+
+	//case 8 Several actions in one rule, built and validated locally
+	rule12, err := oos.NewLifecycleRuleBuilder("logs").Prefix("logs/").
+		TransitionAfterDays(30, oos.StorageClassStandardIA).
+		ExpireAfterDays(365).
+		NoncurrentExpireAfterDays(30).
+		AbortIncompleteUploadAfterDays(7).
+		Build()
+	if err != nil {
+		HandleError(err)
+	}
+	rule13, err := oos.NewLifecycleRuleBuilder("tmp").Prefix("tmp/").Tag("temporary", "true").ExpireAfterDays(1).Build()
+	if err != nil {
+		HandleError(err)
+	}
+	rules = []oos.LifecycleRule{rule12, rule13}
+	if err = oos.ValidateLifecycleRules(rules); err != nil {
+		HandleError(err)
+	}
+
+	// Preview what the rules would do in 30 days before setting them
+	bucket, err := client.Bucket(bucketName)
+	if err != nil {
+		HandleError(err)
+	}
+	sim, err := bucket.SimulateLifecycle(rules, time.Now().AddDate(0, 0, 30), oos.Prefix("logs/"))
+	if err != nil {
+		HandleError(err)
+	}
+	fmt.Println("Lifecycle simulation:", sim)
+	for _, action := range sim.Transitioned {
+		fmt.Println(action.Key, "->", action.StorageClass, "on", action.DueDate.Format("2006-01-02"))
+	}
+	err = client.SetBucketLifecycle(bucketName, rules)
+	if err != nil {
+		HandleError(err)
+	}
+
 	// Get the bucket's lifecycle
 	gbl, err := client.GetBucketLifecycle(bucketName)
 	if err != nil {
@@ -2,6 +2,8 @@ package sample
 
 import (
 	"fmt"
+	"strings"
+
 	"oos-go-sdk/oos"
 )
 
@@ -16,3 +18,30 @@ func GetBucketLocation() {
 	fmt.Println(ret.DataLocationType == oos.DataLocationTypeSpecified)
 	fmt.Println(ret)
 }
+
+// RegionRouterSample routes the buckets to the endpoints of their metadata regions
+func RegionRouterSample() {
+	router, err := oos.NewRegionRouter(endpoint, accessKey, secretKey)
+	if err != nil {
+		HandleError(err)
+	}
+	// the buckets of the other regions use the default endpoint
+	err = router.AddRegionEndpoint("ChengDu", "http://your-chengdu-endpoint", "your-chengdu-region")
+	if err != nil {
+		HandleError(err)
+	}
+
+	// the location is got once and cached, the request is retried on the new endpoint if the bucket has moved
+	err = router.Do(bucketName, func(bucket *oos.Object) error {
+		return bucket.PutObject(objectKey, strings.NewReader("routed"))
+	})
+	if err != nil {
+		HandleError(err)
+	}
+
+	location, err := router.Location(bucketName)
+	if err != nil {
+		HandleError(err)
+	}
+	fmt.Println(bucketName, "is in", location)
+}
@@ -2,6 +2,8 @@ package sample
 
 import (
 	"fmt"
+	"oos-go-sdk/oos"
+	"time"
 )
 
 // BucketLoggingSample shows how to set, get and delete the bucket logging configuration
@@ -25,5 +27,24 @@ func BucketLoggingSample() {
 	}
 	fmt.Printf("Bucket Logging: %s \r\n", logInfo.LoggingEnabled.TargetBucket)
 
+	// Read the access logs of the last day and show the busiest keys
+	logBucket, err := client.Bucket(targetBucketName)
+	if err != nil {
+		HandleError(err)
+	}
+	reader := logBucket.NewAccessLogReader("prefix-1", time.Now().Add(-24*time.Hour), time.Now())
+	defer reader.Close()
+	stats := oos.NewAccessLogStats()
+	for reader.Next() {
+		stats.Add(reader.Record())
+	}
+	if err = reader.Err(); err != nil {
+		HandleError(err)
+	}
+	fmt.Printf("requests:%d error rate:%.2f%% bytes sent:%d\n", stats.Total.Requests, stats.Total.ErrorRate()*100, stats.Total.BytesSent)
+	for _, rank := range stats.TopKeys(10) {
+		fmt.Println(rank.Name, rank.Counter.Requests)
+	}
+
 	fmt.Println("BucketLoggingSample completed")
 }
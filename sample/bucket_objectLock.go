@@ -2,6 +2,8 @@ package sample
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"oos-go-sdk/oos"
 )
@@ -34,6 +36,45 @@ func BucketObjectLockSample() {
 		fmt.Println(out.DefaultRetention.Years)
 	}
 
+	// put an object under the GOVERNANCE retention with a legal hold
+	bucket, err := client.Bucket(bucketName)
+	if err != nil {
+		HandleError(err)
+	}
+	retainUntil := time.Now().AddDate(0, 0, 30)
+	err = bucket.PutObject(objectKey, strings.NewReader("locked"), oos.ObjectLockMode(oos.BucketModeGovernance),
+		oos.ObjectLockRetainUntilDate(retainUntil), oos.ObjectLockLegalHold(oos.LegalHoldOn))
+	if err != nil {
+		HandleError(err)
+	}
+
+	// extend the retention, shortening the GOVERNANCE retention needs BypassGovernanceRetention
+	retention := oos.ObjectRetention{Mode: oos.BucketModeGovernance, RetainUntilDate: retainUntil.AddDate(0, 0, 30)}
+	err = bucket.PutObjectRetention(objectKey, retention)
+	if err != nil {
+		HandleError(err)
+	}
+	retention, err = bucket.GetObjectRetention(objectKey)
+	if err != nil {
+		HandleError(err)
+	}
+	fmt.Println(retention.Mode, retention.RetainUntilDate)
+
+	// remove the legal hold, then the object can be deleted bypassing the GOVERNANCE retention
+	err = bucket.PutObjectLegalHold(objectKey, oos.LegalHoldOff)
+	if err != nil {
+		HandleError(err)
+	}
+	status, err := bucket.GetObjectLegalHold(objectKey)
+	if err != nil {
+		HandleError(err)
+	}
+	fmt.Println("legal hold:", status)
+	err = bucket.DeleteObject(objectKey, oos.BypassGovernanceRetention(true))
+	if err != nil {
+		HandleError(err)
+	}
+
 	//delete
 	err = client.DeleteBucketObjectLock(bucketName)
 	if err != nil {
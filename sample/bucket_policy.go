@@ -2,6 +2,7 @@ package sample
 
 import (
 	"fmt"
+	"oos-go-sdk/oos"
 	"time"
 )
 
@@ -37,3 +38,52 @@ func BucketPolicySample() {
 
 	fmt.Println("bucket website sample complete")
 }
+
+// BucketPolicyDocumentSample shows how to build, validate and set the typed bucket Policy
+func BucketPolicyDocumentSample() {
+
+	var err error
+	// New client
+	client := NewClient()
+
+	// Public read under the "public/" prefix, and hotlink protection for the rest
+	doc := oos.NewPolicyDocument(
+		oos.BuildPublicReadStatement(bucketName, "public/"),
+		oos.BuildRefererStatement(bucketName, "http://*.example.com/*"),
+	)
+
+	// Validate locally before sending, SetBucketPolicyDocument validates it too
+	if err = doc.Validate(); err != nil {
+		HandleError(err)
+	}
+
+	// Check locally what the policy would decide, nothing is sent to the server
+	eval := oos.EvaluatePolicy(doc, oos.GetBucketACLResult{}, oos.PolicyRequest{
+		Action:   "s3:GetObject",
+		Bucket:   bucketName,
+		Key:      "logs/x",
+		SourceIP: "10.0.0.5",
+	})
+	fmt.Printf("GetObject logs/x: %s (%s)\n", eval.Decision, eval.Reason)
+
+	err = client.SetBucketPolicyDocument(bucketName, doc)
+	if err != nil {
+		HandleError(err)
+	}
+
+	doc, err = client.GetBucketPolicyDocument(bucketName)
+	if err != nil {
+		HandleError(err)
+	}
+
+	for _, st := range doc.Statement {
+		fmt.Printf("statement %s: %s %v on %v\n", st.Sid, st.Effect, st.Action, st.Resource)
+	}
+
+	err = client.DeleteBucketPolicy(bucketName)
+	if err != nil {
+		HandleError(err)
+	}
+
+	fmt.Println("bucket policy document sample complete")
+}
@@ -79,6 +79,20 @@ func BucketWebSiteSample() {
 		HandleError(err)
 	}
 
+	// Check the configuration and preview how a request is routed
+	if err = oos.ValidateWebsiteConfiguration(config); err != nil {
+		HandleError(err)
+	}
+	bucket, err := client.Bucket(bucketName)
+	if err != nil {
+		HandleError(err)
+	}
+	res, err := oos.ResolveWebsite(config, "/docs/intro.html", bucket.IsObjectExist)
+	if err != nil {
+		HandleError(err)
+	}
+	fmt.Println("/docs/intro.html:", res.Result, res.StatusCode, res.Key, res.Location)
+
 	webSite, err := client.GetBucketWebsite(bucketName)
 	if err != nil {
 		HandleError(err)
@@ -95,3 +109,41 @@ func BucketWebSiteSample() {
 	}
 
 }
+
+// DeployWebsiteSample shows how to publish a local build directory as the bucket website and roll it back
+func DeployWebsiteSample() {
+	bucket, err := GetTestBucket(bucketName)
+	if err != nil {
+		HandleError(err)
+	}
+
+	// Case 1: Deploy the directory as a new release. The unchanged files are copied from the previous release,
+	// the HTML pages are no-cache and the hashed assets are immutable.
+	opts := &oos.DeployWebsiteOptions{
+		CacheControl: []oos.WebsiteCacheRule{{Pattern: "*.json", CacheControl: "public, max-age=60"}},
+		Exclude:      []string{"*.map"},
+		KeepReleases: 3,
+	}
+	res, err := bucket.DeployWebsite(localDir, opts)
+	if err != nil {
+		HandleError(err)
+	}
+	fmt.Printf("release:%s previous:%s uploaded:%d copied:%d deleted releases:%v\n",
+		res.Release, res.PreviousRelease, res.Uploaded, res.Copied, res.DeletedReleases)
+
+	// Case 2: Roll back to the previous release
+	if res.PreviousRelease != "" {
+		err = bucket.ActivateWebsiteRelease(res.PreviousRelease, opts)
+		if err != nil {
+			HandleError(err)
+		}
+	}
+
+	// Delete object and bucket
+	err = DeleteTestBucketAndObject(bucketName)
+	if err != nil {
+		HandleError(err)
+	}
+
+	fmt.Println("DeployWebsiteSample completed")
+}
@@ -55,6 +55,21 @@ func NewIAMClient() *oos.Client {
 	return client
 }
 
+// create client for a custom domain, IP endpoint or proxy, which doesn't tell the signing region
+func NewClientWithRegion(endpoint, region string) *oos.Client {
+	clientOptionV4 := oos.V4Signature(true)
+	clientOptionRegion := oos.Region(region)
+	client, err := oos.New(endpoint, accessKey, secretKey, clientOptionV4, clientOptionRegion)
+	if err != nil {
+		HandleError(err)
+	}
+	// Report the unresolvable region before the first request
+	if err = client.Config.Validate(); err != nil {
+		HandleError(err)
+	}
+	return client
+}
+
 // GetTestBucket creates the test bucket
 func GetTestBucket(bucketName string) (*oos.Object, error) {
 	// New client
@@ -27,6 +27,7 @@ const (
 	secretKey   string = "your secret key" //   your secret key
 	bucketName  string = "bucket1"
 	userName    string = "User Name"
+	roleArn     string = "your role arn"
 
 	// The object name in the sample code
 	objectKey          string = "your-object"
@@ -35,4 +36,5 @@ const (
 	// The local files to run sample code.
 	localFile          string = "file path"
 	localFileMultipart string = "lage file path"
+	localDir           string = "local dir path"
 )
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"os"
+	"time"
 
 	"oos-go-sdk/oos"
 )
@@ -97,3 +98,86 @@ func StepMultipartSample() {
 	}
 
 }
+
+// AbortIncompleteUploadsSample shows how to clean up the stale multipart uploads
+func AbortIncompleteUploadsSample() {
+	bucket, err := GetTestBucket(bucketName)
+	if err != nil {
+		HandleError(err)
+	}
+
+	// List the uploads initiated more than one day ago under the prefix without aborting them
+	report, err := bucket.AbortIncompleteUploads(24*time.Hour, "tmp/", true)
+	if err != nil {
+		HandleError(err)
+	}
+	for _, upload := range report.Matched {
+		fmt.Println("stale upload:", upload.Key, upload.UploadID, upload.Initiated)
+	}
+
+	// Abort them with 10 goroutines
+	report, err = bucket.AbortIncompleteUploads(24*time.Hour, "tmp/", false, oos.Routines(10))
+	if err != nil {
+		HandleError(err)
+	}
+	fmt.Printf("aborted %d uploads, %d failed\n", len(report.Aborted), len(report.Failed))
+
+	fmt.Println("AbortIncompleteUploadsSample completed")
+}
+
+// PresignedMultipartSample uploads the parts with the presigned URLs. The backend initiates the upload and signs
+// the URLs, the clients without the access key upload the parts and complete the upload.
+func PresignedMultipartSample() {
+	bucket, err := GetTestBucket(bucketName)
+	if err != nil {
+		HandleError(err)
+	}
+
+	// Backend: initiate the upload and sign the URLs
+	imur, err := bucket.InitiateMultipartUpload(objectKeyMultipart)
+	if err != nil {
+		HandleError(err)
+	}
+	var partSize int64 = 5 * 1024 * 1024
+	chunks, err := oos.SplitFileByPartSize(localFileMultipart, partSize)
+	if err != nil {
+		HandleError(err)
+	}
+	partURLs := make([]string, 0, len(chunks))
+	for _, chunk := range chunks {
+		signedURL, err := bucket.PresignUploadPart(imur, chunk.Number, 3600)
+		if err != nil {
+			HandleError(err)
+		}
+		partURLs = append(partURLs, signedURL)
+	}
+	completeURL, err := bucket.PresignCompleteMultipartUpload(imur, 3600)
+	if err != nil {
+		HandleError(err)
+	}
+
+	// Client: upload the parts and complete the upload without the access key
+	client, err := oos.New(endpoint, "", "")
+	if err != nil {
+		HandleError(err)
+	}
+	clientBucket, err := client.Bucket(bucketName)
+	if err != nil {
+		HandleError(err)
+	}
+	uploadParts := make([]oos.UploadPart, 0, len(chunks))
+	for i, chunk := range chunks {
+		data := readFile(localFileMultipart, chunk.Offset, chunk.Size)
+		uploadPart, err := clientBucket.UploadPartWithURL(partURLs[i], data, chunk.Size)
+		if err != nil {
+			HandleError(err)
+		}
+		uploadParts = append(uploadParts, uploadPart)
+	}
+	_, err = clientBucket.CompleteMultipartUploadWithURL(completeURL, uploadParts)
+	if err != nil {
+		HandleError(err)
+	}
+
+	fmt.Println("PresignedMultipartSample completed")
+}
@@ -3,6 +3,7 @@ package sample
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 
@@ -82,3 +83,43 @@ func PutObjectSample() {
 
 	fmt.Println("PutObjectSample completed")
 }
+
+// PutObjectStreamingSample uploads with the aws-chunked streaming signature, the payload is signed chunk by chunk
+// without the temp file, and a non-seekable reader is uploaded with the ContentLength option.
+func PutObjectStreamingSample() {
+	client, err := oos.New(endpoint, accessKey, secretKey, oos.V4Signature(true),
+		oos.EnableStreamingSignature(true), oos.StreamingChunkSize(128*1024))
+	if err != nil {
+		HandleError(err)
+	}
+	bucket, err := client.Bucket(bucketName)
+	if err != nil {
+		HandleError(err)
+	}
+
+	// Case 1: upload the local file
+	fd, err := os.Open(localFile)
+	if err != nil {
+		HandleError(err)
+	}
+	defer fd.Close()
+
+	err = bucket.PutObject(objectKey, fd)
+	if err != nil {
+		HandleError(err)
+	}
+
+	// Case 2: upload the non-seekable reader, the length is required
+	pr, pw := io.Pipe()
+	data := bytes.Repeat([]byte("streaming"), 1024*1024)
+	go func() {
+		pw.Write(data)
+		pw.Close()
+	}()
+	err = bucket.PutObject(objectKey+"-pipe", pr, oos.ContentLength(int64(len(data))))
+	if err != nil {
+		HandleError(err)
+	}
+
+	fmt.Println("PutObjectStreamingSample completed")
+}
@@ -22,6 +22,27 @@ func PutObjectMultipartSample() {
 		HandleError(err)
 	}
 
+	// Part size is 5M, 3 coroutines are used and the checkpoint is enabled. If the upload is interrupted,
+	// run it again with the same checkpoint file and it resumes from the uploaded parts.
+	err = bucket.UploadFile(objectKeyMultipart, localFileMultipart, 5*1024*1024, oos.Routines(3), oos.Checkpoint(true, localFileMultipart+".cp"))
+	if err != nil {
+		HandleError(err)
+	}
+
+	// The checkpoint file is created in the directory, and its name is derived from the local file and the object
+	err = bucket.UploadFile(objectKeyMultipart, localFileMultipart, 5*1024*1024, oos.Routines(3), oos.CheckpointDir(true, localDir))
+	if err != nil {
+		HandleError(err)
+	}
+
+	// The checkpoint is saved as an object in the bucket instead of the local file, so that the upload
+	// can resume in another container after a restart.
+	store := oos.NewObjectCheckpointStore(*bucket, "checkpoints/")
+	err = bucket.UploadFile(objectKeyMultipart, localFileMultipart, 5*1024*1024, oos.Routines(3), oos.CheckpointStore(store))
+	if err != nil {
+		HandleError(err)
+	}
+
 	// Delete object and bucket
 	err = DeleteTestBucketAndObject(bucketName)
 	if err != nil {
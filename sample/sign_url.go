@@ -3,9 +3,12 @@ package sample
 import (
 	"fmt"
 	"io/ioutil"
+	"net/http"
 	"strings"
+	"time"
 
 	"oos-go-sdk/oos"
+	"oos-go-sdk/signer"
 )
 
 // SignURLSample signs URL sample
@@ -32,6 +35,16 @@ func SignURLSample() {
 	}
 	fmt.Println(signedURL)
 
+	// Inspect the signed URL
+	info, err := oos.ParsePresignedURL(signedURL)
+	if err != nil {
+		HandleError(err)
+	}
+	fmt.Println("Version:", info.Version, "AccessKeyID:", info.AccessKeyID, "Expires:", info.Expires)
+	if info.IsExpired(time.Now().Add(time.Minute)) {
+		fmt.Println("The URL expires within a minute")
+	}
+
 	body, err := bucket.GetObjectWithURL(signedURL)
 	if err != nil {
 		HandleError(err)
@@ -57,3 +70,48 @@ func SignURLSample() {
 
 	fmt.Println("SignURLSample completed")
 }
+
+// SignRequestSample signs a plain http.Request with the signer package, without the oos client
+func SignRequestSample() {
+	creds := signer.Credentials{AccessKeyID: accessKey, AccessKeySecret: secretKey}
+
+	// V4 signature of the header
+	req, err := http.NewRequest("GET", endpoint+"/"+bucketName+"/"+objectKey, nil)
+	if err != nil {
+		HandleError(err)
+	}
+	err = signer.SignV4(req, creds, "cn", "s3", signer.EmptyPayloadHash, time.Now())
+	if err != nil {
+		HandleError(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		HandleError(err)
+	}
+	resp.Body.Close()
+	fmt.Println("SignV4 status:", resp.Status)
+
+	// V4 presigned URL
+	req, err = http.NewRequest("GET", endpoint+"/"+bucketName+"/"+objectKey, nil)
+	if err != nil {
+		HandleError(err)
+	}
+	signedURL, err := signer.PresignV4(req, creds, "cn", "s3", time.Now(), time.Hour)
+	if err != nil {
+		HandleError(err)
+	}
+	fmt.Println(signedURL)
+
+	// V2 presigned URL
+	req, err = http.NewRequest("GET", endpoint+"/"+bucketName+"/"+objectKey, nil)
+	if err != nil {
+		HandleError(err)
+	}
+	signedURL, err = signer.PresignV2(req, creds, time.Now().Add(time.Hour))
+	if err != nil {
+		HandleError(err)
+	}
+	fmt.Println(signedURL)
+
+	fmt.Println("SignRequestSample completed")
+}
@@ -0,0 +1,54 @@
+package sample
+
+import (
+	"fmt"
+
+	"oos-go-sdk/oos"
+)
+
+// SyncDirSample shows how to mirror a local directory with a prefix in both directions
+func SyncDirSample() {
+	bucket, err := GetTestBucket(bucketName)
+	if err != nil {
+		HandleError(err)
+	}
+
+	// Case 1: Preview what would be uploaded and deleted, nothing is changed.
+	opts := &oos.SyncOptions{
+		Exclude: []string{"*.tmp", ".git/**"},
+		Delete:  true,
+		DryRun:  true,
+	}
+	res, err := bucket.SyncUp(localDir, "artifacts/", opts)
+	if err != nil {
+		HandleError(err)
+	}
+	for _, action := range res.Actions {
+		fmt.Println(action.Type, action.Key, action.Reason)
+	}
+
+	// Case 2: Upload the differences with 5 coroutines, compared by MD5.
+	opts.DryRun = false
+	opts.CompareMD5 = true
+	opts.Routines = 5
+	res, err = bucket.SyncUp(localDir, "artifacts/", opts)
+	if err != nil {
+		HandleError(err)
+	}
+	fmt.Printf("uploaded:%d deleted:%d unchanged:%d failed:%d\n", res.Uploaded, res.Deleted, res.Unchanged, len(res.Failed))
+
+	// Case 3: Download the prefix to another directory.
+	res, err = bucket.SyncDown("artifacts/", localDir+"-copy", &oos.SyncOptions{Routines: 5})
+	if err != nil {
+		HandleError(err)
+	}
+	fmt.Printf("downloaded:%d bytes:%d\n", res.Downloaded, res.TransferredBytes)
+
+	// Delete object and bucket
+	err = DeleteTestBucketAndObject(bucketName)
+	if err != nil {
+		HandleError(err)
+	}
+
+	fmt.Println("SyncDirSample completed")
+}
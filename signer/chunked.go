@@ -0,0 +1,194 @@
+package signer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Streaming V4 signature constants
+const (
+	StreamingPayload           = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD"
+	HeaderContentEncoding      = "Content-Encoding"
+	HeaderContentLength        = "Content-Length"
+	HeaderDecodedContentLength = "X-Amz-Decoded-Content-Length"
+	DefaultChunkSize           = 64 * 1024 // The default chunk size, it must be at least 8KB except for the last chunk
+	algorithmChunk             = "AWS4-HMAC-SHA256-PAYLOAD"
+	chunkSignaturePrefix       = ";chunk-signature="
+)
+
+// ChunkedContentLength returns the length of the aws-chunked body, including the final empty chunk.
+//
+// decodedLength    the length of the payload.
+// chunkSize    the size of the chunks except the last one.
+func ChunkedContentLength(decodedLength, chunkSize int64) int64 {
+	full, rest := decodedLength/chunkSize, decodedLength%chunkSize
+	length := full*chunkLength(chunkSize) + chunkLength(0)
+	if rest > 0 {
+		length += chunkLength(rest)
+	}
+	return length
+}
+
+// chunkLength is the length of "<hex size>;chunk-signature=<sig>\r\n<data>\r\n"
+func chunkLength(size int64) int64 {
+	return int64(len(strconv.FormatInt(size, 16))+len(chunkSignaturePrefix)+64+2) + size + 2
+}
+
+// chunkedReader encodes the payload in aws-chunked format, each chunk is signed with the previous signature
+type chunkedReader struct {
+	body       io.Reader
+	remaining  int64
+	buf        []byte
+	pending    []byte
+	out        []byte
+	signingKey []byte
+	date       string
+	scope      string
+	signature  string
+	done       bool
+}
+
+// NewChunkedReaderV4 returns the reader of the aws-chunked body. The chunks are signed in a chain starting with
+// the seed signature, which is the V4 signature of the request headers with the payload STREAMING-AWS4-HMAC-SHA256-PAYLOAD.
+// The payload is read chunk by chunk, so a non-seekable reader is fine, but it must have exactly decodedLength bytes.
+//
+// body    the payload.
+// decodedLength    the length of the payload.
+// chunkSize    the size of the chunks except the last one.
+// signingKey    the key returned by SigningKeyV4.
+// t    the signing time of the request.
+// scope    the credential scope returned by ScopeV4.
+// seedSignature    the signature of the request.
+func NewChunkedReaderV4(body io.Reader, decodedLength int64, chunkSize int, signingKey []byte, t time.Time,
+	scope, seedSignature string) io.Reader {
+	return &chunkedReader{
+		body:       body,
+		remaining:  decodedLength,
+		buf:        make([]byte, chunkSize),
+		signingKey: signingKey,
+		date:       t.UTC().Format(TimeFormatV4),
+		scope:      scope,
+		signature:  seedSignature,
+	}
+}
+
+// Read implements io.Reader
+func (r *chunkedReader) Read(p []byte) (int, error) {
+	for len(r.pending) == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		if err := r.nextChunk(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+	return n, nil
+}
+
+func (r *chunkedReader) nextChunk() error {
+	size := int64(len(r.buf))
+	if r.remaining < size {
+		size = r.remaining
+	}
+	data := r.buf[:size]
+	if size > 0 {
+		if _, err := io.ReadFull(r.body, data); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return err
+		}
+	}
+	r.remaining -= size
+	r.done = size == 0
+
+	sum := sha256.Sum256(data)
+	stringToSign := algorithmChunk + "\n" + r.date + "\n" + r.scope + "\n" + r.signature + "\n" +
+		EmptyPayloadHash + "\n" + hex.EncodeToString(sum[:])
+	r.signature = SignatureV4(r.signingKey, stringToSign)
+
+	r.out = append(r.out[:0], strconv.FormatInt(size, 16)...)
+	r.out = append(r.out, chunkSignaturePrefix...)
+	r.out = append(r.out, r.signature...)
+	r.out = append(r.out, "\r\n"...)
+	r.out = append(r.out, data...)
+	r.out = append(r.out, "\r\n"...)
+	r.pending = r.out
+	return nil
+}
+
+// SignV4Streaming signs the request in V4 format with the aws-chunked payload. It sets Content-Encoding,
+// X-Amz-Decoded-Content-Length and X-Amz-Content-Sha256, signs the headers, then replaces the body with
+// the signed chunks and sets the encoded Content-Length.
+//
+// req    the request to sign, req.ContentLength is the payload length.
+// creds    the access key.
+// region    the signing region such as cn.
+// service    the signing service such as s3.
+// t    the signing time.
+// chunkSize    the chunk size, DefaultChunkSize if it's 0. It must be at least 8KB.
+//
+// error    it's nil if no error, otherwise it's an error object.
+func SignV4Streaming(req *http.Request, creds Credentials, region, service string, t time.Time, chunkSize int) error {
+	if chunkSize == 0 {
+		chunkSize = DefaultChunkSize
+	}
+	if chunkSize < 8*1024 {
+		return errors.New("signer: chunk size must be at least 8KB")
+	}
+	if req.ContentLength < 0 || (req.ContentLength > 0 && req.Body == nil) {
+		return errors.New("signer: the content length of the streaming payload must be known")
+	}
+	if req.Header == nil {
+		req.Header = make(http.Header)
+	}
+
+	decodedLength := req.ContentLength
+	encodedLength := ChunkedContentLength(decodedLength, int64(chunkSize))
+	if enc := req.Header.Get(HeaderContentEncoding); enc != "" && !strings.HasPrefix(enc, "aws-chunked") {
+		req.Header.Set(HeaderContentEncoding, "aws-chunked,"+enc)
+	} else {
+		req.Header.Set(HeaderContentEncoding, "aws-chunked")
+	}
+	req.Header.Set(HeaderDecodedContentLength, strconv.FormatInt(decodedLength, 10))
+	req.Header.Set(HeaderContentLength, strconv.FormatInt(encodedLength, 10))
+	req.Header.Set(HeaderContentSHA256, StreamingPayload)
+
+	if err := SignV4(req, creds, region, service, StreamingPayload, t); err != nil {
+		return err
+	}
+	auth := req.Header.Get(HeaderAuthorization)
+	seedSignature := auth[strings.LastIndex(auth, "=")+1:]
+
+	var body io.Reader = http.NoBody
+	if req.Body != nil {
+		body = req.Body
+	}
+	req.Body = struct {
+		io.Reader
+		io.Closer
+	}{NewChunkedReaderV4(body, decodedLength, chunkSize, SigningKeyV4(creds.AccessKeySecret, t, region, service),
+		t, ScopeV4(t, region, service), seedSignature), closerOf(req.Body)}
+	req.ContentLength = encodedLength
+	req.GetBody = nil
+	return nil
+}
+
+type nopCloser struct{}
+
+func (nopCloser) Close() error { return nil }
+
+func closerOf(rc io.ReadCloser) io.Closer {
+	if rc == nil {
+		return nopCloser{}
+	}
+	return rc
+}
@@ -0,0 +1,212 @@
+// Package signer signs plain *http.Request values with the V2 (AWS) and V4 (AWS4-HMAC-SHA256) signatures
+// accepted by oos, without the oos client. Reverse proxies and gateways can use it to sign the requests
+// they forward, and to verify the requests they receive.
+//
+// The requests are path-style, such as https://oos-cn.ctyunapi.cn/bucket/key, like the requests of the oos client.
+package signer
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Credentials is the access key used to sign the requests
+type Credentials struct {
+	AccessKeyID     string // Access key ID
+	AccessKeySecret string // Access key secret
+	SecurityToken   string // STS token, optional
+}
+
+// Header and query names used by the signatures
+const (
+	HeaderAuthorization  = "Authorization"
+	HeaderDate           = "Date"
+	HeaderContentMD5     = "Content-Md5"
+	HeaderContentType    = "Content-Type"
+	HeaderHost           = "Host"
+	HeaderAmzDate        = "X-Amz-Date"
+	HeaderContentSHA256  = "X-Amz-Content-Sha256"
+	HeaderSecurityToken  = "X-Amz-Security-Token"
+	ParamExpires         = "Expires"
+	ParamAWSAccessKeyID  = "AWSAccessKeyId"
+	ParamSignature       = "Signature"
+	ParamSecurityToken   = "security-token"
+	ParamAlgorithm       = "X-Amz-Algorithm"
+	ParamCredential      = "X-Amz-Credential"
+	ParamDate            = "X-Amz-Date"
+	ParamExpiresV4       = "X-Amz-Expires"
+	ParamSignedHeaders   = "X-Amz-SignedHeaders"
+	ParamSignatureV4     = "X-Amz-Signature"
+	ParamSecurityTokenV4 = "X-Amz-Security-Token"
+)
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// shouldEncode reports if the byte is escaped by uriEncode, the unreserved characters are kept
+func shouldEncode(c byte, keepSlash bool) bool {
+	if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' {
+		return false
+	}
+	switch c {
+	case '-', '_', '.', '~':
+		return false
+	case '/':
+		return !keepSlash
+	}
+	return true
+}
+
+// uriEncode escapes the string like the oos client does, "/" is kept in the paths and escaped in the queries
+func uriEncode(s string, keepSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if shouldEncode(c, keepSlash) {
+			b.WriteByte('%')
+			b.WriteByte("0123456789ABCDEF"[c>>4])
+			b.WriteByte("0123456789ABCDEF"[c&15])
+		} else {
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// queryParam is a query parameter in the order of the raw query
+type queryParam struct {
+	Key      string
+	Value    string
+	HasValue bool // "key=value" or "key=", false for "key"
+}
+
+// parseQuery parses the raw query and keeps the parameters without "=", which url.ParseQuery can't tell
+func parseQuery(rawQuery string) []queryParam {
+	var params []queryParam
+	for _, kv := range strings.Split(rawQuery, "&") {
+		if kv == "" {
+			continue
+		}
+		p := queryParam{}
+		k, v := kv, ""
+		if i := strings.Index(kv, "="); i >= 0 {
+			k, v, p.HasValue = kv[:i], kv[i+1:], true
+		}
+		p.Key = unescapeQuery(k)
+		p.Value = unescapeQuery(v)
+		params = append(params, p)
+	}
+	return params
+}
+
+func unescapeQuery(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '%' && i+2 < len(s) && isHex(s[i+1]) && isHex(s[i+2]) {
+			b.WriteByte(unhex(s[i+1])<<4 | unhex(s[i+2]))
+			i += 2
+			continue
+		}
+		if c == '+' {
+			c = ' '
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+func unhex(c byte) byte {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0'
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10
+	}
+	return c - 'A' + 10
+}
+
+// encodeQuery serializes the parameters sorted by key then value, both escaped
+func encodeQuery(params []queryParam) string {
+	escaped := make([][2]string, 0, len(params))
+	for _, p := range params {
+		escaped = append(escaped, [2]string{uriEncode(p.Key, false), uriEncode(p.Value, false)})
+	}
+	// Sorting the "key=value" pairs would put "a-b=1" before "a=2"
+	sort.Slice(escaped, func(i, j int) bool {
+		if escaped[i][0] != escaped[j][0] {
+			return escaped[i][0] < escaped[j][0]
+		}
+		return escaped[i][1] < escaped[j][1]
+	})
+	pairs := make([]string, 0, len(escaped))
+	for _, kv := range escaped {
+		pairs = append(pairs, kv[0]+"="+kv[1])
+	}
+	return strings.Join(pairs, "&")
+}
+
+// setQuery replaces the parameters of the raw query with set and removes the del ones. The other
+// parameters are kept, "key" stays without "=" so that it's still a V2 subresource.
+func setQuery(rawQuery string, set map[string]string, del ...string) string {
+	var params []queryParam
+	for _, p := range parseQuery(rawQuery) {
+		if _, ok := set[p.Key]; ok {
+			continue
+		}
+		deleted := false
+		for _, k := range del {
+			deleted = deleted || p.Key == k
+		}
+		if !deleted {
+			params = append(params, p)
+		}
+	}
+	for k, v := range set {
+		params = append(params, queryParam{Key: k, Value: v, HasValue: true})
+	}
+
+	pairs := make([]string, 0, len(params))
+	for _, p := range params {
+		if p.HasValue {
+			pairs = append(pairs, uriEncode(p.Key, false)+"="+uriEncode(p.Value, false))
+		} else {
+			pairs = append(pairs, uriEncode(p.Key, false))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// requestHost returns the host of the request, the Host header set by the oos client wins
+func requestHost(req *http.Request) string {
+	if h := req.Header.Get(HeaderHost); h != "" {
+		return h
+	}
+	if req.Host != "" {
+		return req.Host
+	}
+	return req.URL.Host
+}
+
+// canonicalPath returns the escaped path of the request, "/" if it's empty
+func canonicalPath(req *http.Request) string {
+	path := req.URL.Path
+	if path == "" {
+		path = req.URL.Opaque
+	}
+	if path == "" {
+		return "/"
+	}
+	return uriEncode(path, true)
+}
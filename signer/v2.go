@@ -0,0 +1,149 @@
+package signer
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SubResourcesV2 are the query parameters signed in the V2 canonical resource, the oos client signs with this list
+var SubResourcesV2 = []string{"acl", "torrent", "logging", "location", "policy", "requestPayment", "versioning",
+	"versions", "versionId", "notification", "uploadId", "uploads", "partNumber", "website",
+	"delete", "lifecycle", "tagging", "cors", "restore", "retention", "legal-hold", "response-cache-control",
+	"response-content-disposition", "response-content-type", "response-content-language", "response-content-encoding",
+	"response-expires"}
+
+func isSubResourceV2(key string) bool {
+	for _, k := range SubResourcesV2 {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// CanonicalResourceV2 returns the V2 canonical resource of the request: the escaped path and the sorted
+// subresources, such as /bucket/key?acl or /bucket/key?partNumber=1&uploadId=xxx. The subresource values are not escaped.
+func CanonicalResourceV2(req *http.Request) string {
+	var subs []string
+	for _, p := range parseQuery(req.URL.RawQuery) {
+		if !isSubResourceV2(p.Key) {
+			continue
+		}
+		if p.HasValue {
+			subs = append(subs, p.Key+"="+p.Value)
+		} else {
+			subs = append(subs, p.Key)
+		}
+	}
+	sort.Strings(subs)
+
+	resource := canonicalPath(req)
+	if len(subs) > 0 {
+		resource += "?" + strings.Join(subs, "&")
+	}
+	return resource
+}
+
+// StringToSignV2 builds the V2 string to sign of the request. The X-Amz-* headers are lowercased and sorted,
+// only the first value is signed.
+//
+// req    the request.
+// date    the Date header, or the Expires timestamp of the presigned URL.
+func StringToSignV2(req *http.Request, date string) string {
+	amzHeaders := map[string]string{}
+	var keys []string
+	for k, v := range req.Header {
+		lk := strings.ToLower(k)
+		if strings.HasPrefix(lk, "x-amz-") && len(v) > 0 {
+			if _, ok := amzHeaders[lk]; !ok {
+				keys = append(keys, lk)
+			}
+			amzHeaders[lk] = v[0]
+		}
+	}
+	sort.Strings(keys)
+
+	var canonicalHeaders strings.Builder
+	for _, k := range keys {
+		canonicalHeaders.WriteString(k + ":" + amzHeaders[k] + "\n")
+	}
+
+	// the oos client sets the non-canonical Content-MD5 key
+	contentMD5 := req.Header.Get(HeaderContentMD5)
+	if v := req.Header["Content-MD5"]; contentMD5 == "" && len(v) > 0 {
+		contentMD5 = v[0]
+	}
+	return req.Method + "\n" + contentMD5 + "\n" + req.Header.Get(HeaderContentType) + "\n" + date + "\n" +
+		canonicalHeaders.String() + CanonicalResourceV2(req)
+}
+
+// SignatureV2 returns the base64 V2 signature of the string to sign.
+func SignatureV2(secret, stringToSign string) string {
+	h := hmac.New(sha1.New, []byte(secret))
+	h.Write([]byte(stringToSign))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// SignV2 signs the request in V2 format and sets the Authorization header. It sets the Date header
+// if it's not set, and X-Amz-Security-Token if the credentials have a token.
+//
+// req    the request to sign.
+// creds    the access key.
+//
+// error    it's nil if no error, otherwise it's an error object.
+func SignV2(req *http.Request, creds Credentials) error {
+	if creds.AccessKeyID == "" || creds.AccessKeySecret == "" {
+		return errors.New("signer: access key id or secret is empty")
+	}
+	if req.Header == nil {
+		req.Header = make(http.Header)
+	}
+
+	req.Header.Del(HeaderAuthorization)
+	if req.Header.Get(HeaderDate) == "" {
+		req.Header.Set(HeaderDate, time.Now().UTC().Format(http.TimeFormat))
+	}
+	if creds.SecurityToken != "" {
+		req.Header.Set(HeaderSecurityToken, creds.SecurityToken)
+	}
+
+	signature := SignatureV2(creds.AccessKeySecret, StringToSignV2(req, req.Header.Get(HeaderDate)))
+	req.Header.Set(HeaderAuthorization, "AWS "+creds.AccessKeyID+":"+signature)
+	return nil
+}
+
+// PresignV2 presigns the request in V2 format. The Expires, AWSAccessKeyId and Signature parameters are
+// added to the query of req.URL, and security-token if the credentials have a token.
+//
+// req    the request to presign.
+// creds    the access key.
+// expires    the expiration time of the URL.
+//
+// string    the presigned URL, only valid when error is nil.
+// error    it's nil if no error, otherwise it's an error object.
+func PresignV2(req *http.Request, creds Credentials, expires time.Time) (string, error) {
+	if creds.AccessKeyID == "" || creds.AccessKeySecret == "" {
+		return "", errors.New("signer: access key id or secret is empty")
+	}
+	if req.Header == nil {
+		req.Header = make(http.Header)
+	}
+
+	expiresStr := strconv.FormatInt(expires.Unix(), 10)
+	set := map[string]string{ParamExpires: expiresStr, ParamAWSAccessKeyID: creds.AccessKeyID}
+	if creds.SecurityToken != "" {
+		set[ParamSecurityToken] = creds.SecurityToken
+	}
+	req.URL.RawQuery = setQuery(req.URL.RawQuery, set, ParamSignature)
+
+	signature := SignatureV2(creds.AccessKeySecret, StringToSignV2(req, expiresStr))
+	req.URL.RawQuery += "&" + ParamSignature + "=" + uriEncode(signature, false)
+	return req.URL.String(), nil
+}
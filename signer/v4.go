@@ -0,0 +1,201 @@
+package signer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// V4 signature constants
+const (
+	AlgorithmV4      = "AWS4-HMAC-SHA256"
+	UnsignedPayload  = "UNSIGNED-PAYLOAD"
+	EmptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	TimeFormatV4     = "20060102T150405Z"
+	DateFormatV4     = "20060102"
+	scopeTerminator  = "aws4_request"
+)
+
+// ignoredHeadersV4 are not signed, the proxies and the HTTP client may change them
+var ignoredHeadersV4 = map[string]bool{
+	"authorization":   true,
+	"user-agent":      true,
+	"x-amzn-trace-id": true,
+	"expect":          true,
+	"connection":      true,
+}
+
+// SigningKeyV4 derives the V4 signing key of the day, region and service.
+func SigningKeyV4(secret string, t time.Time, region, service string) []byte {
+	dateKey := hmacSHA256([]byte("AWS4"+secret), t.UTC().Format(DateFormatV4))
+	regionKey := hmacSHA256(dateKey, region)
+	serviceKey := hmacSHA256(regionKey, service)
+	return hmacSHA256(serviceKey, scopeTerminator)
+}
+
+// ScopeV4 returns the credential scope such as 20150830/cn/s3/aws4_request.
+func ScopeV4(t time.Time, region, service string) string {
+	return t.UTC().Format(DateFormatV4) + "/" + region + "/" + service + "/" + scopeTerminator
+}
+
+// SignedHeadersV4 returns the sorted lowercase names of the headers signed by SignV4: host and all the
+// headers of the request except Authorization, User-Agent, X-Amzn-Trace-Id, Expect and Connection.
+func SignedHeadersV4(req *http.Request) []string {
+	names := []string{"host"}
+	for k := range req.Header {
+		lk := strings.ToLower(k)
+		if lk == "host" || ignoredHeadersV4[lk] {
+			continue
+		}
+		names = append(names, lk)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// canonicalHeaderValue trims the values, collapses the inner spaces and joins them with commas
+func canonicalHeaderValue(values []string) string {
+	vs := make([]string, 0, len(values))
+	for _, v := range values {
+		vs = append(vs, strings.Join(strings.Fields(v), " "))
+	}
+	return strings.Join(vs, ",")
+}
+
+// CanonicalRequestV4 builds the V4 canonical request of the signed headers. The query parameter
+// X-Amz-Signature is excluded, so it can rebuild the canonical request of a presigned URL.
+//
+// req    the request.
+// signedHeaders    the sorted lowercase header names, such as the result of SignedHeadersV4.
+// payloadHash    the hex SHA256 of the body, or UNSIGNED-PAYLOAD.
+func CanonicalRequestV4(req *http.Request, signedHeaders []string, payloadHash string) string {
+	var params []queryParam
+	for _, p := range parseQuery(req.URL.RawQuery) {
+		if p.Key != ParamSignatureV4 {
+			params = append(params, p)
+		}
+	}
+
+	var headers strings.Builder
+	for _, name := range signedHeaders {
+		value := ""
+		if name == "host" {
+			value = requestHost(req)
+		} else {
+			value = canonicalHeaderValue(req.Header[http.CanonicalHeaderKey(name)])
+		}
+		headers.WriteString(name + ":" + value + "\n")
+	}
+
+	return req.Method + "\n" +
+		canonicalPath(req) + "\n" +
+		encodeQuery(params) + "\n" +
+		headers.String() + "\n" +
+		strings.Join(signedHeaders, ";") + "\n" +
+		payloadHash
+}
+
+// StringToSignV4 builds the V4 string to sign of the canonical request.
+func StringToSignV4(t time.Time, scope, canonicalRequest string) string {
+	sum := sha256.Sum256([]byte(canonicalRequest))
+	return AlgorithmV4 + "\n" + t.UTC().Format(TimeFormatV4) + "\n" + scope + "\n" + hex.EncodeToString(sum[:])
+}
+
+// SignatureV4 returns the hex V4 signature of the string to sign.
+func SignatureV4(signingKey []byte, stringToSign string) string {
+	return hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+}
+
+// SignV4 signs the request in V4 format and sets the Authorization header. It sets X-Amz-Date, and
+// X-Amz-Security-Token if the credentials have a token. For the s3 service it sets X-Amz-Content-Sha256
+// to the payload hash if it's not set.
+//
+// req    the request to sign, the headers set after signing must not be signed ones.
+// creds    the access key.
+// region    the signing region such as cn.
+// service    the signing service such as s3 or sts.
+// payloadHash    the hex SHA256 of the body, EmptyPayloadHash for no body, UNSIGNED-PAYLOAD if it's empty.
+// t    the signing time.
+//
+// error    it's nil if no error, otherwise it's an error object.
+func SignV4(req *http.Request, creds Credentials, region, service, payloadHash string, t time.Time) error {
+	if creds.AccessKeyID == "" || creds.AccessKeySecret == "" {
+		return errors.New("signer: access key id or secret is empty")
+	}
+	if payloadHash == "" {
+		payloadHash = UnsignedPayload
+	}
+	if req.Header == nil {
+		req.Header = make(http.Header)
+	}
+
+	t = t.UTC()
+	req.Header.Del(HeaderAuthorization)
+	req.Header.Set(HeaderAmzDate, t.Format(TimeFormatV4))
+	if creds.SecurityToken != "" {
+		req.Header.Set(HeaderSecurityToken, creds.SecurityToken)
+	}
+	if service == "s3" && req.Header.Get(HeaderContentSHA256) == "" {
+		req.Header.Set(HeaderContentSHA256, payloadHash)
+	}
+
+	signedHeaders := SignedHeadersV4(req)
+	scope := ScopeV4(t, region, service)
+	stringToSign := StringToSignV4(t, scope, CanonicalRequestV4(req, signedHeaders, payloadHash))
+	signature := SignatureV4(SigningKeyV4(creds.AccessKeySecret, t, region, service), stringToSign)
+
+	req.Header.Set(HeaderAuthorization, fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		AlgorithmV4, creds.AccessKeyID, scope, strings.Join(signedHeaders, ";"), signature))
+	return nil
+}
+
+// PresignV4 presigns the request in V4 format. The signature parameters are added to the query of req.URL,
+// the payload is UNSIGNED-PAYLOAD.
+//
+// req    the request to presign, the signed headers are host and the headers of the request.
+// creds    the access key, the token is added as X-Amz-Security-Token.
+// region    the signing region such as cn.
+// service    the signing service such as s3.
+// t    the signing time.
+// expires    the validity period from t, 1 second to 7 days.
+//
+// string    the presigned URL, only valid when error is nil.
+// error    it's nil if no error, otherwise it's an error object.
+func PresignV4(req *http.Request, creds Credentials, region, service string, t time.Time, expires time.Duration) (string, error) {
+	if creds.AccessKeyID == "" || creds.AccessKeySecret == "" {
+		return "", errors.New("signer: access key id or secret is empty")
+	}
+	if expires < time.Second || expires > 7*24*time.Hour {
+		return "", errors.New("signer: expires must be between 1 second and 7 days")
+	}
+	if req.Header == nil {
+		req.Header = make(http.Header)
+	}
+
+	t = t.UTC()
+	signedHeaders := SignedHeadersV4(req)
+	scope := ScopeV4(t, region, service)
+
+	set := map[string]string{
+		ParamAlgorithm:     AlgorithmV4,
+		ParamCredential:    creds.AccessKeyID + "/" + scope,
+		ParamDate:          t.Format(TimeFormatV4),
+		ParamExpiresV4:     strconv.FormatInt(int64(expires/time.Second), 10),
+		ParamSignedHeaders: strings.Join(signedHeaders, ";"),
+	}
+	if creds.SecurityToken != "" {
+		set[ParamSecurityTokenV4] = creds.SecurityToken
+	}
+	req.URL.RawQuery = setQuery(req.URL.RawQuery, set, ParamSignatureV4)
+
+	stringToSign := StringToSignV4(t, scope, CanonicalRequestV4(req, signedHeaders, UnsignedPayload))
+	signature := SignatureV4(SigningKeyV4(creds.AccessKeySecret, t, region, service), stringToSign)
+	req.URL.RawQuery += "&" + ParamSignatureV4 + "=" + signature
+	return req.URL.String(), nil
+}
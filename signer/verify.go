@@ -0,0 +1,443 @@
+package signer
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Error codes of VerifyError, the same as the oos service
+const (
+	ErrCodeAccessDenied              = "AccessDenied"
+	ErrCodeInvalidAccessKeyID        = "InvalidAccessKeyId"
+	ErrCodeSignatureDoesNotMatch     = "SignatureDoesNotMatch"
+	ErrCodeRequestTimeTooSkewed      = "RequestTimeTooSkewed"
+	ErrCodeAuthorizationMalformed    = "AuthorizationHeaderMalformed"
+	ErrCodeAuthorizationQueryInvalid = "AuthorizationQueryParametersError"
+)
+
+// DefaultMaxSkew is the default allowed difference between the request time and the verifier clock
+const DefaultMaxSkew = 15 * time.Minute
+
+// SecretLookupFunc returns the secret of the access key ID, or an error if the key is unknown or disabled
+type SecretLookupFunc func(accessKeyID string) (string, error)
+
+// VerifyError is returned by Verify when the request is not authenticated.
+// The mismatch details are set for the SignatureDoesNotMatch error.
+type VerifyError struct {
+	Code             string // Error code such as SignatureDoesNotMatch
+	Message          string // Error detail
+	AccessKeyID      string // Access key ID of the request, empty if it can't be parsed
+	Provided         string // Signature of the request
+	Expected         string // Signature calculated by the verifier
+	StringToSign     string // String to sign calculated by the verifier
+	CanonicalRequest string // V4 canonical request calculated by the verifier
+}
+
+// Error implements the error interface
+func (e *VerifyError) Error() string {
+	return fmt.Sprintf("signer: %s: %s", e.Code, e.Message)
+}
+
+func newVerifyError(code, accessKeyID, format string, args ...interface{}) *VerifyError {
+	return &VerifyError{Code: code, AccessKeyID: accessKeyID, Message: fmt.Sprintf(format, args...)}
+}
+
+// VerifyResult is the authenticated identity of the request
+type VerifyResult struct {
+	AccessKeyID   string    // Access key ID of the signature
+	SecurityToken string    // STS token of the request, the caller checks it
+	Version       string    // V2 or V4
+	Presigned     bool      // The signature is in the query instead of the Authorization header
+	SignedAt      time.Time // Date of the signature, zero for the V2 presigned URLs
+	Expires       time.Time // Expiration of the presigned URL, zero for the header signatures
+	Region        string    // V4 signing region
+	Service       string    // V4 signing service
+	SignedHeaders []string  // V4 signed headers
+}
+
+// Verifier authenticates the incoming requests signed by the oos client or by this package. It supports
+// the V2 and V4 signatures in the Authorization header and in presigned URLs.
+type Verifier struct {
+	Lookup  SecretLookupFunc // Returns the secret of the access key ID
+	MaxSkew time.Duration    // Allowed clock skew, DefaultMaxSkew if it's 0
+	Now     func() time.Time // Clock of the verifier, time.Now if it's nil
+}
+
+// NewVerifier creates the verifier with the secret lookup function and the default clock skew.
+func NewVerifier(lookup SecretLookupFunc) *Verifier {
+	return &Verifier{Lookup: lookup, MaxSkew: DefaultMaxSkew}
+}
+
+func (v *Verifier) now() time.Time {
+	if v.Now != nil {
+		return v.Now()
+	}
+	return time.Now()
+}
+
+func (v *Verifier) maxSkew() time.Duration {
+	if v.MaxSkew > 0 {
+		return v.MaxSkew
+	}
+	return DefaultMaxSkew
+}
+
+// Verify checks the signature, the expiration and the clock skew of the request.
+//
+// The canonical request is rebuilt as the oos client builds it. The bucket-level requests to IP endpoints are
+// sent to /bucket/ but signed as /bucket, so a path with a trailing slash is also tried without it.
+// The body of a V4 request is read to calculate the payload hash only if X-Amz-Content-Sha256 is not set,
+// and it's restored for the handler. The payload hash in the header is not checked against the body.
+//
+// req    the incoming request, such as the request of an http.Handler.
+//
+// *VerifyResult    the access key ID and the signature details, only valid when error is nil.
+// error    it's nil if the request is authenticated, otherwise it's a *VerifyError or the lookup error.
+func (v *Verifier) Verify(req *http.Request) (*VerifyResult, error) {
+	if v.Lookup == nil {
+		return nil, fmt.Errorf("signer: the secret lookup function is not set")
+	}
+
+	auth := req.Header.Get(HeaderAuthorization)
+	query := parseQuery(req.URL.RawQuery)
+	switch {
+	case strings.HasPrefix(auth, AlgorithmV4+" "):
+		return v.verifyHeaderV4(req, strings.TrimPrefix(auth, AlgorithmV4+" "))
+	case strings.HasPrefix(auth, "AWS "):
+		return v.verifyHeaderV2(req, strings.TrimPrefix(auth, "AWS "))
+	case auth != "":
+		return nil, newVerifyError(ErrCodeAuthorizationMalformed, "", "unsupported authorization %q", auth)
+	case hasParam(query, ParamAlgorithm):
+		return v.verifyPresignV4(req, query)
+	case hasParam(query, ParamSignature) || hasParam(query, ParamAWSAccessKeyID):
+		return v.verifyPresignV2(req, query)
+	}
+	return nil, newVerifyError(ErrCodeAccessDenied, "", "the request is not signed")
+}
+
+func hasParam(params []queryParam, key string) bool {
+	for _, p := range params {
+		if p.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+func getParam(params []queryParam, key string) string {
+	for _, p := range params {
+		if p.Key == key {
+			return p.Value
+		}
+	}
+	return ""
+}
+
+// pathCandidates returns the request, and a copy without the trailing slash of the path for the IP endpoint quirk
+func pathCandidates(req *http.Request) []*http.Request {
+	reqs := []*http.Request{req}
+	path := req.URL.Path
+	if len(path) > 1 && strings.HasSuffix(path, "/") {
+		u := *req.URL
+		u.Path = strings.TrimSuffix(path, "/")
+		u.RawPath = ""
+		r := *req
+		r.URL = &u
+		reqs = append(reqs, &r)
+	}
+	return reqs
+}
+
+func (v *Verifier) lookup(accessKeyID string) (string, error) {
+	secret, err := v.Lookup(accessKeyID)
+	if err != nil {
+		return "", &VerifyError{Code: ErrCodeInvalidAccessKeyID, AccessKeyID: accessKeyID, Message: err.Error()}
+	}
+	if secret == "" {
+		return "", newVerifyError(ErrCodeInvalidAccessKeyID, accessKeyID, "the access key id does not exist")
+	}
+	return secret, nil
+}
+
+// checkSkew checks the difference between the signing time and the verifier clock
+func (v *Verifier) checkSkew(t time.Time, accessKeyID string) error {
+	skew := v.now().Sub(t)
+	if skew < 0 {
+		skew = -skew
+	}
+	if skew > v.maxSkew() {
+		return newVerifyError(ErrCodeRequestTimeTooSkewed, accessKeyID,
+			"the difference between the request time %s and the current time is too large", t.UTC().Format(TimeFormatV4))
+	}
+	return nil
+}
+
+func signatureEqual(a, b string) bool {
+	return hmac.Equal([]byte(a), []byte(b))
+}
+
+// credentialV4 is the parsed X-Amz-Credential, "<id>/<date>/<region>/<service>/aws4_request"
+type credentialV4 struct {
+	AccessKeyID, Date, Region, Service string
+}
+
+func parseCredentialV4(credential string) (credentialV4, bool) {
+	parts := strings.Split(credential, "/")
+	if len(parts) != 5 || parts[0] == "" || parts[4] != scopeTerminator {
+		return credentialV4{}, false
+	}
+	return credentialV4{AccessKeyID: parts[0], Date: parts[1], Region: parts[2], Service: parts[3]}, true
+}
+
+// payloadHashV4 returns X-Amz-Content-Sha256, or the hash of the body if it's not set
+func payloadHashV4(req *http.Request) (string, error) {
+	if h := req.Header.Get(HeaderContentSHA256); h != "" {
+		return h, nil
+	}
+	if req.Body == nil || req.Body == http.NoBody {
+		return EmptyPayloadHash, nil
+	}
+	data, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return "", err
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(data))
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// matchV4 compares the signature with the ones of the path candidates, the error has the details of the first one
+func matchV4(req *http.Request, cred credentialV4, secret string, t time.Time, signedHeaders []string,
+	payloadHash, signature string) error {
+	var verr *VerifyError
+	scope := cred.Date + "/" + cred.Region + "/" + cred.Service + "/" + scopeTerminator
+	key := SigningKeyV4(secret, t, cred.Region, cred.Service)
+	for _, r := range pathCandidates(req) {
+		canonicalRequest := CanonicalRequestV4(r, signedHeaders, payloadHash)
+		stringToSign := StringToSignV4(t, scope, canonicalRequest)
+		expected := SignatureV4(key, stringToSign)
+		if signatureEqual(expected, signature) {
+			return nil
+		}
+		if verr == nil {
+			verr = &VerifyError{Code: ErrCodeSignatureDoesNotMatch, AccessKeyID: cred.AccessKeyID,
+				Message:  "the request signature we calculated does not match the signature you provided",
+				Provided: signature, Expected: expected, StringToSign: stringToSign, CanonicalRequest: canonicalRequest}
+		}
+	}
+	return verr
+}
+
+func (v *Verifier) verifyHeaderV4(req *http.Request, auth string) (*VerifyResult, error) {
+	fields := map[string]string{}
+	for _, kv := range strings.Split(auth, ",") {
+		kv = strings.TrimSpace(kv)
+		if i := strings.Index(kv, "="); i > 0 {
+			fields[kv[:i]] = kv[i+1:]
+		}
+	}
+	cred, ok := parseCredentialV4(fields["Credential"])
+	if !ok || fields["SignedHeaders"] == "" || fields["Signature"] == "" {
+		return nil, newVerifyError(ErrCodeAuthorizationMalformed, cred.AccessKeyID, "the authorization header is malformed")
+	}
+	signedHeaders := strings.Split(fields["SignedHeaders"], ";")
+	if !containsString(signedHeaders, "host") {
+		return nil, newVerifyError(ErrCodeAuthorizationMalformed, cred.AccessKeyID, "the host header is not signed")
+	}
+
+	t, err := time.Parse(TimeFormatV4, req.Header.Get(HeaderAmzDate))
+	if err != nil {
+		return nil, newVerifyError(ErrCodeAccessDenied, cred.AccessKeyID, "invalid or missing %s header", HeaderAmzDate)
+	}
+	if t.Format(DateFormatV4) != cred.Date {
+		return nil, newVerifyError(ErrCodeAuthorizationMalformed, cred.AccessKeyID,
+			"the credential date %s does not match %s", cred.Date, t.Format(DateFormatV4))
+	}
+
+	secret, err := v.lookup(cred.AccessKeyID)
+	if err != nil {
+		return nil, err
+	}
+	payloadHash, err := payloadHashV4(req)
+	if err != nil {
+		return nil, err
+	}
+	if err = matchV4(req, cred, secret, t, signedHeaders, payloadHash, fields["Signature"]); err != nil {
+		return nil, err
+	}
+	if err = v.checkSkew(t, cred.AccessKeyID); err != nil {
+		return nil, err
+	}
+
+	return &VerifyResult{
+		AccessKeyID:   cred.AccessKeyID,
+		SecurityToken: req.Header.Get(HeaderSecurityToken),
+		Version:       "V4",
+		SignedAt:      t,
+		Region:        cred.Region,
+		Service:       cred.Service,
+		SignedHeaders: signedHeaders,
+	}, nil
+}
+
+func (v *Verifier) verifyPresignV4(req *http.Request, query []queryParam) (*VerifyResult, error) {
+	cred, ok := parseCredentialV4(getParam(query, ParamCredential))
+	if !ok {
+		return nil, newVerifyError(ErrCodeAuthorizationQueryInvalid, "", "invalid %s", ParamCredential)
+	}
+	if alg := getParam(query, ParamAlgorithm); alg != AlgorithmV4 {
+		return nil, newVerifyError(ErrCodeAuthorizationQueryInvalid, cred.AccessKeyID, "unsupported %s %q", ParamAlgorithm, alg)
+	}
+	t, err := time.Parse(TimeFormatV4, getParam(query, ParamDate))
+	if err != nil {
+		return nil, newVerifyError(ErrCodeAuthorizationQueryInvalid, cred.AccessKeyID, "invalid %s", ParamDate)
+	}
+	if t.Format(DateFormatV4) != cred.Date {
+		return nil, newVerifyError(ErrCodeAuthorizationQueryInvalid, cred.AccessKeyID,
+			"the credential date %s does not match %s", cred.Date, t.Format(DateFormatV4))
+	}
+	expiresSec, err := strconv.ParseInt(getParam(query, ParamExpiresV4), 10, 64)
+	if err != nil || expiresSec < 1 || expiresSec > 7*24*3600 {
+		return nil, newVerifyError(ErrCodeAuthorizationQueryInvalid, cred.AccessKeyID,
+			"%s must be a number between 1 and 604800", ParamExpiresV4)
+	}
+	signedHeaders := strings.Split(getParam(query, ParamSignedHeaders), ";")
+	signature := getParam(query, ParamSignatureV4)
+	if !containsString(signedHeaders, "host") || signature == "" {
+		return nil, newVerifyError(ErrCodeAuthorizationQueryInvalid, cred.AccessKeyID,
+			"%s and %s with host are required", ParamSignatureV4, ParamSignedHeaders)
+	}
+
+	secret, err := v.lookup(cred.AccessKeyID)
+	if err != nil {
+		return nil, err
+	}
+	if err = matchV4(req, cred, secret, t, signedHeaders, UnsignedPayload, signature); err != nil {
+		return nil, err
+	}
+
+	now := v.now()
+	expires := t.Add(time.Duration(expiresSec) * time.Second)
+	if t.Sub(now) > v.maxSkew() {
+		return nil, newVerifyError(ErrCodeRequestTimeTooSkewed, cred.AccessKeyID, "the request is signed in the future")
+	}
+	if now.After(expires) {
+		return nil, newVerifyError(ErrCodeAccessDenied, cred.AccessKeyID, "request has expired at %s", expires.UTC().Format(TimeFormatV4))
+	}
+
+	return &VerifyResult{
+		AccessKeyID:   cred.AccessKeyID,
+		SecurityToken: getParam(query, ParamSecurityTokenV4),
+		Version:       "V4",
+		Presigned:     true,
+		SignedAt:      t,
+		Expires:       expires,
+		Region:        cred.Region,
+		Service:       cred.Service,
+		SignedHeaders: signedHeaders,
+	}, nil
+}
+
+// matchV2 compares the signature with the ones of the path candidates, the error has the details of the first one
+func matchV2(req *http.Request, accessKeyID, secret, date, signature string) error {
+	var verr *VerifyError
+	for _, r := range pathCandidates(req) {
+		stringToSign := StringToSignV2(r, date)
+		expected := SignatureV2(secret, stringToSign)
+		if signatureEqual(expected, signature) {
+			return nil
+		}
+		if verr == nil {
+			verr = &VerifyError{Code: ErrCodeSignatureDoesNotMatch, AccessKeyID: accessKeyID,
+				Message:  "the request signature we calculated does not match the signature you provided",
+				Provided: signature, Expected: expected, StringToSign: stringToSign}
+		}
+	}
+	return verr
+}
+
+func (v *Verifier) verifyHeaderV2(req *http.Request, auth string) (*VerifyResult, error) {
+	i := strings.LastIndex(auth, ":")
+	if i <= 0 || i == len(auth)-1 {
+		return nil, newVerifyError(ErrCodeAuthorizationMalformed, "", "the authorization header is malformed")
+	}
+	accessKeyID, signature := auth[:i], auth[i+1:]
+
+	date := req.Header.Get(HeaderDate)
+	t, err := http.ParseTime(date)
+	if err != nil {
+		return nil, newVerifyError(ErrCodeAccessDenied, accessKeyID, "invalid or missing %s header", HeaderDate)
+	}
+
+	secret, err := v.lookup(accessKeyID)
+	if err != nil {
+		return nil, err
+	}
+	if err = matchV2(req, accessKeyID, secret, date, signature); err != nil {
+		return nil, err
+	}
+	if err = v.checkSkew(t, accessKeyID); err != nil {
+		return nil, err
+	}
+
+	return &VerifyResult{
+		AccessKeyID:   accessKeyID,
+		SecurityToken: req.Header.Get(HeaderSecurityToken),
+		Version:       "V2",
+		SignedAt:      t,
+	}, nil
+}
+
+func (v *Verifier) verifyPresignV2(req *http.Request, query []queryParam) (*VerifyResult, error) {
+	accessKeyID := getParam(query, ParamAWSAccessKeyID)
+	// "+" of an unescaped base64 signature is decoded as a space
+	signature := strings.Replace(getParam(query, ParamSignature), " ", "+", -1)
+	expiresStr := getParam(query, ParamExpires)
+	if accessKeyID == "" || signature == "" {
+		return nil, newVerifyError(ErrCodeAuthorizationQueryInvalid, accessKeyID,
+			"%s and %s are required", ParamAWSAccessKeyID, ParamSignature)
+	}
+	expiresUnix, err := strconv.ParseInt(expiresStr, 10, 64)
+	if err != nil {
+		return nil, newVerifyError(ErrCodeAuthorizationQueryInvalid, accessKeyID, "invalid %s", ParamExpires)
+	}
+
+	secret, err := v.lookup(accessKeyID)
+	if err != nil {
+		return nil, err
+	}
+	if err = matchV2(req, accessKeyID, secret, expiresStr, signature); err != nil {
+		return nil, err
+	}
+
+	expires := time.Unix(expiresUnix, 0)
+	if v.now().After(expires) {
+		return nil, newVerifyError(ErrCodeAccessDenied, accessKeyID, "request has expired at %s", expires.UTC().Format(TimeFormatV4))
+	}
+
+	return &VerifyResult{
+		AccessKeyID:   accessKeyID,
+		SecurityToken: getParam(query, ParamSecurityToken),
+		Version:       "V2",
+		Presigned:     true,
+		Expires:       expires,
+	}, nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
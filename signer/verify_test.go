@@ -0,0 +1,229 @@
+package signer
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var verifyTime = time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+
+func testVerifier(now time.Time) *Verifier {
+	v := NewVerifier(func(accessKeyID string) (string, error) {
+		if accessKeyID == exampleCreds.AccessKeyID {
+			return exampleCreds.AccessKeySecret, nil
+		}
+		return "", errors.New("unknown access key " + accessKeyID)
+	})
+	v.Now = func() time.Time { return now }
+	return v
+}
+
+// incoming returns the request as received by the server from the signed URL and headers
+func incoming(method, signedURL string, header http.Header) *http.Request {
+	req := httptest.NewRequest(method, signedURL, nil)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	return req
+}
+
+// The signed requests and URLs are verified with the escaped keys, the subresources and the virtual-host style
+var verifyURLs = []string{
+	"http://oos-cn.ctyunapi.cn/bucket/photos/puppy.jpg",
+	"http://oos-cn.ctyunapi.cn/bucket/a%20b/%C3%BC%2B%25.txt",
+	"http://oos-cn.ctyunapi.cn/bucket/key?uploadId=x%2By&partNumber=2",
+	"http://oos-cn.ctyunapi.cn/bucket/?acl",
+	"http://oos-cn.ctyunapi.cn/bucket/key?retention&prefix=a%2Fb",
+	"http://bucket.oos-cn.ctyunapi.cn/dir/key~1.txt",
+	"http://bucket.oos-cn.ctyunapi.cn/?lifecycle",
+}
+
+func TestVerifySignV4(t *testing.T) {
+	v := testVerifier(verifyTime.Add(time.Minute))
+	for _, u := range verifyURLs {
+		req, _ := http.NewRequest("PUT", u, nil)
+		req.Header.Set(HeaderContentType, "text/plain")
+		req.Header.Set("X-Amz-Meta-Name", "value")
+		if err := SignV4(req, exampleCreds, "cn", "s3", EmptyPayloadHash, verifyTime); err != nil {
+			t.Fatal(err)
+		}
+		res, err := v.Verify(incoming("PUT", u, req.Header))
+		if err != nil {
+			t.Errorf("%s: %v", u, err)
+			continue
+		}
+		if res.AccessKeyID != exampleCreds.AccessKeyID || res.Version != "V4" || res.Presigned ||
+			res.Region != "cn" || res.Service != "s3" || !res.SignedAt.Equal(verifyTime) {
+			t.Errorf("%s: result %+v", u, res)
+		}
+	}
+}
+
+func TestVerifyPresignV4(t *testing.T) {
+	v := testVerifier(verifyTime.Add(time.Hour))
+	for _, u := range verifyURLs {
+		req, _ := http.NewRequest("GET", u, nil)
+		signed, err := PresignV4(req, exampleCreds, "cn", "s3", verifyTime, 2*time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := v.Verify(incoming("GET", signed, nil))
+		if err != nil {
+			t.Errorf("%s: %v", signed, err)
+			continue
+		}
+		if !res.Presigned || res.Version != "V4" || !res.Expires.Equal(verifyTime.Add(2*time.Hour)) {
+			t.Errorf("%s: result %+v", signed, res)
+		}
+	}
+}
+
+func TestVerifySignV2(t *testing.T) {
+	v := testVerifier(verifyTime.Add(-time.Minute))
+	for _, u := range verifyURLs {
+		req, _ := http.NewRequest("PUT", u, nil)
+		req.Header.Set(HeaderDate, verifyTime.Format(http.TimeFormat))
+		req.Header.Set(HeaderContentType, "text/plain")
+		req.Header.Set("X-Amz-Meta-Name", "value")
+		if err := SignV2(req, exampleCreds); err != nil {
+			t.Fatal(err)
+		}
+		res, err := v.Verify(incoming("PUT", u, req.Header))
+		if err != nil {
+			t.Errorf("%s: %v", u, err)
+			continue
+		}
+		if res.AccessKeyID != exampleCreds.AccessKeyID || res.Version != "V2" || res.Presigned {
+			t.Errorf("%s: result %+v", u, res)
+		}
+	}
+}
+
+func TestVerifyPresignV2(t *testing.T) {
+	v := testVerifier(verifyTime)
+	for _, u := range verifyURLs {
+		req, _ := http.NewRequest("GET", u, nil)
+		signed, err := PresignV2(req, exampleCreds, verifyTime.Add(time.Hour))
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := v.Verify(incoming("GET", signed, nil))
+		if err != nil {
+			t.Errorf("%s: %v", signed, err)
+			continue
+		}
+		if !res.Presigned || res.Version != "V2" || !res.Expires.Equal(verifyTime.Add(time.Hour)) {
+			t.Errorf("%s: result %+v", signed, res)
+		}
+	}
+}
+
+// The bucket-level requests to IP endpoints are sent to /bucket/ but signed as /bucket
+func TestVerifyTrailingSlash(t *testing.T) {
+	req, _ := http.NewRequest("GET", "http://10.0.0.1/bucket?acl", nil)
+	req.Header.Set(HeaderDate, verifyTime.Format(http.TimeFormat))
+	if err := SignV2(req, exampleCreds); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := testVerifier(verifyTime).Verify(incoming("GET", "http://10.0.0.1/bucket/?acl", req.Header)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestVerifyFailures(t *testing.T) {
+	const u = "http://oos-cn.ctyunapi.cn/bucket/key"
+	unknown := Credentials{AccessKeyID: "AKIDUNKNOWN", AccessKeySecret: "secret"}
+
+	signV4 := func(creds Credentials, at time.Time) *http.Request {
+		req, _ := http.NewRequest("GET", u, nil)
+		SignV4(req, creds, "cn", "s3", EmptyPayloadHash, at)
+		return incoming("GET", u, req.Header)
+	}
+	signV2 := func(creds Credentials, at time.Time) *http.Request {
+		req, _ := http.NewRequest("GET", u, nil)
+		req.Header.Set(HeaderDate, at.Format(http.TimeFormat))
+		SignV2(req, creds)
+		return incoming("GET", u, req.Header)
+	}
+	presignV4 := func(at time.Time, expires time.Duration) *http.Request {
+		req, _ := http.NewRequest("GET", u, nil)
+		signed, _ := PresignV4(req, exampleCreds, "cn", "s3", at, expires)
+		return incoming("GET", signed, nil)
+	}
+	presignV2 := func(expires time.Time) *http.Request {
+		req, _ := http.NewRequest("GET", u, nil)
+		signed, _ := PresignV2(req, exampleCreds, expires)
+		return incoming("GET", signed, nil)
+	}
+	tamper := func(req *http.Request) *http.Request {
+		auth := req.Header.Get(HeaderAuthorization)
+		last := auth[len(auth)-2:]
+		if last[0] == 'A' {
+			last = "B" + last[1:]
+		} else {
+			last = "A" + last[1:]
+		}
+		req.Header.Set(HeaderAuthorization, auth[:len(auth)-2]+last)
+		return req
+	}
+
+	cases := []struct {
+		name string
+		req  *http.Request
+		code string
+	}{
+		{"V4 tampered signature", tamper(signV4(exampleCreds, verifyTime)), ErrCodeSignatureDoesNotMatch},
+		{"V2 tampered signature", tamper(signV2(exampleCreds, verifyTime)), ErrCodeSignatureDoesNotMatch},
+		{"V4 tampered path", func() *http.Request {
+			req := signV4(exampleCreds, verifyTime)
+			return incoming("GET", u+"2", req.Header)
+		}(), ErrCodeSignatureDoesNotMatch},
+		{"V4 presigned tampered query", func() *http.Request {
+			req := presignV4(verifyTime, time.Hour)
+			req.URL.RawQuery = strings.Replace(req.URL.RawQuery, "X-Amz-Expires=3600", "X-Amz-Expires=7200", 1)
+			return req
+		}(), ErrCodeSignatureDoesNotMatch},
+		{"V4 skew", signV4(exampleCreds, verifyTime.Add(-16*time.Minute)), ErrCodeRequestTimeTooSkewed},
+		{"V4 future skew", signV4(exampleCreds, verifyTime.Add(16*time.Minute)), ErrCodeRequestTimeTooSkewed},
+		{"V2 skew", signV2(exampleCreds, verifyTime.Add(-time.Hour)), ErrCodeRequestTimeTooSkewed},
+		{"V4 presigned in the future", presignV4(verifyTime.Add(time.Hour), time.Hour), ErrCodeRequestTimeTooSkewed},
+		{"V4 presigned expired", presignV4(verifyTime.Add(-2*time.Hour), time.Hour), ErrCodeAccessDenied},
+		{"V2 presigned expired", presignV2(verifyTime.Add(-time.Second)), ErrCodeAccessDenied},
+		{"V4 unknown key", signV4(unknown, verifyTime), ErrCodeInvalidAccessKeyID},
+		{"V2 unknown key", signV2(unknown, verifyTime), ErrCodeInvalidAccessKeyID},
+		{"not signed", incoming("GET", u, nil), ErrCodeAccessDenied},
+	}
+
+	v := testVerifier(verifyTime)
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := v.Verify(c.req)
+			verr, ok := err.(*VerifyError)
+			if !ok {
+				t.Fatalf("error %v, want %s", err, c.code)
+			}
+			if verr.Code != c.code {
+				t.Errorf("code %s (%s), want %s", verr.Code, verr.Message, c.code)
+			}
+		})
+	}
+}
+
+func TestVerifyMaxSkew(t *testing.T) {
+	req, _ := http.NewRequest("GET", "http://oos-cn.ctyunapi.cn/bucket/key", nil)
+	SignV4(req, exampleCreds, "cn", "s3", EmptyPayloadHash, verifyTime)
+
+	v := testVerifier(verifyTime.Add(10 * time.Minute))
+	if _, err := v.Verify(incoming("GET", req.URL.String(), req.Header)); err != nil {
+		t.Errorf("10 minutes with the default skew: %v", err)
+	}
+	v.MaxSkew = 5 * time.Minute
+	_, err := v.Verify(incoming("GET", req.URL.String(), req.Header))
+	if verr, ok := err.(*VerifyError); !ok || verr.Code != ErrCodeRequestTimeTooSkewed {
+		t.Errorf("10 minutes with 5 minutes skew: %v", err)
+	}
+}